@@ -20,6 +20,7 @@ import (
 	pciaddress "github.com/jaypipes/ghw/pkg/pci/address"
 	"github.com/jaypipes/ghw/pkg/product"
 	"github.com/jaypipes/ghw/pkg/topology"
+	"github.com/jaypipes/ghw/pkg/udev"
 )
 
 type WithOption = option.Option
@@ -33,6 +34,10 @@ var (
 	WithDisableWarnings = option.WithNullAlerter
 	WithDisableTools    = option.WithDisableTools
 	WithPathOverrides   = option.WithPathOverrides
+	// include loop, zram and ram devices in the block storage information
+	WithVirtualBlockDevices = option.WithVirtualBlockDevices
+	// read the SMART health of the disks
+	WithDiskHealth = option.WithDiskHealth
 )
 
 type SnapshotOptions = option.SnapshotOptions
@@ -40,9 +45,42 @@ type SnapshotOptions = option.SnapshotOptions
 type PathOverrides = option.PathOverrides
 
 type CPUInfo = cpu.Info
+type LogicalProcessor = cpu.LogicalProcessor
+type Frequency = cpu.Frequency
+type FrequencySummary = cpu.FrequencySummary
+type IdleState = cpu.IdleState
+type BoostState = cpu.BoostState
+type CoreType = cpu.CoreType
+type CPUSets = cpu.Sets
+type CPUVulnerability = cpu.Vulnerability
+type CPUVulnerabilityStatus = cpu.VulnerabilityStatus
+type X86Identification = cpu.X86Identification
+type ArmIdentification = cpu.ArmIdentification
+type CPUFeatures = cpu.Features
+
+const (
+	BOOST_STATE_UNKNOWN  = cpu.BOOST_STATE_UNKNOWN
+	BOOST_STATE_ENABLED  = cpu.BOOST_STATE_ENABLED
+	BOOST_STATE_DISABLED = cpu.BOOST_STATE_DISABLED
+)
+
+const (
+	CORE_TYPE_UNKNOWN     = cpu.CORE_TYPE_UNKNOWN
+	CORE_TYPE_PERFORMANCE = cpu.CORE_TYPE_PERFORMANCE
+	CORE_TYPE_EFFICIENCY  = cpu.CORE_TYPE_EFFICIENCY
+)
+
+const (
+	CPU_VULNERABILITY_STATUS_UNKNOWN      = cpu.VULNERABILITY_STATUS_UNKNOWN
+	CPU_VULNERABILITY_STATUS_NOT_AFFECTED = cpu.VULNERABILITY_STATUS_NOT_AFFECTED
+	CPU_VULNERABILITY_STATUS_VULNERABLE   = cpu.VULNERABILITY_STATUS_VULNERABLE
+	CPU_VULNERABILITY_STATUS_MITIGATED    = cpu.VULNERABILITY_STATUS_MITIGATED
+)
 
 var (
-	CPU = cpu.New
+	CPU                = cpu.New
+	ArmImplementerName = cpu.ArmImplementerName
+	ArmPartName        = cpu.ArmPartName
 )
 
 type MemoryInfo = memory.Info
@@ -62,9 +100,39 @@ var (
 type BlockInfo = block.Info
 type Disk = block.Disk
 type Partition = block.Partition
+type MappedDevice = block.MappedDevice
+type MDArray = block.MDArray
+type MDMember = block.MDMember
+type NVMeController = block.NVMeController
+type NVMeNamespace = block.NVMeNamespace
+type NVMePath = block.NVMePath
+type SCSIAddress = block.SCSIAddress
+type SCSIHost = block.SCSIHost
+type ATAPort = block.ATAPort
+type ATALink = block.ATALink
+type ATADevice = block.ATADevice
+type FCHost = block.FCHost
+type ISCSISession = block.ISCSISession
+type ISCSILUN = block.ISCSILUN
+type BtrfsFilesystem = block.BtrfsFilesystem
+type BtrfsAllocation = block.BtrfsAllocation
+type ZFSPool = block.ZFSPool
+type ZFSVdev = block.ZFSVdev
+type IOStats = block.IOStats
+type IOStatsSample = block.IOStatsSample
+type IORates = block.IORates
+type IORatesSample = block.IORatesSample
+type Queue = block.Queue
+type MountInfo = block.MountInfo
+type MountUsage = block.MountUsage
+type Health = block.Health
+type LoopDevice = block.LoopDevice
+type ZramDevice = block.ZramDevice
+type PathChain = block.PathChain
 
 var (
-	Block = block.New
+	Block       = block.New
+	ResolvePath = block.ResolvePath
 )
 
 type DriveType = block.DriveType
@@ -88,6 +156,53 @@ const (
 	STORAGE_CONTROLLER_MMC     = block.STORAGE_CONTROLLER_MMC
 )
 
+type Transport = block.Transport
+
+const (
+	TRANSPORT_UNKNOWN   = block.TRANSPORT_UNKNOWN
+	TRANSPORT_ATA       = block.TRANSPORT_ATA
+	TRANSPORT_SAS       = block.TRANSPORT_SAS
+	TRANSPORT_USB       = block.TRANSPORT_USB
+	TRANSPORT_FC        = block.TRANSPORT_FC
+	TRANSPORT_ISCSI     = block.TRANSPORT_ISCSI
+	TRANSPORT_VIRTIO    = block.TRANSPORT_VIRTIO
+	TRANSPORT_NVME_PCIE = block.TRANSPORT_NVME_PCIE
+	TRANSPORT_NVME_TCP  = block.TRANSPORT_NVME_TCP
+	TRANSPORT_MMC       = block.TRANSPORT_MMC
+	TRANSPORT_XEN       = block.TRANSPORT_XEN
+)
+
+type MappedDeviceKind = block.MappedDeviceKind
+
+const (
+	MAPPED_DEVICE_KIND_UNKNOWN   = block.MAPPED_DEVICE_KIND_UNKNOWN
+	MAPPED_DEVICE_KIND_LINEAR    = block.MAPPED_DEVICE_KIND_LINEAR
+	MAPPED_DEVICE_KIND_LVM       = block.MAPPED_DEVICE_KIND_LVM
+	MAPPED_DEVICE_KIND_CRYPT     = block.MAPPED_DEVICE_KIND_CRYPT
+	MAPPED_DEVICE_KIND_MULTIPATH = block.MAPPED_DEVICE_KIND_MULTIPATH
+	MAPPED_DEVICE_KIND_THIN      = block.MAPPED_DEVICE_KIND_THIN
+)
+
+type NVMeTransport = block.NVMeTransport
+
+const (
+	NVME_TRANSPORT_UNKNOWN = block.NVME_TRANSPORT_UNKNOWN
+	NVME_TRANSPORT_PCIE    = block.NVME_TRANSPORT_PCIE
+	NVME_TRANSPORT_TCP     = block.NVME_TRANSPORT_TCP
+	NVME_TRANSPORT_RDMA    = block.NVME_TRANSPORT_RDMA
+	NVME_TRANSPORT_FC      = block.NVME_TRANSPORT_FC
+	NVME_TRANSPORT_LOOP    = block.NVME_TRANSPORT_LOOP
+)
+
+type ZonedModel = block.ZonedModel
+
+const (
+	ZONED_MODEL_UNKNOWN      = block.ZONED_MODEL_UNKNOWN
+	ZONED_MODEL_NONE         = block.ZONED_MODEL_NONE
+	ZONED_MODEL_HOST_AWARE   = block.ZONED_MODEL_HOST_AWARE
+	ZONED_MODEL_HOST_MANAGED = block.ZONED_MODEL_HOST_MANAGED
+)
+
 type NetworkInfo = net.Info
 type NIC = net.NIC
 type NICCapability = net.NICCapability
@@ -149,3 +264,10 @@ type GraphicsCard = gpu.GraphicsCard
 var (
 	GPU = gpu.New
 )
+
+type UdevInfo = udev.Info
+type UdevDevice = udev.Device
+
+var (
+	Udev = udev.New
+)
@@ -8,12 +8,19 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jaypipes/ghw"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+// virtualBlockDevices requests loop, zram and ram devices to be shown
+var virtualBlockDevices bool
+
+// diskHealth requests the SMART health of the disks to be shown
+var diskHealth bool
+
 // blockCmd represents the install command
 var blockCmd = &cobra.Command{
 	Use:   "block",
@@ -23,7 +30,14 @@ var blockCmd = &cobra.Command{
 
 // showBlock show block storage information for the host system.
 func showBlock(cmd *cobra.Command, args []string) error {
-	block, err := ghw.Block()
+	opts := make([]*ghw.WithOption, 0)
+	if virtualBlockDevices {
+		opts = append(opts, ghw.WithVirtualBlockDevices())
+	}
+	if diskHealth {
+		opts = append(opts, ghw.WithDiskHealth())
+	}
+	block, err := ghw.Block(opts...)
 	if err != nil {
 		return errors.Wrap(err, "error getting block device info")
 	}
@@ -34,10 +48,61 @@ func showBlock(cmd *cobra.Command, args []string) error {
 
 		for _, disk := range block.Disks {
 			fmt.Printf(" %v\n", disk)
+			if disk.Health != nil {
+				fmt.Printf("  %v\n", disk.Health)
+			}
+			if disk.Loop != nil {
+				fmt.Printf("  %v\n", disk.Loop)
+			}
+			if disk.Zram != nil {
+				fmt.Printf("  %v\n", disk.Zram)
+			}
+			if disk.ATADevice != nil {
+				fmt.Printf("  %v\n", disk.ATADevice)
+			}
 			for _, part := range disk.Partitions {
 				fmt.Printf("  %v\n", part)
 			}
 		}
+		for _, mapped := range block.MappedDevices {
+			fmt.Printf(" %v\n", mapped)
+		}
+		for _, ctrl := range block.NVMeControllers {
+			fmt.Printf(" %v\n", ctrl)
+		}
+		for _, host := range block.SCSIHosts {
+			fmt.Printf(" %v\n", host)
+		}
+		for _, port := range block.ATAPorts {
+			fmt.Printf(" %v\n", port)
+			for _, link := range port.Links {
+				fmt.Printf("  %v\n", link)
+			}
+		}
+		for _, host := range block.FCHosts {
+			fmt.Printf(" %v\n", host)
+		}
+		for _, session := range block.ISCSISessions {
+			fmt.Printf(" %v\n", session)
+			for _, lun := range session.LUNs {
+				fmt.Printf("  LUN %d: %s\n", lun.LUN, lun.DiskName)
+			}
+		}
+		for _, fs := range block.BtrfsFilesystems {
+			fmt.Printf(" %v\n", fs)
+		}
+		for _, pool := range block.ZFSPools {
+			fmt.Printf(" %v\n", pool)
+			for _, vdev := range pool.Vdevs {
+				fmt.Printf("  %v\n", vdev)
+			}
+		}
+		for _, array := range block.MDArrays {
+			fmt.Printf(" %v\n", array)
+			for _, member := range array.Members {
+				fmt.Printf("  %s slot=%d state=%s\n", member.Name, member.Slot, strings.Join(member.State, ","))
+			}
+		}
 	case outputFormatJSON:
 		fmt.Printf("%s\n", block.JSONString(pretty))
 	case outputFormatYAML:
@@ -47,5 +112,11 @@ func showBlock(cmd *cobra.Command, args []string) error {
 }
 
 func init() {
+	blockCmd.Flags().BoolVar(
+		&virtualBlockDevices, "virtual", false, "Show loop, zram and ram devices too",
+	)
+	blockCmd.Flags().BoolVar(
+		&diskHealth, "health", false, "Show the SMART health of the disks too",
+	)
 	rootCmd.AddCommand(blockCmd)
 }
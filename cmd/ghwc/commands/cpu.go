@@ -33,12 +33,27 @@ func showCPU(cmd *cobra.Command, args []string) error {
 	switch outputFormat {
 	case outputFormatHuman:
 		fmt.Printf("%v\n", cpu)
+		if cpu.Sets != nil {
+			fmt.Printf(" sets: %v\n", cpu.Sets)
+		}
 
 		for _, proc := range cpu.Processors {
 			fmt.Printf(" %v\n", proc)
+			if proc.X86 != nil {
+				fmt.Printf("  x86: %v\n", proc.X86)
+			}
+			if proc.Arm != nil {
+				fmt.Printf("  arm: %v\n", proc.Arm)
+			}
 			for _, core := range proc.Cores {
 				fmt.Printf("  %v\n", core)
 			}
+			if proc.Features != nil {
+				fmt.Printf("  features: %v\n", proc.Features)
+			}
+			if proc.Frequency != nil {
+				fmt.Printf("  frequency: %v\n", proc.Frequency)
+			}
 			if len(proc.Capabilities) > 0 {
 				// pretty-print the (large) block of capability strings into rows
 				// of 6 capability strings
@@ -58,6 +73,12 @@ func showCPU(cmd *cobra.Command, args []string) error {
 				}
 			}
 		}
+		if len(cpu.Vulnerabilities) > 0 {
+			fmt.Printf(" vulnerabilities:\n")
+			for _, v := range cpu.Vulnerabilities {
+				fmt.Printf("  %v\n", v)
+			}
+		}
 	case outputFormatJSON:
 		fmt.Printf("%s\n", cpu.JSONString(pretty))
 	case outputFormatYAML:
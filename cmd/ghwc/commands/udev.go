@@ -0,0 +1,51 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/jaypipes/ghw"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// udevCmd represents the install command
+var udevCmd = &cobra.Command{
+	Use:   "udev",
+	Short: "Show the udev database of the host system",
+	RunE:  showUdev,
+}
+
+// showUdev shows the udev database of the host system.
+func showUdev(cmd *cobra.Command, args []string) error {
+	udev, err := ghw.Udev()
+	if err != nil {
+		return errors.Wrap(err, "error getting udev info")
+	}
+
+	switch outputFormat {
+	case outputFormatHuman:
+		fmt.Printf("%v\n", udev)
+
+		for _, dev := range udev.Devices {
+			fmt.Printf(" %v\n", dev)
+			for _, link := range dev.Links {
+				fmt.Printf("  %s\n", link)
+			}
+		}
+	case outputFormatJSON:
+		fmt.Printf("%s\n", udev.JSONString(pretty))
+	case outputFormatYAML:
+		fmt.Printf("%s", udev.YAMLString())
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(udevCmd)
+}
@@ -11,9 +11,12 @@ import (
 	"math"
 	"strings"
 
+	"github.com/jaypipes/ghw/pkg/block/fsprobe"
+	"github.com/jaypipes/ghw/pkg/block/parttable"
 	"github.com/jaypipes/ghw/pkg/context"
 	"github.com/jaypipes/ghw/pkg/marshal"
 	"github.com/jaypipes/ghw/pkg/option"
+	"github.com/jaypipes/ghw/pkg/udev"
 	"github.com/jaypipes/ghw/pkg/unitutil"
 	"github.com/jaypipes/ghw/pkg/util"
 )
@@ -106,25 +109,143 @@ type Disk struct {
 	SerialNumber string       `json:"serial_number"`
 	WWN          string       `json:"wwn"`
 	Partitions   []*Partition `json:"partitions"`
-	MountInfo    *MountInfo   `json:"mount_info"`
-	// TODO(jaypipes): Add PCI field for accessing PCI device information
-	// PCI *PCIDevice `json:"pci"`
+	// PartitionTableType is the type of the partition table of the disk. It
+	// is only known when the disk's device node is readable.
+	PartitionTableType parttable.TableType `json:"partition_table_type,omitempty"`
+	// PartitionTableUUID is the GUID of a GPT disk, or the disk signature of
+	// an MBR disk
+	PartitionTableUUID string `json:"partition_table_uuid,omitempty"`
+	// TODO(jaypipes): Deprecate this field in favor of Mounts
+	MountInfo *MountInfo `json:"mount_info"`
+	// Mounts contains the places the disk is mounted in, in mount order. A
+	// disk may be mounted several times, e.g. through bind mounts.
+	Mounts []*MountInfo `json:"mounts,omitempty"`
+	// Filesystem describes the filesystem (or LVM physical volume, LUKS
+	// container...) found directly on a disk without partitions
+	Filesystem *fsprobe.Filesystem `json:"filesystem,omitempty"`
+	// Holders contains the kernel names of the block devices (e.g.
+	// device-mapper devices) built upon this disk
+	Holders []string `json:"holders,omitempty"`
+	// MDMember describes the membership of this disk in a software RAID
+	// array. It is nil if the disk is not an array member.
+	MDMember *MDMember `json:"md_member,omitempty"`
+	// NVMeNamespace describes the NVMe namespace backing this disk. It is
+	// nil for disks other than NVMe namespaces.
+	NVMeNamespace *NVMeNamespace `json:"nvme_namespace,omitempty"`
+	// Transport is the bus or fabric the disk is reached through, e.g. USB
+	// for a USB stick named like any SCSI disk
+	Transport Transport `json:"transport"`
+	// PCIAddress is the PCI address of the device the disk hangs off: its
+	// HBA, NVMe controller or virtio device. It is nil for disks without a
+	// PCI device, like NVMe over Fabrics namespaces.
+	PCIAddress *string `json:"pci_address,omitempty"`
+	// SCSIAddress is the host:channel:target:lun address of SCSI disks
+	SCSIAddress *SCSIAddress `json:"scsi_address,omitempty"`
+	// SCSIHost points to the SCSI host serving the disk
+	SCSIHost *SCSIHost `json:"-"`
+	// SASAddress and SASPort identify SAS end devices, e.g.
+	// "0x5000c500a1b2c3d4" and "port-2:0"
+	SASAddress string `json:"sas_address,omitempty"`
+	SASPort    string `json:"sas_port,omitempty"`
+	// Queue describes the request queue of the disk: its I/O limits,
+	// alignment and scheduling settings
+	Queue *Queue `json:"queue,omitempty"`
+	// Health describes the SMART health of the disk. It is only filled in
+	// when smartctl can be called (or when its output was recorded in a
+	// snapshot) and the disk supports SMART.
+	Health *Health `json:"health,omitempty"`
+	// IsVirtual is true for block devices with no physical backing, like
+	// loop, zram, ram, device-mapper and md devices
+	IsVirtual bool `json:"virtual"`
+	// Loop describes the file backing a loop device. Loop devices are only
+	// reported when requested with option.WithVirtualBlockDevices().
+	Loop *LoopDevice `json:"loop,omitempty"`
+	// Zram describes a compressed RAM block device
+	Zram *ZramDevice `json:"zram,omitempty"`
+	// ATADevice describes the ATA device backing a disk attached to an ATA
+	// port, with its link speed and firmware revision
+	ATADevice *ATADevice `json:"ata_device,omitempty"`
+	// Udev is the entry of the disk in the udev database, with all its
+	// properties and its /dev/disk/by-* symlinks. It is nil when udev is not
+	// running, e.g. in most containers.
+	Udev *udev.Device `json:"udev,omitempty"`
+	// BtrfsFilesystem and ZFSPool point to the multi-device btrfs filesystem
+	// or ZFS pool a disk without partitions belongs to
+	BtrfsFilesystem *BtrfsFilesystem `json:"-"`
+	ZFSPool         *ZFSPool         `json:"-"`
 }
 
 type MountInfo struct {
 	MountPoint string `json:"mount_point"`
 	Type       string `json:"type"`
 	ReadOnly   bool   `json:"read_only"`
+	// Usage describes how full the mounted filesystem is. It is nil when
+	// this cannot be found out, e.g. on operating systems other than Linux.
+	Usage *MountUsage `json:"usage,omitempty"`
+	// MountID and ParentID are the unique identifiers of the mount and of
+	// its parent mount
+	MountID  int `json:"mount_id,omitempty"`
+	ParentID int `json:"parent_id,omitempty"`
+	// MajorMinor contains the major:minor numbers of the mounted device, e.g.
+	// "8:1"
+	MajorMinor string `json:"major_minor,omitempty"`
+	// Root is the directory of the filesystem mounted on MountPoint, e.g.
+	// "/" for the whole filesystem, or the source directory of a bind mount
+	// or the path of a btrfs subvolume
+	Root string `json:"root,omitempty"`
+	// Source is the device the filesystem was mounted from, as given to
+	// mount, e.g. "/dev/sda1" or "/dev/mapper/vg0-root"
+	Source string `json:"source,omitempty"`
+	// Options contains the per-mount options, e.g. "rw" or "noatime"
+	Options []string `json:"options,omitempty"`
+	// Propagation contains the propagation fields of the mount, e.g.
+	// "shared:1" or "master:2". It is empty for private mounts.
+	Propagation []string `json:"propagation,omitempty"`
+	// SuperOptions contains the per-filesystem options, shared by all the
+	// mounts of the filesystem
+	SuperOptions []string `json:"super_options,omitempty"`
 }
 
 // Partition describes a logical division of a Disk.
 type Partition struct {
-	Disk      *Disk      `json:"-"`
-	Name      string     `json:"name"`
-	Label     string     `json:"label"`
-	SizeBytes uint64     `json:"size_bytes"`
-	UUID      string     `json:"uuid"` // This would be volume UUID on macOS, PartUUID on linux, empty on Windows
+	Disk      *Disk  `json:"-"`
+	Name      string `json:"name"`
+	Label     string `json:"label"`
+	SizeBytes uint64 `json:"size_bytes"`
+	UUID      string `json:"uuid"` // This would be volume UUID on macOS, PartUUID on linux, empty on Windows
+	// TODO(jaypipes): Deprecate this field in favor of Mounts
 	MountInfo *MountInfo `json:"mount_info"`
+	// Mounts contains the places the partition is mounted in, in mount
+	// order. A partition may be mounted several times, e.g. through bind
+	// mounts or as several btrfs subvolumes.
+	Mounts []*MountInfo `json:"mounts,omitempty"`
+	// TypeID is the partition type GUID on GPT disks, or the partition type
+	// byte (e.g. "0x83") on MBR disks
+	TypeID string `json:"type_id,omitempty"`
+	// TypeName is a human-readable name for TypeID, e.g. "EFI System"
+	TypeName string `json:"type_name,omitempty"`
+	// Attributes contains the GPT attribute flags of the partition. See the
+	// parttable.ATTRIBUTE_* constants.
+	Attributes uint64 `json:"attributes,omitempty"`
+	// FirstLBA and LastLBA delimit the partition on its disk, in logical
+	// blocks
+	FirstLBA uint64 `json:"first_lba,omitempty"`
+	LastLBA  uint64 `json:"last_lba,omitempty"`
+	// Filesystem describes the filesystem (or LVM physical volume, LUKS
+	// container...) found on the partition, or is nil
+	Filesystem *fsprobe.Filesystem `json:"filesystem,omitempty"`
+	// Holders contains the kernel names of the block devices (e.g.
+	// device-mapper devices) built upon this partition
+	Holders []string `json:"holders,omitempty"`
+	// MDMember describes the membership of this partition in a software RAID
+	// array. It is nil if the partition is not an array member.
+	MDMember *MDMember `json:"md_member,omitempty"`
+	// Udev is the entry of the partition in the udev database, or nil
+	Udev *udev.Device `json:"udev,omitempty"`
+	// BtrfsFilesystem and ZFSPool point to the multi-device btrfs filesystem
+	// or ZFS pool the partition belongs to
+	BtrfsFilesystem *BtrfsFilesystem `json:"-"`
+	ZFSPool         *ZFSPool         `json:"-"`
 }
 
 // Info describes all disk drives and partitions in the host system.
@@ -134,6 +255,29 @@ type Info struct {
 	TotalPhysicalBytes uint64       `json:"total_size_bytes"`
 	Disks              []*Disk      `json:"disks"`
 	Partitions         []*Partition `json:"-"`
+	// MappedDevices contains the device-mapper devices (LVM logical volumes,
+	// dm-crypt mappings, multipath maps...) found on the host
+	MappedDevices []*MappedDevice `json:"mapped_devices,omitempty"`
+	// MDArrays contains the Linux software RAID arrays found on the host
+	MDArrays []*MDArray `json:"md_arrays,omitempty"`
+	// NVMeControllers contains the NVMe controllers found on the host, both
+	// local PCIe controllers and NVMe over Fabrics connections
+	NVMeControllers []*NVMeController `json:"nvme_controllers,omitempty"`
+	// SCSIHosts contains the SCSI hosts (HBAs, RAID and SATA controllers...)
+	// serving the disks found on the host
+	SCSIHosts []*SCSIHost `json:"scsi_hosts,omitempty"`
+	// ATAPorts contains the ATA ports serving the disks found on the host
+	ATAPorts []*ATAPort `json:"ata_ports,omitempty"`
+	// FCHosts contains the Fibre Channel ports found on the host
+	FCHosts []*FCHost `json:"fc_hosts,omitempty"`
+	// ISCSISessions contains the iSCSI sessions of the host
+	ISCSISessions []*ISCSISession `json:"iscsi_sessions,omitempty"`
+	// BtrfsFilesystems contains the mounted btrfs filesystems
+	BtrfsFilesystems []*BtrfsFilesystem `json:"btrfs_filesystems,omitempty"`
+	// ZFSPools contains the imported ZFS pools, and ZFSVersion the version
+	// of the zfs module
+	ZFSPools   []*ZFSPool `json:"zfs_pools,omitempty"`
+	ZFSVersion string     `json:"zfs_version,omitempty"`
 }
 
 // New returns a pointer to an Info struct that describes the block storage
@@ -169,6 +313,9 @@ func (d *Disk) String() string {
 	if d.MountInfo != nil {
 		typeStr = fmt.Sprintf(" [%s]", d.MountInfo.Type)
 		mountStr = fmt.Sprintf(" mounted@%s", d.MountInfo.MountPoint)
+		if d.MountInfo.Usage != nil {
+			mountStr += fmt.Sprintf(" (%s)", d.MountInfo.Usage)
+		}
 	}
 
 	sizeStr := util.UNKNOWN
@@ -226,6 +373,9 @@ func (p *Partition) String() string {
 	if p.MountInfo != nil {
 		typeStr = fmt.Sprintf("[%s]", p.MountInfo.Type)
 		mountStr = fmt.Sprintf(" mounted@%s", p.MountInfo.MountPoint)
+		if p.MountInfo.Usage != nil {
+			mountStr += fmt.Sprintf(" (%s)", p.MountInfo.Usage)
+		}
 	}
 
 	sizeStr := util.UNKNOWN
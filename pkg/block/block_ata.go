@@ -0,0 +1,84 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+)
+
+// ATAPort describes a port of an ATA controller, e.g. a SATA port of an AHCI
+// controller
+type ATAPort struct {
+	// Name is the kernel name of the port, e.g. "ata1"
+	Name string `json:"name"`
+	// PortNumber is the number of the port on its controller
+	PortNumber int `json:"port_number"`
+	// Links contains the links of the port: a single one, or one for each
+	// port of a port multiplier
+	Links []*ATALink `json:"links"`
+}
+
+func (p *ATAPort) String() string {
+	return fmt.Sprintf("%s port_no=%d (%d links)", p.Name, p.PortNumber, len(p.Links))
+}
+
+// ATALink describes the link between an ATA port and the devices behind it
+type ATALink struct {
+	// Name is the kernel name of the link, e.g. "link1"
+	Name string `json:"name"`
+	// SpeedGbps is the negotiated SATA link speed, or 0 when no device is
+	// connected. A link running below MaxSpeedGbps usually points to a bad
+	// cable or to a drive that fell back to a slower speed after errors.
+	SpeedGbps float64 `json:"speed_gbps"`
+	// SpeedLimitGbps is the speed limit set by the kernel, e.g. with the
+	// libata.force parameter, or 0 for no limit
+	SpeedLimitGbps float64 `json:"speed_limit_gbps"`
+	// MaxSpeedGbps is the maximum speed the port supports
+	MaxSpeedGbps float64 `json:"max_speed_gbps"`
+	// Devices contains the names of the disks behind the link
+	Devices []string `json:"devices"`
+}
+
+func (l *ATALink) String() string {
+	return fmt.Sprintf("%s speed=%.1fGbps max=%.1fGbps", l.Name, l.SpeedGbps, l.MaxSpeedGbps)
+}
+
+// ATADevice describes an ATA device, as seen by libata
+type ATADevice struct {
+	// Name is the kernel name of the device, e.g. "dev1.0"
+	Name string `json:"name"`
+	// PortName and LinkName are the names of the port and link the device
+	// is attached to, and Link points to the link
+	PortName string   `json:"port"`
+	LinkName string   `json:"link"`
+	Link     *ATALink `json:"-"`
+	// Class is "ata" for disks and "atapi" for optical and tape drives
+	Class string `json:"class"`
+	// TransferMode is the transfer mode in use, e.g. "UDMA/133"
+	TransferMode string `json:"transfer_mode"`
+	// TRIM is the TRIM support of the device: "unsupported", "unqueued",
+	// "queued" or "forced_unqueued"
+	TRIM string `json:"trim"`
+	// FirmwareRevision is the firmware revision of the drive, e.g. "3B6Q"
+	FirmwareRevision string `json:"firmware_revision"`
+	// NCQDepth is the Native Command Queuing depth, 1 when NCQ is not used
+	NCQDepth int `json:"ncq_depth"`
+	// RotationRateRPM is the rotation rate of the drive, 0 for solid-state
+	// drives. It is only known when udev probed the drive, and -1 otherwise.
+	RotationRateRPM int `json:"rotation_rate_rpm"`
+}
+
+func (d *ATADevice) String() string {
+	return fmt.Sprintf(
+		"%s class=%s firmware=%s ncq_depth=%d trim=%s",
+		d.Name,
+		d.Class,
+		d.FirmwareRevision,
+		d.NCQDepth,
+		d.TRIM,
+	)
+}
@@ -0,0 +1,161 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+	"github.com/jaypipes/ghw/pkg/udev"
+)
+
+var (
+	ataPortRegex = regexp.MustCompile(`^ata(\d+)$`)
+)
+
+// ataPorts returns a slice of pointers to ATAPort structs, one for each ATA
+// port serving at least one of the supplied Disks, whose ATA device is filled
+// in along the way. The SCSI address of the Disks must be known. The firmware
+// revision and NCQ depth are attributes of the SCSI device of the disk.
+//
+// libata publishes its ports, links and devices in the /sys/class/ata_port,
+// /sys/class/ata_link and /sys/class/ata_device classes. They are found in
+// the port directory a disk is nested in, e.g.
+//
+// /sys/devices/pci0000:00/0000:00:17.0/ata1/ata_port/ata1
+// /sys/devices/pci0000:00/0000:00:17.0/ata1/link1/ata_link/link1
+// /sys/devices/pci0000:00/0000:00:17.0/ata1/link1/dev1.0/ata_device/dev1.0
+// /sys/devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda
+func ataPorts(paths *linuxpath.Paths, disks []*Disk, ancestors map[string][]string) []*ATAPort {
+	out := make([]*ATAPort, 0)
+	ports := make(map[string]*ATAPort)
+	for _, d := range disks {
+		if d.SCSIAddress == nil {
+			continue
+		}
+		scsiDevPath := ""
+		for _, dir := range ancestors[d.Name] {
+			if scsiAddressRegex.MatchString(filepath.Base(dir)) {
+				scsiDevPath = dir
+				continue
+			}
+			m := ataPortRegex.FindStringSubmatch(filepath.Base(dir))
+			if m == nil {
+				continue
+			}
+			p, ok := ports[m[0]]
+			if !ok {
+				p = ataPort(dir)
+				ports[m[0]] = p
+				out = append(out, p)
+			}
+			d.ATADevice = ataDevice(paths, dir, m[1], p, scsiDevPath, d)
+			break
+		}
+	}
+	return out
+}
+
+func ataPort(portPath string) *ATAPort {
+	name := filepath.Base(portPath)
+	portNo := int(sysfsUintAttr(filepath.Join(portPath, "ata_port", name), "port_no"))
+	return &ATAPort{
+		Name:       name,
+		PortNumber: portNo,
+		Links:      make([]*ATALink, 0),
+	}
+}
+
+// ataDevice returns a pointer to an ATADevice struct describing the ATA
+// device backing the supplied Disk, or nil. libata exposes the devices on the
+// host link of a port as SCSI targets on channel 0, and the devices behind a
+// port multiplier as target 0 on the channel of their link.
+func ataDevice(
+	paths *linuxpath.Paths,
+	portPath string,
+	portID string,
+	p *ATAPort,
+	scsiDevPath string,
+	d *Disk,
+) *ATADevice {
+	a := d.SCSIAddress
+	candidates := [][2]string{
+		{"link" + portID, fmt.Sprintf("dev%s.%d", portID, a.Target)},
+		{fmt.Sprintf("link%s.%d", portID, a.Channel), fmt.Sprintf("dev%s.%d.0", portID, a.Channel)},
+	}
+	if a.Channel != 0 {
+		candidates = candidates[1:]
+	}
+	for _, c := range candidates {
+		linkName, devName := c[0], c[1]
+		devPath := filepath.Join(portPath, linkName, devName, "ata_device", devName)
+		if _, err := os.Stat(devPath); err != nil {
+			continue
+		}
+		link := ataPortLink(portPath, p, linkName)
+		link.Devices = append(link.Devices, d.Name)
+
+		queueDepth := int(sysfsUintAttr(scsiDevPath, "queue_depth"))
+		return &ATADevice{
+			Name:             devName,
+			PortName:         p.Name,
+			LinkName:         linkName,
+			Link:             link,
+			Class:            sysfsAttr(devPath, "class"),
+			TransferMode:     sysfsAttr(devPath, "xfer_mode"),
+			TRIM:             sysfsAttr(devPath, "trim"),
+			FirmwareRevision: sysfsAttr(scsiDevPath, "rev"),
+			NCQDepth:         queueDepth,
+			RotationRateRPM:  diskRotationRateRPM(d.Udev),
+		}
+	}
+	return nil
+}
+
+// ataPortLink returns the link of the supplied port with the supplied name,
+// reading it from sysfs and adding it to the port if needed
+func ataPortLink(portPath string, p *ATAPort, name string) *ATALink {
+	for _, l := range p.Links {
+		if l.Name == name {
+			return l
+		}
+	}
+	linkPath := filepath.Join(portPath, name, "ata_link", name)
+	l := &ATALink{
+		Name:           name,
+		SpeedGbps:      parseSATASpeed(sysfsAttr(linkPath, "sata_spd")),
+		SpeedLimitGbps: parseSATASpeed(sysfsAttr(linkPath, "sata_spd_limit")),
+		MaxSpeedGbps:   parseSATASpeed(sysfsAttr(linkPath, "hw_sata_spd_limit")),
+		Devices:        make([]string, 0),
+	}
+	p.Links = append(p.Links, l)
+	return l
+}
+
+// parseSATASpeed parses the SATA link speeds published by libata, e.g.
+// "6.0 Gbps", returning 0 for "<unknown>"
+func parseSATASpeed(speed string) float64 {
+	gbps, err := strconv.ParseFloat(strings.TrimSuffix(speed, " Gbps"), 64)
+	if err != nil {
+		return 0
+	}
+	return gbps
+}
+
+// diskRotationRateRPM returns the rotation rate udev's ata_id recorded in the
+// supplied entry of a disk, or -1
+func diskRotationRateRPM(ud *udev.Device) int {
+	rpm, err := strconv.Atoi(ud.Property("ID_ATA_ROTATION_RATE_RPM"))
+	if err != nil {
+		return -1
+	}
+	return rpm
+}
@@ -0,0 +1,118 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestATAPorts(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-ata-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// sda is directly attached to ata1, whose link fell back to 1.5 Gbps.
+	// sdb sits behind a port multiplier attached to ata2.
+	ahci := "pci0000:00/0000:00:17.0"
+	disks := map[string]string{
+		"sda": ahci + "/ata1/host0/target0:0:0/0:0:0:0/block/sda",
+		"sdb": ahci + "/ata2/host1/target1:1:0/1:1:0:0/block/sdb",
+	}
+	files := map[string]string{
+		ahci + "/ata1/ata_port/ata1/port_no":                      "1\n",
+		ahci + "/ata1/link1/ata_link/link1/sata_spd":              "1.5 Gbps\n",
+		ahci + "/ata1/link1/ata_link/link1/sata_spd_limit":        "<unknown>\n",
+		ahci + "/ata1/link1/ata_link/link1/hw_sata_spd_limit":     "6.0 Gbps\n",
+		ahci + "/ata1/link1/dev1.0/ata_device/dev1.0/class":       "ata\n",
+		ahci + "/ata1/link1/dev1.0/ata_device/dev1.0/trim":        "queued\n",
+		ahci + "/ata1/link1/dev1.0/ata_device/dev1.0/xfer_mode":   "UDMA/133\n",
+		ahci + "/ata1/host0/target0:0:0/0:0:0:0/rev":              "3B6Q\n",
+		ahci + "/ata1/host0/target0:0:0/0:0:0:0/queue_depth":      "32\n",
+		ahci + "/ata2/ata_port/ata2/port_no":                      "2\n",
+		ahci + "/ata2/link2/ata_link/link2/sata_spd":              "3.0 Gbps\n",
+		ahci + "/ata2/link2/ata_link/link2/hw_sata_spd_limit":     "6.0 Gbps\n",
+		ahci + "/ata2/link2.1/ata_link/link2.1/sata_spd":          "3.0 Gbps\n",
+		ahci + "/ata2/link2.1/ata_link/link2.1/hw_sata_spd_limit": "3.0 Gbps\n",
+		ahci + "/ata2/link2.1/dev2.1.0/ata_device/dev2.1.0/class": "ata\n",
+		ahci + "/ata2/link2.1/dev2.1.0/ata_device/dev2.1.0/trim":  "unsupported\n",
+		ahci + "/ata2/host1/target1:1:0/1:1:0:0/rev":              "CC43\n",
+		ahci + "/ata2/host1/target1:1:0/1:1:0:0/queue_depth":      "1\n",
+	}
+	for _, path := range disks {
+		files[filepath.Join(path, "size")] = "2048\n"
+	}
+	files[filepath.Join(disks["sda"], "dev")] = "8:0\n"
+	writeFiles(t, filepath.Join(root, "sys", "devices"), files)
+	writeFiles(t, root, map[string]string{
+		"run/udev/data/b8:0": "E:ID_ATA=1\nE:ID_ATA_ROTATION_RATE_RPM=0\n",
+	})
+	if err = os.MkdirAll(filepath.Join(root, "sys", "block"), os.ModePerm); err != nil {
+		t.Fatalf("Unable to create /sys/block: %v", err)
+	}
+	for name, path := range disks {
+		if err = os.Symlink(filepath.Join("..", "devices", path), filepath.Join(root, "sys", "block", name)); err != nil {
+			t.Fatalf("Unable to create block device link: %v", err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.ATAPorts) != 2 {
+		t.Fatalf("Expected 2 ATA ports, but got %d", len(info.ATAPorts))
+	}
+
+	sda := info.Disks[0]
+	expected := &ATADevice{
+		Name:             "dev1.0",
+		PortName:         "ata1",
+		LinkName:         "link1",
+		Link:             info.ATAPorts[0].Links[0],
+		Class:            "ata",
+		TransferMode:     "UDMA/133",
+		TRIM:             "queued",
+		FirmwareRevision: "3B6Q",
+		NCQDepth:         32,
+		RotationRateRPM:  0,
+	}
+	if !reflect.DeepEqual(sda.ATADevice, expected) {
+		t.Fatalf("Expected sda ATA device %+v, but got %+v", expected, sda.ATADevice)
+	}
+	link := sda.ATADevice.Link
+	if link.SpeedGbps != 1.5 || link.MaxSpeedGbps != 6.0 || link.SpeedLimitGbps != 0 {
+		t.Fatalf("Expected a 1.5 Gbps link with a 6.0 Gbps maximum, but got %s", link)
+	}
+	if info.ATAPorts[0].PortNumber != 1 || !reflect.DeepEqual(link.Devices, []string{"sda"}) {
+		t.Fatalf("Expected sda behind port 1, but got %s with devices %v", info.ATAPorts[0], link.Devices)
+	}
+
+	sdb := info.Disks[1].ATADevice
+	if sdb == nil || sdb.Name != "dev2.1.0" || sdb.LinkName != "link2.1" {
+		t.Fatalf("Expected sdb to be dev2.1.0 behind link2.1, but got %+v", sdb)
+	}
+	if sdb.NCQDepth != 1 || sdb.FirmwareRevision != "CC43" || sdb.RotationRateRPM != -1 {
+		t.Fatalf("Unexpected sdb ATA device attributes: %+v", sdb)
+	}
+	if sdb.Link.SpeedGbps != 3.0 || sdb.Link.MaxSpeedGbps != 3.0 {
+		t.Fatalf("Expected a 3.0 Gbps link to sdb, but got %s", sdb.Link)
+	}
+}
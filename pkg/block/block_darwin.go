@@ -281,3 +281,15 @@ func (info *Info) load() error {
 
 	return nil
 }
+
+func (i *Info) ioStats() (*IOStatsSample, error) {
+	return nil, errors.New("ioStats not implemented on darwin")
+}
+
+func (i *Info) loadStack() error {
+	return errors.New("loadStack not implemented on darwin")
+}
+
+func (i *Info) resolvePath(chain *PathChain, path string) error {
+	return errors.New("resolvePath not implemented on darwin")
+}
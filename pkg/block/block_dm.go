@@ -0,0 +1,108 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MappedDeviceKind describes the general category of a device-mapper device
+type MappedDeviceKind int
+
+const (
+	MAPPED_DEVICE_KIND_UNKNOWN   MappedDeviceKind = iota
+	MAPPED_DEVICE_KIND_LINEAR                     // Plain linear mapping (e.g. kpartx partitions)
+	MAPPED_DEVICE_KIND_LVM                        // LVM logical volume
+	MAPPED_DEVICE_KIND_CRYPT                      // dm-crypt/LUKS mapping
+	MAPPED_DEVICE_KIND_MULTIPATH                  // dm-multipath map
+	MAPPED_DEVICE_KIND_THIN                       // LVM thin pool and its hidden data/metadata volumes
+)
+
+var (
+	mappedDeviceKindString = map[MappedDeviceKind]string{
+		MAPPED_DEVICE_KIND_UNKNOWN:   "Unknown",
+		MAPPED_DEVICE_KIND_LINEAR:    "Linear",
+		MAPPED_DEVICE_KIND_LVM:       "LVM",
+		MAPPED_DEVICE_KIND_CRYPT:     "Crypt",
+		MAPPED_DEVICE_KIND_MULTIPATH: "Multipath",
+		MAPPED_DEVICE_KIND_THIN:      "Thin",
+	}
+)
+
+func (k MappedDeviceKind) String() string {
+	return mappedDeviceKindString[k]
+}
+
+// MarshalJSON encodes the kind of mapping as its lowercased name
+func (k MappedDeviceKind) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + strings.ToLower(k.String()) + "\""), nil
+}
+
+// MappedDevice describes a device-mapper block device (e.g. "dm-0") and the
+// block devices it is built from.
+type MappedDevice struct {
+	// Disk is the Disk entry for the mapped device itself
+	Disk *Disk `json:"-"`
+	// Name is the kernel name of the mapped device, e.g. "dm-0"
+	Name string `json:"name"`
+	// MapperName is the name of the mapping, as found in /dev/mapper, e.g.
+	// "vg0-root"
+	MapperName string `json:"mapper_name"`
+	// UUID is the device-mapper UUID of the mapping. The subsystem which
+	// created the mapping (LVM, cryptsetup, multipathd...) encodes itself in
+	// the prefix of this value
+	UUID string           `json:"uuid"`
+	Kind MappedDeviceKind `json:"kind"`
+	// Slaves contains the kernel names of the block devices this mapped
+	// device is built upon
+	Slaves []string `json:"slaves"`
+	// Holders contains the kernel names of the block devices which are built
+	// upon this mapped device
+	Holders []string `json:"holders"`
+	// Disks contains the Disks named in Slaves. Note that other mapped
+	// devices are Disks too.
+	Disks []*Disk `json:"-"`
+	// Partitions contains the Partitions named in Slaves
+	Partitions []*Partition `json:"-"`
+}
+
+func (m *MappedDevice) String() string {
+	return fmt.Sprintf(
+		"%s %s (%s) slaves=%v",
+		m.Name,
+		m.MapperName,
+		m.Kind.String(),
+		m.Slaves,
+	)
+}
+
+// mappedDeviceKind returns the kind of a device-mapper device given its
+// mapper name and its device-mapper UUID. The kernel does not expose the
+// target types of a mapping in sysfs, so we rely on the UUID prefixes the
+// well-known userspace tools set when creating mappings.
+func mappedDeviceKind(name string, uuid string) MappedDeviceKind {
+	switch {
+	case strings.HasPrefix(uuid, "LVM-"):
+		// NOTE: thin volumes carved from a pool are indistinguishable from
+		// regular logical volumes here; only the pool itself and its hidden
+		// sub-volumes are reported as thin.
+		if strings.HasSuffix(uuid, "-tpool") || strings.HasSuffix(uuid, "-pool") ||
+			strings.HasSuffix(name, "_tdata") || strings.HasSuffix(name, "_tmeta") {
+			return MAPPED_DEVICE_KIND_THIN
+		}
+		return MAPPED_DEVICE_KIND_LVM
+	case strings.HasPrefix(uuid, "CRYPT-"):
+		return MAPPED_DEVICE_KIND_CRYPT
+	case strings.HasPrefix(uuid, "mpath-"):
+		return MAPPED_DEVICE_KIND_MULTIPATH
+	case strings.HasPrefix(uuid, "part"):
+		// kpartx names partition mappings "partN-<parent uuid>"
+		return MAPPED_DEVICE_KIND_LINEAR
+	}
+	return MAPPED_DEVICE_KIND_UNKNOWN
+}
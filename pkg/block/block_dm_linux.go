@@ -0,0 +1,60 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// mappedDevices returns a slice of pointers to MappedDevice structs, one for
+// each device-mapper block device found in the supplied Disks. The Disks and
+// Partitions each mapping is built upon are looked up in the same slice.
+func mappedDevices(paths *linuxpath.Paths, disks []*Disk) []*MappedDevice {
+	// The device-mapper devices are listed in /sys/block like any other block
+	// device. They can be told apart by the /sys/block/$DEVICE/dm directory,
+	// which contains the "name" and "uuid" of the mapping.
+	disksByName := make(map[string]*Disk, len(disks))
+	partsByName := make(map[string]*Partition)
+	for _, d := range disks {
+		disksByName[d.Name] = d
+		for _, p := range d.Partitions {
+			partsByName[p.Name] = p
+		}
+	}
+
+	out := make([]*MappedDevice, 0)
+	for _, d := range disks {
+		dmPath := filepath.Join(paths.SysBlock, d.Name, "dm")
+		if _, err := os.Stat(dmPath); err != nil {
+			continue
+		}
+		name := sysfsAttr(dmPath, "name")
+		uuid := sysfsAttr(dmPath, "uuid")
+		m := &MappedDevice{
+			Disk:       d,
+			Name:       d.Name,
+			MapperName: name,
+			UUID:       uuid,
+			Kind:       mappedDeviceKind(name, uuid),
+			Slaves:     blockDeviceLinks(filepath.Join(paths.SysBlock, d.Name, "slaves")),
+			Holders:    d.Holders,
+			Disks:      make([]*Disk, 0),
+			Partitions: make([]*Partition, 0),
+		}
+		for _, slave := range m.Slaves {
+			if sd, ok := disksByName[slave]; ok {
+				m.Disks = append(m.Disks, sd)
+			} else if sp, ok := partsByName[slave]; ok {
+				m.Partitions = append(m.Partitions, sp)
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
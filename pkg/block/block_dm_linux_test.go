@@ -0,0 +1,146 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestMappedDeviceKind(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	tests := []struct {
+		name     string
+		uuid     string
+		expected MappedDeviceKind
+	}{
+		{
+			name:     "vg0-root",
+			uuid:     "LVM-q3bDKmvK3Hh0ZIXNd7fo8FFYDMR3ye6Hd5DOSyNQCOhhPy4Kx9Y9V6tDBOVKq2Hb",
+			expected: MAPPED_DEVICE_KIND_LVM,
+		},
+		{
+			name:     "vg0-pool-tpool",
+			uuid:     "LVM-q3bDKmvK3Hh0ZIXNd7fo8FFYDMR3ye6Hd5DOSyNQCOhhPy4Kx9Y9V6tDBOVKq2Hb-tpool",
+			expected: MAPPED_DEVICE_KIND_THIN,
+		},
+		{
+			name:     "vg0-pool_tdata",
+			uuid:     "LVM-q3bDKmvK3Hh0ZIXNd7fo8FFYDMR3ye6HK6fGk2BTx3JLXp5kaHPqt1wKNyh0Vbo3",
+			expected: MAPPED_DEVICE_KIND_THIN,
+		},
+		{
+			name:     "luks-0cd3f5b7-4c8d-4bd1-8a3e-8a0b3d6a0b2e",
+			uuid:     "CRYPT-LUKS2-0cd3f5b74c8d4bd18a3e8a0b3d6a0b2e-luks-0cd3f5b7-4c8d-4bd1-8a3e-8a0b3d6a0b2e",
+			expected: MAPPED_DEVICE_KIND_CRYPT,
+		},
+		{
+			name:     "mpatha",
+			uuid:     "mpath-3600508b400105e210000900000490000",
+			expected: MAPPED_DEVICE_KIND_MULTIPATH,
+		},
+		{
+			name:     "mpatha1",
+			uuid:     "part1-mpath-3600508b400105e210000900000490000",
+			expected: MAPPED_DEVICE_KIND_LINEAR,
+		},
+		{
+			name:     "custom",
+			uuid:     "",
+			expected: MAPPED_DEVICE_KIND_UNKNOWN,
+		},
+	}
+
+	for _, test := range tests {
+		got := mappedDeviceKind(test.name, test.uuid)
+		if got != test.expected {
+			t.Fatalf("For %s, expected kind %s, but got %s", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestMappedDevices(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-dm-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	sysBlock := filepath.Join(root, "sys", "block")
+	writeFiles(t, sysBlock, map[string]string{
+		"sdb/size":              "2048\n",
+		"sdb/holders/dm-0":      "",
+		"sdc/size":              "2048\n",
+		"sdc/sdc1/size":         "1024\n",
+		"sdc/sdc1/holders/dm-0": "",
+		"dm-0/size":             "3072\n",
+		"dm-0/dm/name":          "vg0-root\n",
+		"dm-0/dm/uuid":          "LVM-q3bDKmvK3Hh0ZIXNd7fo8FFYDMR3ye6Hd5DOSyNQCOhhPy4Kx9Y9V6tDBOVKq2Hb\n",
+		"dm-0/slaves/sdb":       "",
+		"dm-0/slaves/sdc1":      "",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.MappedDevices) != 1 {
+		t.Fatalf("Expected 1 mapped device, but got %d", len(info.MappedDevices))
+	}
+	m := info.MappedDevices[0]
+	if m.MapperName != "vg0-root" {
+		t.Fatalf("Expected mapper name vg0-root, but got %s", m.MapperName)
+	}
+	if m.Kind != MAPPED_DEVICE_KIND_LVM {
+		t.Fatalf("Expected kind %s, but got %s", MAPPED_DEVICE_KIND_LVM, m.Kind)
+	}
+	if m.Disk == nil || m.Disk.Name != "dm-0" {
+		t.Fatalf("Expected mapped device to point to the dm-0 disk, but got %v", m.Disk)
+	}
+	if len(m.Disks) != 1 || m.Disks[0].Name != "sdb" {
+		t.Fatalf("Expected mapped device to use disk sdb, but got %v", m.Disks)
+	}
+	if len(m.Partitions) != 1 || m.Partitions[0].Name != "sdc1" {
+		t.Fatalf("Expected mapped device to use partition sdc1, but got %v", m.Partitions)
+	}
+	if m.Partitions[0].Disk.Name != "sdc" {
+		t.Fatalf("Expected partition sdc1 to be on disk sdc, but got %s", m.Partitions[0].Disk.Name)
+	}
+	if len(m.Disks[0].Holders) != 1 || m.Disks[0].Holders[0] != "dm-0" {
+		t.Fatalf("Expected sdb to be held by dm-0, but got %v", m.Disks[0].Holders)
+	}
+	if len(m.Partitions[0].Holders) != 1 || m.Partitions[0].Holders[0] != "dm-0" {
+		t.Fatalf("Expected sdc1 to be held by dm-0, but got %v", m.Partitions[0].Holders)
+	}
+}
+
+// writeFiles populates the supplied root directory with the supplied files,
+// keyed by path relative to root, creating parent directories as needed.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, contents := range files {
+		fp := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+			t.Fatalf("Unable to create directory for %q: %v", fp, err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(contents), 0644); err != nil {
+			t.Fatalf("Unable to write %q: %v", fp, err)
+		}
+	}
+}
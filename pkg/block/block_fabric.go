@@ -0,0 +1,82 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+)
+
+// FCHost describes a Fibre Channel host bus adapter port
+type FCHost struct {
+	// Name is the kernel name of the SCSI host of the port, e.g. "host5"
+	Name string `json:"name"`
+	// PortName and NodeName are the World Wide Port Name (WWPN) and World
+	// Wide Node Name (WWNN) of the port, e.g. "0x10000090fa1b2c3d"
+	PortName string `json:"port_name"`
+	NodeName string `json:"node_name"`
+	// PortState is the state of the port, e.g. "Online" or "Linkdown"
+	PortState string `json:"port_state"`
+	// Speed is the negotiated speed of the port, e.g. "16 Gbit"
+	Speed string `json:"speed"`
+	// FabricName is the WWN of the fabric the port is logged in to
+	FabricName string `json:"fabric_name"`
+	// PCIAddress is the PCI address of the adapter, or nil
+	PCIAddress *string `json:"pci_address,omitempty"`
+}
+
+func (h *FCHost) String() string {
+	pciAddr := ""
+	if h.PCIAddress != nil {
+		pciAddr = " [@" + *h.PCIAddress + "]"
+	}
+	return fmt.Sprintf(
+		"%s FC port_name=%s state=%s speed=%s fabric=%s%s",
+		h.Name,
+		h.PortName,
+		h.PortState,
+		h.Speed,
+		h.FabricName,
+		pciAddr,
+	)
+}
+
+// ISCSISession describes an iSCSI session to a target
+type ISCSISession struct {
+	// Name is the kernel name of the session, e.g. "session1"
+	Name string `json:"name"`
+	// TargetName is the iSCSI Qualified Name (IQN) of the target, e.g.
+	// "iqn.2003-01.org.linux-iscsi.storage1:lun0"
+	TargetName string `json:"target_name"`
+	// InitiatorName is the IQN the host logged in to the target with
+	InitiatorName string `json:"initiator_name"`
+	// Portal is the address and TCP port of the target portal the session
+	// is connected to, e.g. "10.0.0.1:3260"
+	Portal string `json:"portal"`
+	// State is the state of the session, e.g. "LOGGED_IN" or "FAILED"
+	State string `json:"state"`
+	// LUNs contains the logical units of the target seen through the
+	// session, with the disks backing them
+	LUNs []*ISCSILUN `json:"luns"`
+}
+
+func (s *ISCSISession) String() string {
+	return fmt.Sprintf(
+		"%s iSCSI target=%s portal=%s state=%s (%d LUNs)",
+		s.Name,
+		s.TargetName,
+		s.Portal,
+		s.State,
+		len(s.LUNs),
+	)
+}
+
+// ISCSILUN maps a logical unit of an iSCSI target to the disk backing it
+type ISCSILUN struct {
+	LUN      uint64 `json:"lun"`
+	DiskName string `json:"disk"`
+	Disk     *Disk  `json:"-"`
+}
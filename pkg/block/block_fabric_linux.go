@@ -0,0 +1,120 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+	pciaddr "github.com/jaypipes/ghw/pkg/pci/address"
+)
+
+// fcHosts returns a slice of pointers to FCHost structs, one for each Fibre
+// Channel port found in /sys/class/fc_host
+func fcHosts(paths *linuxpath.Paths) []*FCHost {
+	out := make([]*FCHost, 0)
+	entries, err := ioutil.ReadDir(paths.SysClassFCHost)
+	if err != nil {
+		return out
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		hpath := filepath.Join(paths.SysClassFCHost, name)
+		h := &FCHost{
+			Name:       name,
+			PortName:   sysfsAttr(hpath, "port_name"),
+			NodeName:   sysfsAttr(hpath, "node_name"),
+			PortState:  sysfsAttr(hpath, "port_state"),
+			Speed:      sysfsAttr(hpath, "speed"),
+			FabricName: sysfsAttr(hpath, "fabric_name"),
+		}
+		// The class device is nested in the SCSI host, itself nested in the
+		// PCI device of the adapter, e.g.
+		// /sys/devices/pci0000:00/0000:00:02.0/0000:02:00.0/host5/fc_host/host5
+		if devPath, err := filepath.EvalSymlinks(hpath); err == nil {
+			for _, dir := range deviceAncestors(paths, devPath) {
+				if addr := pciaddr.FromString(filepath.Base(dir)); addr != nil {
+					pciAddr := filepath.Base(dir)
+					h.PCIAddress = &pciAddr
+					break
+				}
+			}
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// iscsiSessions returns a slice of pointers to ISCSISession structs, one for
+// each iSCSI session found in /sys/class/iscsi_session. The SCSI address of
+// the supplied Disks must be known, to map the LUNs of each session to them.
+func iscsiSessions(paths *linuxpath.Paths, disks []*Disk, ancestors map[string][]string) []*ISCSISession {
+	out := make([]*ISCSISession, 0)
+	entries, err := ioutil.ReadDir(paths.SysClassISCSISession)
+	if err != nil {
+		return out
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		spath := filepath.Join(paths.SysClassISCSISession, name)
+		s := &ISCSISession{
+			Name:          name,
+			TargetName:    sysfsAttr(spath, "targetname"),
+			InitiatorName: sysfsAttr(spath, "initiatorname"),
+			State:         sysfsAttr(spath, "state"),
+			Portal:        iscsiPortal(paths, strings.TrimPrefix(name, "session")),
+			LUNs:          make([]*ISCSILUN, 0),
+		}
+		// The class device is nested in the session device, which the SCSI
+		// targets of the session are nested in, e.g.
+		// /sys/devices/platform/host3/session1/iscsi_session/session1
+		// /sys/devices/platform/host3/session1/target3:0:0/3:0:0:1/block/sdd
+		devPath, err := filepath.EvalSymlinks(spath)
+		if err != nil {
+			out = append(out, s)
+			continue
+		}
+		sessionPath := filepath.Dir(filepath.Dir(devPath))
+		for _, d := range disks {
+			if d.SCSIAddress == nil {
+				continue
+			}
+			for _, dir := range ancestors[d.Name] {
+				if dir == sessionPath {
+					s.LUNs = append(s.LUNs, &ISCSILUN{
+						LUN:      d.SCSIAddress.LUN,
+						DiskName: d.Name,
+						Disk:     d,
+					})
+					break
+				}
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// iscsiPortal returns the address of the target portal the first connection
+// of the supplied session is connected to, e.g. "10.0.0.1:3260". The
+// persistent address is the one the session was logged in to, which may
+// differ from the current one after a redirection.
+func iscsiPortal(paths *linuxpath.Paths, sessionID string) string {
+	cpath := filepath.Join(paths.SysClassISCSIConn, "connection"+sessionID+":0")
+	addr := sysfsAttr(cpath, "persistent_address")
+	port := sysfsAttr(cpath, "persistent_port")
+	if addr == "" {
+		addr = sysfsAttr(cpath, "address")
+		port = sysfsAttr(cpath, "port")
+	}
+	if addr == "" {
+		return ""
+	}
+	return net.JoinHostPort(addr, port)
+}
@@ -0,0 +1,101 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestStorageFabrics(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-fabric-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	hba := "pci0000:00/0000:00:02.0/0000:02:00.0/host5"
+	session := "platform/host3/session1"
+	writeFiles(t, filepath.Join(root, "sys", "devices"), map[string]string{
+		hba + "/fc_host/host5/port_name":                                             "0x10000090fa1b2c3d\n",
+		hba + "/fc_host/host5/node_name":                                             "0x20000090fa1b2c3d\n",
+		hba + "/fc_host/host5/port_state":                                            "Online\n",
+		hba + "/fc_host/host5/speed":                                                 "16 Gbit\n",
+		hba + "/fc_host/host5/fabric_name":                                           "0x100000051e0c1d2e\n",
+		hba + "/rport-5:0-2/target5:0:0/5:0:0:0/block/sdc/size":                      "2048\n",
+		session + "/iscsi_session/session1/targetname":                               "iqn.2003-01.org.linux-iscsi.storage1:lun0\n",
+		session + "/iscsi_session/session1/initiatorname":                            "iqn.1993-08.org.debian:01:8a1b2c3d4e5f\n",
+		session + "/iscsi_session/session1/state":                                    "LOGGED_IN\n",
+		session + "/connection1:0/iscsi_connection/connection1:0/persistent_address": "10.0.0.1\n",
+		session + "/connection1:0/iscsi_connection/connection1:0/persistent_port":    "3260\n",
+		session + "/target3:0:0/3:0:0:1/block/sdd/size":                              "2048\n",
+		session + "/target3:0:0/3:0:0:2/block/sde/size":                              "2048\n",
+	})
+	links := map[string]string{
+		"block/sdc":                            hba + "/rport-5:0-2/target5:0:0/5:0:0:0/block/sdc",
+		"block/sdd":                            session + "/target3:0:0/3:0:0:1/block/sdd",
+		"block/sde":                            session + "/target3:0:0/3:0:0:2/block/sde",
+		"class/fc_host/host5":                  hba + "/fc_host/host5",
+		"class/iscsi_session/session1":         session + "/iscsi_session/session1",
+		"class/iscsi_connection/connection1:0": session + "/connection1:0/iscsi_connection/connection1:0",
+	}
+	for link, target := range links {
+		linkPath := filepath.Join(root, "sys", link)
+		if err = os.MkdirAll(filepath.Dir(linkPath), os.ModePerm); err != nil {
+			t.Fatalf("Unable to create directory for %q: %v", linkPath, err)
+		}
+		if err = os.Symlink(filepath.Join(root, "sys", "devices", target), linkPath); err != nil {
+			t.Fatalf("Unable to create link %q: %v", linkPath, err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+
+	if len(info.FCHosts) != 1 {
+		t.Fatalf("Expected 1 FC host, but got %d", len(info.FCHosts))
+	}
+	fc := info.FCHosts[0]
+	if fc.PortName != "0x10000090fa1b2c3d" || fc.NodeName != "0x20000090fa1b2c3d" || fc.FabricName != "0x100000051e0c1d2e" {
+		t.Fatalf("Unexpected FC host WWNs: %s", fc)
+	}
+	if fc.PortState != "Online" || fc.Speed != "16 Gbit" {
+		t.Fatalf("Expected an online 16 Gbit port, but got %s", fc)
+	}
+	if fc.PCIAddress == nil || *fc.PCIAddress != "0000:02:00.0" {
+		t.Fatalf("Expected the FC host at 0000:02:00.0, but got %v", fc.PCIAddress)
+	}
+
+	if len(info.ISCSISessions) != 1 {
+		t.Fatalf("Expected 1 iSCSI session, but got %d", len(info.ISCSISessions))
+	}
+	s := info.ISCSISessions[0]
+	if s.TargetName != "iqn.2003-01.org.linux-iscsi.storage1:lun0" || s.State != "LOGGED_IN" || s.Portal != "10.0.0.1:3260" {
+		t.Fatalf("Unexpected iSCSI session attributes: %s", s)
+	}
+	if len(s.LUNs) != 2 {
+		t.Fatalf("Expected 2 LUNs, but got %d", len(s.LUNs))
+	}
+	for x, expected := range []string{"sdd", "sde"} {
+		lun := s.LUNs[x]
+		if lun.LUN != uint64(x+1) || lun.DiskName != expected || lun.Disk == nil || lun.Disk.Name != expected {
+			t.Fatalf("Expected LUN %d to be backed by %s, but got %+v", x+1, expected, lun)
+		}
+	}
+}
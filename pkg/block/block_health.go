@@ -0,0 +1,123 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Health describes the health of a disk, as reported by its SMART (or NVMe
+// SMART / Health Information) log
+type Health struct {
+	// Passed is the overall SMART health self-assessment of the disk
+	Passed             bool  `json:"passed"`
+	TemperatureCelsius int64 `json:"temperature_celsius"`
+	PowerOnHours       int64 `json:"power_on_hours"`
+	// ReallocatedSectors is the count of sectors remapped to spare sectors
+	// (the grown defect list of SCSI disks). PendingSectors is the count of
+	// unstable sectors waiting to be remapped. Both are ATA/SCSI only.
+	ReallocatedSectors int64 `json:"reallocated_sectors"`
+	PendingSectors     int64 `json:"pending_sectors"`
+	// PercentageUsed is the vendor estimate of the life of an NVMe drive
+	// used, which may exceed 100. MediaErrors is the count of unrecovered
+	// data integrity errors of an NVMe drive. Both are nil for other drives.
+	PercentageUsed *int64 `json:"percentage_used,omitempty"`
+	MediaErrors    *int64 `json:"media_errors,omitempty"`
+	// ErrorLogCount is the number of entries in the error log of the disk
+	ErrorLogCount int64 `json:"error_log_count"`
+}
+
+func (h *Health) String() string {
+	status := "PASSED"
+	if !h.Passed {
+		status = "FAILED"
+	}
+	return fmt.Sprintf(
+		"health=%s temperature=%dC power_on_hours=%d",
+		status,
+		h.TemperatureCelsius,
+		h.PowerOnHours,
+	)
+}
+
+// The subset of the `smartctl --json -a` output we care about
+type smartctlOutput struct {
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours int64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	ATASmartErrorLog struct {
+		Summary struct {
+			Count int64 `json:"count"`
+		} `json:"summary"`
+		Extended struct {
+			Count int64 `json:"count"`
+		} `json:"extended"`
+	} `json:"ata_smart_error_log"`
+	SCSIGrownDefectList int64 `json:"scsi_grown_defect_list"`
+	NVMeHealth          *struct {
+		PercentageUsed   int64 `json:"percentage_used"`
+		MediaErrors      int64 `json:"media_errors"`
+		NumErrLogEntries int64 `json:"num_err_log_entries"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+const (
+	ataAttrReallocatedSectorCount = 5
+	ataAttrCurrentPendingSector   = 197
+)
+
+// parseSmartctlOutput maps the supplied `smartctl --json -a` output to a
+// Health struct. It returns nil if smartctl could not find out the SMART
+// status of the disk, e.g. because the disk does not support SMART.
+func parseSmartctlOutput(raw []byte) (*Health, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	if out.SmartStatus == nil {
+		return nil, nil
+	}
+	h := &Health{
+		Passed:             out.SmartStatus.Passed,
+		TemperatureCelsius: out.Temperature.Current,
+		PowerOnHours:       out.PowerOnTime.Hours,
+		ReallocatedSectors: out.SCSIGrownDefectList,
+		ErrorLogCount:      out.ATASmartErrorLog.Summary.Count,
+	}
+	if out.ATASmartErrorLog.Extended.Count > h.ErrorLogCount {
+		h.ErrorLogCount = out.ATASmartErrorLog.Extended.Count
+	}
+	for _, attr := range out.ATASmartAttributes.Table {
+		switch attr.ID {
+		case ataAttrReallocatedSectorCount:
+			h.ReallocatedSectors = attr.Raw.Value
+		case ataAttrCurrentPendingSector:
+			h.PendingSectors = attr.Raw.Value
+		}
+	}
+	if out.NVMeHealth != nil {
+		h.PercentageUsed = &out.NVMeHealth.PercentageUsed
+		h.MediaErrors = &out.NVMeHealth.MediaErrors
+		h.ErrorLogCount = out.NVMeHealth.NumErrLogEntries
+	}
+	return h, nil
+}
@@ -0,0 +1,72 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jaypipes/ghw/pkg/block/smartctl"
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// diskHealth fills in the Health of the supplied Disks, when requested with
+// option.WithDiskHealth().
+//
+// Snapshots record the `smartctl --json -a` output of each disk in the
+// /run/ghw/smartctl/$DEVICE.json file, which is used when found. Otherwise,
+// smartctl is called when external tools are enabled, unless reading from a
+// snapshot. The disks are queried concurrently, and smartctl is killed on
+// those that did not answer within smartctl.Timeout. Disks with no SMART
+// support, or in standby (which are not woken up), are left without Health.
+func diskHealth(ctx *context.Context, paths *linuxpath.Paths, disks []*Disk) {
+	if !ctx.EnableDiskHealth {
+		return
+	}
+	smartctlPath := ""
+	if ctx.EnableTools && ctx.SnapshotPath == "" {
+		smartctlPath, _ = exec.LookPath("smartctl")
+	}
+	deadline := time.Now().Add(smartctl.Timeout)
+	outputs := make([][]byte, len(disks))
+	errs := make([]error, len(disks))
+	var wg sync.WaitGroup
+	for x, d := range disks {
+		raw, err := ioutil.ReadFile(filepath.Join(paths.RunGHWSmartctl, d.Name+".json"))
+		if err == nil {
+			outputs[x] = raw
+			continue
+		}
+		if smartctlPath == "" || !smartctl.HasSMART(d.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func(x int, devPath string) {
+			defer wg.Done()
+			outputs[x], errs[x] = smartctl.Run(smartctlPath, devPath, deadline)
+		}(x, filepath.Join(paths.Dev, d.Name))
+	}
+	wg.Wait()
+
+	for x, d := range disks {
+		raw := outputs[x]
+		if len(raw) == 0 {
+			if errs[x] != nil {
+				ctx.Warn("failed to run smartctl for %s: %s\n", d.Name, errs[x])
+			}
+			continue
+		}
+		var err error
+		d.Health, err = parseSmartctlOutput(raw)
+		if err != nil {
+			ctx.Warn("failed to parse smartctl output for %s: %s\n", d.Name, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+// Trimmed down `smartctl --json -a` outputs
+const (
+	smartctlATAFailing = `{
+  "smartctl": {"version": [7, 2], "exit_status": 8},
+  "device": {"name": "/dev/sda", "type": "sat", "protocol": "ATA"},
+  "smart_status": {"passed": false},
+  "ata_smart_attributes": {
+    "revision": 16,
+    "table": [
+      {"id": 5, "name": "Reallocated_Sector_Ct", "value": 90, "raw": {"value": 1304, "string": "1304"}},
+      {"id": 9, "name": "Power_On_Hours", "value": 42, "raw": {"value": 51213, "string": "51213"}},
+      {"id": 197, "name": "Current_Pending_Sector", "value": 100, "raw": {"value": 16, "string": "16"}}
+    ]
+  },
+  "power_on_time": {"hours": 51213},
+  "temperature": {"current": 41},
+  "ata_smart_error_log": {"summary": {"revision": 1, "count": 27}}
+}`
+	smartctlNVMe = `{
+  "smartctl": {"version": [7, 2], "exit_status": 0},
+  "device": {"name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+  "smart_status": {"passed": true, "nvme": {"value": 0}},
+  "nvme_smart_health_information_log": {
+    "critical_warning": 0,
+    "temperature": 38,
+    "percentage_used": 7,
+    "power_on_hours": 8790,
+    "media_errors": 2,
+    "num_err_log_entries": 154
+  },
+  "temperature": {"current": 38},
+  "power_on_time": {"hours": 8790}
+}`
+	smartctlUnsupported = `{
+  "smartctl": {
+    "version": [7, 2],
+    "messages": [{"string": "/dev/sdc: Unknown USB bridge [0x152d:0x0578 (0x209)]", "severity": "error"}],
+    "exit_status": 1
+  }
+}`
+)
+
+func TestDiskHealth(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-health-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"sys/block/nvme0n1/size":           "2048\n",
+		"sys/block/sda/size":               "2048\n",
+		"sys/block/sdc/size":               "2048\n",
+		"run/ghw/smartctl/nvme0n1.json":    smartctlNVMe,
+		"run/ghw/smartctl/sda.json":        smartctlATAFailing,
+		"run/ghw/smartctl/sdc.json":        smartctlUnsupported,
+		"run/ghw/smartctl/not-a-disk.json": "{}",
+	})
+
+	// Health is only read on request
+	info, err := New(option.WithChroot(root), option.WithNullAlerter(), option.WithDisableTools())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	for _, d := range info.Disks {
+		if d.Health != nil {
+			t.Fatalf("Expected no health for %s without WithDiskHealth, but got %+v", d.Name, d.Health)
+		}
+	}
+
+	info, err = New(option.WithChroot(root), option.WithNullAlerter(), option.WithDisableTools(), option.WithDiskHealth())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 3 {
+		t.Fatalf("Expected 3 disks, but got %d", len(info.Disks))
+	}
+
+	percentageUsed := int64(7)
+	mediaErrors := int64(2)
+	expected := Health{
+		Passed:             true,
+		TemperatureCelsius: 38,
+		PowerOnHours:       8790,
+		PercentageUsed:     &percentageUsed,
+		MediaErrors:        &mediaErrors,
+		ErrorLogCount:      154,
+	}
+	if h := info.Disks[0].Health; h == nil || !reflect.DeepEqual(*h, expected) {
+		t.Fatalf("Expected nvme0n1 health %+v, but got %+v", expected, h)
+	}
+
+	expected = Health{
+		Passed:             false,
+		TemperatureCelsius: 41,
+		PowerOnHours:       51213,
+		ReallocatedSectors: 1304,
+		PendingSectors:     16,
+		ErrorLogCount:      27,
+	}
+	if h := info.Disks[1].Health; h == nil || !reflect.DeepEqual(*h, expected) {
+		t.Fatalf("Expected sda health %+v, but got %+v", expected, h)
+	}
+
+	if h := info.Disks[2].Health; h != nil {
+		t.Fatalf("Expected no health for sdc, but got %+v", h)
+	}
+}
+
+func TestHealthJSON(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	// A healthy NVMe drive reports its 0 media errors, while an ATA drive
+	// has no NVMe health data at all
+	healthy := strings.Replace(smartctlNVMe, `"media_errors": 2`, `"media_errors": 0`, 1)
+	h, err := parseSmartctlOutput([]byte(healthy))
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	out, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if !strings.Contains(string(out), `"media_errors":0`) {
+		t.Fatalf("Expected the 0 media errors of nvme0n1 to be reported, but got %s", out)
+	}
+
+	h, err = parseSmartctlOutput([]byte(smartctlATAFailing))
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	out, err = json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if strings.Contains(string(out), "media_errors") || strings.Contains(string(out), "percentage_used") {
+		t.Fatalf("Expected no NVMe health data for sda, but got %s", out)
+	}
+}
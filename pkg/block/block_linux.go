@@ -7,16 +7,17 @@ package block
 
 import (
 	"bufio"
-	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/jaypipes/ghw/pkg/block/fsprobe"
+	"github.com/jaypipes/ghw/pkg/block/parttable"
 	"github.com/jaypipes/ghw/pkg/context"
 	"github.com/jaypipes/ghw/pkg/linuxpath"
+	"github.com/jaypipes/ghw/pkg/udev"
 	"github.com/jaypipes/ghw/pkg/util"
 )
 
@@ -26,7 +27,19 @@ const (
 
 func (i *Info) load() error {
 	paths := linuxpath.New(i.ctx)
-	i.Disks = disks(i.ctx, paths)
+	ancestors := diskAncestors(paths)
+	i.Disks = disks(i.ctx, paths, ancestors, true)
+	i.MappedDevices = mappedDevices(paths, i.Disks)
+	i.MDArrays = mdArrays(paths, i.Disks)
+	i.NVMeControllers = nvmeControllers(paths, i.Disks)
+	i.SCSIHosts = scsiHosts(i.Disks, ancestors)
+	i.ATAPorts = ataPorts(paths, i.Disks, ancestors)
+	i.FCHosts = fcHosts(paths)
+	i.ISCSISessions = iscsiSessions(paths, i.Disks, ancestors)
+	i.BtrfsFilesystems = btrfsFilesystems(paths, i.Disks)
+	i.ZFSPools = zfsPools(i.ctx, paths, i.Disks)
+	i.ZFSVersion = zfsVersion(paths)
+	diskHealth(i.ctx, paths, i.Disks)
 	var tpb uint64
 	for _, d := range i.Disks {
 		tpb += d.SizeBytes
@@ -35,6 +48,41 @@ func (i *Info) load() error {
 	return nil
 }
 
+// loadStack reads the block devices and the way they are stacked upon each
+// other, which is all resolvePath needs. Unlike load, it neither reads device
+// nodes, the partition tables and filesystems being taken from udev instead,
+// nor runs smartctl.
+func (i *Info) loadStack() error {
+	paths := linuxpath.New(i.ctx)
+	i.Disks = disks(i.ctx, paths, diskAncestors(paths), false)
+	i.MappedDevices = mappedDevices(paths, i.Disks)
+	i.MDArrays = mdArrays(paths, i.Disks)
+	i.BtrfsFilesystems = btrfsFilesystems(paths, i.Disks)
+	i.ZFSPools = zfsPools(i.ctx, paths, i.Disks)
+	return nil
+}
+
+// sysfsAttr returns the contents of the supplied attribute file of a sysfs
+// directory, with the trailing newline removed, or an empty string if the
+// attribute cannot be read
+func sysfsAttr(path string, attr string) string {
+	contents, err := ioutil.ReadFile(filepath.Join(path, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+// sysfsUintAttr returns the value of the supplied numeric attribute file of
+// a sysfs directory, or 0 if the attribute cannot be read
+func sysfsUintAttr(path string, attr string) uint64 {
+	val, err := strconv.ParseUint(sysfsAttr(path, attr), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return val
+}
+
 func diskPhysicalBlockSizeBytes(paths *linuxpath.Paths, disk string) uint64 {
 	// We can find the sector size in Linux by looking at the
 	// /sys/block/$DEVICE/queue/physical_block_size file in sysfs
@@ -50,10 +98,10 @@ func diskPhysicalBlockSizeBytes(paths *linuxpath.Paths, disk string) uint64 {
 	return size
 }
 
-func diskSizeBytes(paths *linuxpath.Paths, disk string) uint64 {
-	// We can find the number of 512-byte sectors by examining the contents of
-	// /sys/block/$DEVICE/size and calculate the physical bytes accordingly.
-	path := filepath.Join(paths.SysBlock, disk, "size")
+func diskLogicalBlockSizeBytes(paths *linuxpath.Paths, disk string) uint64 {
+	// The LBAs in partition tables are expressed in logical blocks, whose
+	// size is found in the /sys/block/$DEVICE/queue/logical_block_size file
+	path := filepath.Join(paths.SysBlock, disk, "queue", "logical_block_size")
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		return 0
@@ -62,22 +110,22 @@ func diskSizeBytes(paths *linuxpath.Paths, disk string) uint64 {
 	if err != nil {
 		return 0
 	}
-	return size * sectorSize
+	return size
 }
 
-func diskNUMANodeID(paths *linuxpath.Paths, disk string) int {
-	link, err := os.Readlink(filepath.Join(paths.SysBlock, disk))
+func diskSizeBytes(paths *linuxpath.Paths, disk string) uint64 {
+	// We can find the number of 512-byte sectors by examining the contents of
+	// /sys/block/$DEVICE/size and calculate the physical bytes accordingly.
+	path := filepath.Join(paths.SysBlock, disk, "size")
+	contents, err := ioutil.ReadFile(path)
 	if err != nil {
-		return -1
+		return 0
 	}
-	for partial := link; strings.HasPrefix(partial, "../devices/"); partial = filepath.Base(partial) {
-		if nodeContents, err := ioutil.ReadFile(filepath.Join(paths.SysBlock, partial, "numa_node")); err != nil {
-			if nodeInt, err := strconv.Atoi(string(nodeContents)); err != nil {
-				return nodeInt
-			}
-		}
+	size, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 64)
+	if err != nil {
+		return 0
 	}
-	return -1
+	return size * sectorSize
 }
 
 func diskVendor(paths *linuxpath.Paths, disk string) string {
@@ -91,86 +139,59 @@ func diskVendor(paths *linuxpath.Paths, disk string) string {
 	return strings.TrimSpace(string(contents))
 }
 
-func udevInfo(paths *linuxpath.Paths, disk string) (map[string]string, error) {
+// blockDeviceUdev returns the entry of the udev database of the supplied
+// disk or partition, whose path relative to /sys/block is supplied, e.g.
+// "sda" or "sda/sda1", or nil
+func blockDeviceUdev(ctx *context.Context, paths *linuxpath.Paths, sysPath string) *udev.Device {
 	// Get device major:minor numbers
-	devNo, err := ioutil.ReadFile(filepath.Join(paths.SysBlock, disk, "dev"))
+	devNo, err := ioutil.ReadFile(filepath.Join(paths.SysBlock, sysPath, "dev"))
 	if err != nil {
-		return nil, err
+		return nil
 	}
 
 	// Look up block device in udev runtime database
-	udevID := "b" + strings.TrimSpace(string(devNo))
-	udevBytes, err := ioutil.ReadFile(filepath.Join(paths.RunUdevData, udevID))
+	dev, err := udev.ReadDevice(ctx, udev.BlockDeviceID(strings.TrimSpace(string(devNo))))
 	if err != nil {
-		return nil, err
-	}
-
-	udevInfo := make(map[string]string)
-	for _, udevLine := range strings.Split(string(udevBytes), "\n") {
-		if strings.HasPrefix(udevLine, "E:") {
-			if s := strings.SplitN(udevLine[2:], "=", 2); len(s) == 2 {
-				udevInfo[s[0]] = s[1]
-			}
-		}
+		return nil
 	}
-	return udevInfo, nil
+	return dev
 }
 
-func diskModel(paths *linuxpath.Paths, disk string) string {
-	info, err := udevInfo(paths, disk)
-	if err != nil {
-		return util.UNKNOWN
-	}
-
-	if model, ok := info["ID_MODEL"]; ok {
+func diskModel(ud *udev.Device) string {
+	if model, ok := ud.LookupProperty("ID_MODEL"); ok {
 		return model
 	}
 	return util.UNKNOWN
 }
 
-func diskSerialNumber(paths *linuxpath.Paths, disk string) string {
-	info, err := udevInfo(paths, disk)
-	if err != nil {
-		return util.UNKNOWN
-	}
-
+func diskSerialNumber(ud *udev.Device) string {
 	// There are two serial number keys, ID_SERIAL and ID_SERIAL_SHORT The
 	// non-_SHORT version often duplicates vendor information collected
 	// elsewhere, so use _SHORT and fall back to ID_SERIAL if missing...
-	if serial, ok := info["ID_SERIAL_SHORT"]; ok {
+	if serial, ok := ud.LookupProperty("ID_SERIAL_SHORT"); ok {
 		return serial
 	}
-	if serial, ok := info["ID_SERIAL"]; ok {
+	if serial, ok := ud.LookupProperty("ID_SERIAL"); ok {
 		return serial
 	}
 	return util.UNKNOWN
 }
 
-func diskBusPath(paths *linuxpath.Paths, disk string) string {
-	info, err := udevInfo(paths, disk)
-	if err != nil {
-		return util.UNKNOWN
-	}
-
+func diskBusPath(ud *udev.Device) string {
 	// There are two path keys, ID_PATH and ID_PATH_TAG.
 	// The difference seems to be _TAG has funky characters converted to underscores.
-	if path, ok := info["ID_PATH"]; ok {
+	if path, ok := ud.LookupProperty("ID_PATH"); ok {
 		return path
 	}
 	return util.UNKNOWN
 }
 
-func diskWWN(paths *linuxpath.Paths, disk string) string {
-	info, err := udevInfo(paths, disk)
-	if err != nil {
-		return util.UNKNOWN
-	}
-
+func diskWWN(ud *udev.Device) string {
 	// Trying ID_WWN_WITH_EXTENSION and falling back to ID_WWN is the same logic lsblk uses
-	if wwn, ok := info["ID_WWN_WITH_EXTENSION"]; ok {
+	if wwn, ok := ud.LookupProperty("ID_WWN_WITH_EXTENSION"); ok {
 		return wwn
 	}
-	if wwn, ok := info["ID_WWN"]; ok {
+	if wwn, ok := ud.LookupProperty("ID_WWN"); ok {
 		return wwn
 	}
 	return util.UNKNOWN
@@ -179,63 +200,174 @@ func diskWWN(paths *linuxpath.Paths, disk string) string {
 // diskPartitions takes the name of a disk (note: *not* the path of the disk,
 // but just the name. In other words, "sda", not "/dev/sda" and "nvme0n1" not
 // "/dev/nvme0n1") and returns a slice of pointers to Partition structs
-// representing the partitions in that disk
-func diskPartitions(ctx *context.Context, paths *linuxpath.Paths, device string) []*Partition {
+// representing the partitions in that disk, along with the partition table
+// of the disk if its device node could be read. The device nodes are only
+// read when the supplied readable flag is set, see diskNodeReadable.
+func diskPartitions(ctx *context.Context, paths *linuxpath.Paths, device string, readable bool, mounts []*MountInfo) ([]*Partition, *parttable.Table) {
 	out := make([]*Partition, 0)
 	path := filepath.Join(paths.SysBlock, device)
 	files, err := ioutil.ReadDir(path)
 	if err != nil {
 		ctx.Warn("failed to read disk partitions: %s\n", err)
-		return out
+		return out, nil
 	}
+	var table *parttable.Table
+	tableRead := false
 	for _, file := range files {
 		fname := file.Name()
 		if !strings.HasPrefix(fname, device) {
 			continue
 		}
+		// Only read the partition table once we know the disk has partitions
+		if !tableRead && readable {
+			table = diskPartitionTable(paths, device)
+			tableRead = true
+		}
 		size := partitionSizeBytes(paths, device, fname)
-		mp, pt, ro := mountInfo(paths, fname)
-		du := diskPartUUID(ctx, fname)
 		p := &Partition{
 			Name:      fname,
 			SizeBytes: size,
-			UUID:      du,
+			Holders:   blockDeviceLinks(filepath.Join(path, fname, "holders")),
+			Udev:      blockDeviceUdev(ctx, paths, filepath.Join(device, fname)),
+		}
+		if tp := tablePartition(table, partitionNumber(paths, device, fname)); tp != nil {
+			p.Label = tp.Name
+			p.UUID = tp.UUID
+			p.TypeID = tp.TypeID
+			p.TypeName = tp.TypeName
+			p.Attributes = tp.Attributes
+			p.FirstLBA = tp.FirstLBA
+			p.LastLBA = tp.LastLBA
+		} else if p.Udev != nil {
+			// The device node is not readable, but udev has already probed
+			// the partition table for us
+			p.Label = p.Udev.Property("ID_PART_ENTRY_NAME")
+			p.UUID = p.Udev.Property("ID_PART_ENTRY_UUID")
+			p.TypeID = p.Udev.Property("ID_PART_ENTRY_TYPE")
+			if p.TypeID != "" {
+				p.TypeName = parttable.TypeName(p.TypeID)
+			}
 		}
-		if mp != "" {
-			p.MountInfo = &MountInfo{mp, pt, ro}
+		if readable {
+			p.Filesystem = diskFilesystem(paths, fname, p.Udev)
+		} else {
+			p.Filesystem = udevFilesystem(p.Udev)
+		}
+		p.Mounts = deviceMounts(ctx, paths, mounts, filepath.Join(path, fname), fname)
+		if len(p.Mounts) > 0 {
+			p.MountInfo = p.Mounts[0]
 		}
 		out = append(out, p)
 	}
-	return out
+	return out, table
 }
 
-func diskPartUUID(ctx *context.Context, part string) string {
-	if !strings.HasPrefix(part, "/dev") {
-		part = "/dev/" + part
+// diskPartitionTable returns the partition table read from the device node of
+// the supplied disk, or nil if the device node cannot be read (which is
+// usually the case when not running as root) or contains no partition table.
+// It must only be called on disks whose device node is safe to read, see
+// diskNodeReadable.
+func diskPartitionTable(paths *linuxpath.Paths, disk string) *parttable.Table {
+	f, err := os.Open(filepath.Join(paths.Dev, disk))
+	if err != nil {
+		return nil
 	}
-	args := []string{
-		"blkid",
-		"-s",
-		"PARTUUID",
-		part,
+	defer f.Close()
+	var table *parttable.Table
+	if lbs := diskLogicalBlockSizeBytes(paths, disk); lbs > 0 {
+		table, err = parttable.ReadWithSectorSize(f, int(lbs))
+	} else {
+		table, err = parttable.Read(f)
 	}
-	out, err := exec.Command(args[0], args[1:]...).Output()
 	if err != nil {
-		ctx.Warn("failed to read disk partuuid of %s : %s\n", part, err.Error())
-		return ""
+		return nil
 	}
+	return table
+}
 
-	if out == nil || len(out) == 0 {
-		return ""
+// diskFilesystem returns the filesystem found on the supplied disk or
+// partition, or nil. The device node is probed first; when it cannot be read,
+// the filesystem identity recorded by udev in the supplied entry is used
+// instead.
+func diskFilesystem(paths *linuxpath.Paths, name string, ud *udev.Device) *fsprobe.Filesystem {
+	fs, err := fsprobe.ProbeFile(filepath.Join(paths.Dev, name))
+	if err == nil {
+		return fs
 	}
+	if err == fsprobe.ErrUnknownFilesystem {
+		return nil
+	}
+	return udevFilesystem(ud)
+}
 
-	parts := strings.Split(string(out), "PARTUUID=")
-	if len(parts) != 2 {
-		ctx.Warn("failed to parse the partuuid of %s\n", part)
-		return ""
+// diskNodeReadable returns whether the device node of the supplied disk, and
+// the ones of its partitions, may be read to probe their partition table and
+// filesystems. Reads on a suspended device-mapper device (whose dm/suspended
+// file contains 1), or on a multipath map queueing I/O while it has no usable
+// path, block until the device is resumed, and md arrays may stall in the
+// same way. Like udev, which does not probe such devices when they change,
+// ghw never reads device-mapper devices and md arrays, and uses the identity
+// udev recorded for them instead.
+func diskNodeReadable(paths *linuxpath.Paths, disk string) bool {
+	diskPath := filepath.Join(paths.SysBlock, disk)
+	for _, dir := range []string{"dm", "md"} {
+		if _, err := os.Stat(filepath.Join(diskPath, dir)); err == nil {
+			return false
+		}
 	}
+	return true
+}
 
-	return strings.ReplaceAll(strings.TrimSpace(parts[1]), `"`, "")
+// udevFilesystem returns the filesystem identity udev recorded in the
+// supplied entry of a disk or partition, or nil
+func udevFilesystem(ud *udev.Device) *fsprobe.Filesystem {
+	fsType := ud.Property("ID_FS_TYPE")
+	if fsType == "" {
+		return nil
+	}
+	return &fsprobe.Filesystem{
+		Type:       fsType,
+		UUID:       ud.Property("ID_FS_UUID"),
+		DeviceUUID: ud.Property("ID_FS_UUID_SUB"),
+		Label:      ud.Property("ID_FS_LABEL"),
+	}
+}
+
+// partitionNumber returns the number of the supplied partition of a disk,
+// found in the /sys/block/$DEVICE/$PARTITION/partition file, or -1
+func partitionNumber(paths *linuxpath.Paths, disk string, part string) int {
+	path := filepath.Join(paths.SysBlock, disk, part, "partition")
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return -1
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return -1
+	}
+	return number
+}
+
+func tablePartition(table *parttable.Table, number int) *parttable.Partition {
+	if table == nil || number < 0 {
+		return nil
+	}
+	return table.Partition(number)
+}
+
+// blockDeviceLinks returns the names of the entries in one of the "holders" or
+// "slaves" directories sysfs keeps for every block device and partition. Each
+// entry is a symlink named after the kernel name of the related block device.
+func blockDeviceLinks(path string) []string {
+	links, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	out := make([]string, 0, len(links))
+	for _, link := range links {
+		out = append(out, link.Name())
+	}
+	return out
 }
 
 func diskIsRemovable(paths *linuxpath.Paths, disk string) bool {
@@ -251,7 +383,11 @@ func diskIsRemovable(paths *linuxpath.Paths, disk string) bool {
 	return false
 }
 
-func disks(ctx *context.Context, paths *linuxpath.Paths) []*Disk {
+// disks returns the block devices found in /sys/block, whose ancestors are
+// supplied keyed by name, see diskAncestors. The device nodes of the disks
+// are only probed for partition tables and filesystems when the supplied
+// probe flag is set.
+func disks(ctx *context.Context, paths *linuxpath.Paths, ancestors map[string][]string, probe bool) []*Disk {
 	// In Linux, we could use the fdisk, lshw or blockdev commands to list disk
 	// information, however all of these utilities require root privileges to
 	// run. We can get all of this information by examining the /sys/block
@@ -261,13 +397,25 @@ func disks(ctx *context.Context, paths *linuxpath.Paths) []*Disk {
 	if err != nil {
 		return nil
 	}
+	mounts := mountTable(ctx, paths)
+	swaps := swapDevices(paths)
 	for _, file := range files {
 		dname := file.Name()
-		if strings.HasPrefix(dname, "loop") {
+		// Loop, zram and ram devices are only reported on request, and loop
+		// devices only when attached to a backing file
+		if isVirtualBlockDevice(dname) && !ctx.EnableVirtualBlockDevices {
 			continue
 		}
+		var loop *LoopDevice
+		if strings.HasPrefix(dname, "loop") {
+			if loop = diskLoop(paths, dname); loop == nil {
+				continue
+			}
+		}
 
+		transport := diskTransport(ancestors[dname])
 		driveType, storageController := diskTypes(dname)
+		driveType, storageController = transportTypes(transport, driveType, storageController)
 		// TODO(jaypipes): Move this into diskTypes() once abstracting
 		// diskIsRotational for ease of unit testing
 		if !diskIsRotational(ctx, paths, dname) {
@@ -275,12 +423,13 @@ func disks(ctx *context.Context, paths *linuxpath.Paths) []*Disk {
 		}
 		size := diskSizeBytes(paths, dname)
 		pbs := diskPhysicalBlockSizeBytes(paths, dname)
-		busPath := diskBusPath(paths, dname)
-		node := diskNUMANodeID(paths, dname)
+		ud := blockDeviceUdev(ctx, paths, dname)
+		busPath := diskBusPath(ud)
+		node := deviceNUMANodeID(ancestors[dname])
 		vendor := diskVendor(paths, dname)
-		model := diskModel(paths, dname)
-		serialNo := diskSerialNumber(paths, dname)
-		wwn := diskWWN(paths, dname)
+		model := diskModel(ud)
+		serialNo := diskSerialNumber(ud)
+		wwn := diskWWN(ud)
 		removable := diskIsRemovable(paths, dname)
 
 		d := &Disk{
@@ -290,30 +439,53 @@ func disks(ctx *context.Context, paths *linuxpath.Paths) []*Disk {
 			DriveType:              driveType,
 			IsRemovable:            removable,
 			StorageController:      storageController,
+			Transport:              transport,
 			BusPath:                busPath,
 			NUMANodeID:             node,
+			PCIAddress:             diskPCIAddress(ancestors[dname]),
 			Vendor:                 vendor,
 			Model:                  model,
 			SerialNumber:           serialNo,
 			WWN:                    wwn,
+			Holders:                blockDeviceLinks(filepath.Join(paths.SysBlock, dname, "holders")),
+			Queue:                  diskQueue(paths, dname),
+			IsVirtual:              diskIsVirtual(paths, dname),
+			Loop:                   loop,
+			Udev:                   ud,
+		}
+		if strings.HasPrefix(dname, "zram") {
+			d.Zram = diskZram(paths, dname, swaps)
 		}
 
-		parts := diskPartitions(ctx, paths, dname)
+		// Opening the device node of floppy and optical drives may have side
+		// effects, like closing the tray, so those are left to udev too
+		readable := probe && driveType != DRIVE_TYPE_FDD && driveType != DRIVE_TYPE_ODD &&
+			diskNodeReadable(paths, dname)
+		parts, table := diskPartitions(ctx, paths, dname, readable, mounts)
 		// Map this Disk object into the Partition...
 		for _, part := range parts {
 			part.Disk = d
 		}
 		d.Partitions = parts
-
-		mp, pt, ro := mountInfo(paths, dname)
-		if mp != "" {
-			d.MountInfo = &MountInfo{
-				MountPoint: mp,
-				Type:       pt,
-				ReadOnly:   ro,
+		if table != nil {
+			d.PartitionTableType = table.Type
+			d.PartitionTableUUID = table.DiskID
+		}
+		// Whole-disk filesystems (or LVM physical volumes, LUKS containers...)
+		// are only looked for on disks without partitions
+		if len(parts) == 0 {
+			if readable {
+				d.Filesystem = diskFilesystem(paths, dname, ud)
+			} else {
+				d.Filesystem = udevFilesystem(ud)
 			}
 		}
 
+		d.Mounts = deviceMounts(ctx, paths, mounts, filepath.Join(paths.SysBlock, dname), dname)
+		if len(d.Mounts) > 0 {
+			d.MountInfo = d.Mounts[0]
+		}
+
 		disks = append(disks, d)
 	}
 
@@ -381,42 +553,170 @@ func partitionSizeBytes(paths *linuxpath.Paths, disk string, part string) uint64
 	return size * sectorSize
 }
 
-// Given a full or short partition name, returns the mount point, the type of
-// the partition and whether it's readonly
-func mountInfo(paths *linuxpath.Paths, device string) (string, string, bool) {
-	// Allow calling PartitionInfo with either the full partition name
-	// "/dev/sda1" or just "sda1"
-	if !strings.HasPrefix(device, "/dev") {
-		device = "/dev/" + device
+// mountTable returns a slice of pointers to MountInfo structs, one for each
+// filesystem mounted from a block device, in mount order. Devices mounted in
+// several places, e.g. bind mounts or btrfs subvolumes, have several entries.
+func mountTable(ctx *context.Context, paths *linuxpath.Paths) []*MountInfo {
+	out := make([]*MountInfo, 0)
+	entries, err := mountInfoEntries(paths)
+	if err != nil {
+		// Fall back to /proc/self/mounts, whose entries can only be matched
+		// to block devices by device path
+		return legacyMountTable(ctx, paths)
 	}
+	for _, mi := range entries {
+		if !strings.HasPrefix(mi.Source, "/") {
+			continue
+		}
+		out = append(out, mi)
+	}
+	return out
+}
 
-	// mount entries for mounted partitions look like this:
-	// /dev/sda6 / ext4 rw,relatime,errors=remount-ro,data=ordered 0 0
-	var r io.ReadCloser
+// mountInfoEntries returns all the entries of /proc/self/mountinfo, including
+// the ones of pseudo filesystems like proc or tmpfs
+func mountInfoEntries(paths *linuxpath.Paths) ([]*MountInfo, error) {
+	r, err := os.Open(paths.ProcMountinfo)
+	if err != nil {
+		return nil, err
+	}
+	defer util.SafeClose(r)
+
+	out := make([]*MountInfo, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if mi := parseMountInfoEntry(scanner.Text()); mi != nil {
+			out = append(out, mi)
+		}
+	}
+	return out, nil
+}
+
+func legacyMountTable(ctx *context.Context, paths *linuxpath.Paths) []*MountInfo {
+	out := make([]*MountInfo, 0)
 	r, err := os.Open(paths.ProcMounts)
 	if err != nil {
-		return "", "", true
+		return out
 	}
 	defer util.SafeClose(r)
 
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := scanner.Text()
-		entry := parseMountEntry(line)
-		if entry == nil || entry.Device != device {
+		entry := parseMountEntry(scanner.Text())
+		if entry == nil {
 			continue
 		}
-		ro := true
-		for _, opt := range entry.Options {
-			if opt == "rw" {
-				ro = false
-				break
+		out = append(out, &MountInfo{
+			MountPoint: entry.Mountpoint,
+			Type:       entry.FilesystemType,
+			ReadOnly:   mountIsReadOnly(entry.Options),
+			Source:     entry.Device,
+			Options:    entry.Options,
+		})
+	}
+	return out
+}
+
+// deviceMounts returns the entries of the supplied mount table pertaining to
+// the block device whose sysfs directory and name are supplied. Entries are
+// matched on the major:minor numbers of the device, so that devices mounted
+// through symlinks like /dev/disk/by-uuid/$UUID or /dev/mapper/$NAME are
+// found too. Filesystems like btrfs are given an anonymous device number
+// (with a major number of 0) instead, and entries from /proc/self/mounts have
+// none: those are matched on the mounted device path.
+//
+// The Usage of the matched entries is filled in along the way. It is only
+// looked up for mounts backed by a block device, so that statfs(2) is never
+// called on network or FUSE mounts, which hang when their server is gone.
+func deviceMounts(ctx *context.Context, paths *linuxpath.Paths, mounts []*MountInfo, sysPath string, name string) []*MountInfo {
+	out := make([]*MountInfo, 0)
+	majMin := sysfsAttr(sysPath, "dev")
+	mapperName := sysfsAttr(filepath.Join(sysPath, "dm"), "name")
+	for _, mi := range mounts {
+		if mi.MajorMinor != "" && !strings.HasPrefix(mi.MajorMinor, "0:") {
+			if mi.MajorMinor != majMin {
+				continue
 			}
+		} else if !mountSourceIs(ctx, mi.Source, name, mapperName) {
+			continue
+		}
+		if mi.Usage == nil {
+			mi.Usage = mountUsage(ctx, paths, mi.MountPoint)
+		}
+		out = append(out, mi)
+	}
+	return out
+}
+
+// mountSourceIs returns whether the supplied device path of a mount refers to
+// the block device with the supplied name and device-mapper name, if any. The
+// path may be a symlink, e.g. /dev/disk/by-uuid/$UUID.
+func mountSourceIs(ctx *context.Context, source string, name string, mapperName string) bool {
+	if source == "/dev/"+name {
+		return true
+	}
+	if mapperName != "" && source == "/dev/mapper/"+mapperName {
+		return true
+	}
+	if !strings.HasPrefix(source, "/dev/") {
+		return false
+	}
+	resolved, err := evalSymlinks(ctx.Chroot, source)
+	return err == nil && resolved == "/dev/"+name
+}
+
+// parseMountInfoEntry parses a /proc/self/mountinfo line, which looks like
+// this (see proc(5)):
+//
+// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// with zero or more optional propagation fields before the "-" separator
+func parseMountInfoEntry(line string) *MountInfo {
+	fields := strings.Fields(line)
+	sep := -1
+	for x := 6; x < len(fields); x++ {
+		if fields[x] == "-" {
+			sep = x
+			break
 		}
+	}
+	if sep < 0 || len(fields) < sep+3 {
+		return nil
+	}
+	mountID, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil
+	}
+	parentID, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil
+	}
+	options := strings.Split(fields[5], ",")
+	mi := &MountInfo{
+		MountPoint:  unescapeMountPoint(fields[4]),
+		Type:        fields[sep+1],
+		ReadOnly:    mountIsReadOnly(options),
+		MountID:     mountID,
+		ParentID:    parentID,
+		MajorMinor:  fields[2],
+		Root:        unescapeMountPoint(fields[3]),
+		Source:      unescapeMountPoint(fields[sep+2]),
+		Options:     options,
+		Propagation: fields[6:sep],
+	}
+	if len(fields) > sep+3 {
+		mi.SuperOptions = strings.Split(fields[sep+3], ",")
+	}
+	return mi
+}
 
-		return entry.Mountpoint, entry.FilesystemType, ro
+func mountIsReadOnly(options []string) bool {
+	for _, opt := range options {
+		if opt == "rw" {
+			return false
+		}
 	}
-	return "", "", true
+	return true
 }
 
 type mountEntry struct {
@@ -438,6 +738,19 @@ func parseMountEntry(line string) *mountEntry {
 		return nil
 	}
 
+	res := &mountEntry{
+		Device:         fields[0],
+		Mountpoint:     unescapeMountPoint(fields[1]),
+		FilesystemType: fields[2],
+	}
+	opts := strings.Split(fields[3], ",")
+	res.Options = opts
+	return res
+}
+
+// unescapeMountPoint decodes the space, tab, newline and backslash characters
+// encoded in a mount point read from a mount table
+func unescapeMountPoint(mp string) string {
 	// We do some special parsing of the mountpoint, which may contain space,
 	// tab and newline characters, encoded into the mount entry line using their
 	// octal-to-string representations. From the GNU mtab man pages:
@@ -447,33 +760,8 @@ func parseMountEntry(line string) *mountEntry {
 	//   '\040' is used to encode a space character, '\011' to encode a tab
 	//   character, '\012' to encode a newline character, and '\\' to encode a
 	//   backslash."
-	mp := fields[1]
 	r := strings.NewReplacer(
 		"\\011", "\t", "\\012", "\n", "\\040", " ", "\\\\", "\\",
 	)
-	mp = r.Replace(mp)
-
-	res := &mountEntry{
-		Device:         fields[0],
-		Mountpoint:     mp,
-		FilesystemType: fields[2],
-	}
-	opts := strings.Split(fields[3], ",")
-	res.Options = opts
-	return res
-}
-
-func partitionMountPoint(paths *linuxpath.Paths, part string) string {
-	mp, _, _ := mountInfo(paths, part)
-	return mp
-}
-
-func partitionType(paths *linuxpath.Paths, part string) string {
-	_, pt, _ := mountInfo(paths, part)
-	return pt
-}
-
-func partitionIsReadOnly(paths *linuxpath.Paths, part string) bool {
-	_, _, ro := mountInfo(paths, part)
-	return ro
+	return r.Replace(mp)
 }
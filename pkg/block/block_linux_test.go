@@ -9,9 +9,15 @@
 package block
 
 import (
+	"encoding/binary"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/jaypipes/ghw/pkg/block/parttable"
+	"github.com/jaypipes/ghw/pkg/option"
 )
 
 func TestParseMountEntry(t *testing.T) {
@@ -90,6 +96,179 @@ func TestParseMountEntry(t *testing.T) {
 	}
 }
 
+func TestParseMountInfoEntry(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	tests := []struct {
+		line     string
+		expected *MountInfo
+	}{
+		{
+			line: "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue",
+			expected: &MountInfo{
+				MountPoint:   "/mnt2",
+				Type:         "ext3",
+				MountID:      36,
+				ParentID:     35,
+				MajorMinor:   "98:0",
+				Root:         "/mnt1",
+				Source:       "/dev/root",
+				Options:      []string{"rw", "noatime"},
+				Propagation:  []string{"master:1"},
+				SuperOptions: []string{"rw", "errors=continue"},
+			},
+		},
+		{
+			line: "29 1 0:26 /@home /home/Name\\040with\\040spaces ro,relatime shared:2 propagate_from:1 - btrfs /dev/mapper/luks-home rw,ssd,subvol=/@home",
+			expected: &MountInfo{
+				MountPoint:   "/home/Name with spaces",
+				Type:         "btrfs",
+				ReadOnly:     true,
+				MountID:      29,
+				ParentID:     1,
+				MajorMinor:   "0:26",
+				Root:         "/@home",
+				Source:       "/dev/mapper/luks-home",
+				Options:      []string{"ro", "relatime"},
+				Propagation:  []string{"shared:2", "propagate_from:1"},
+				SuperOptions: []string{"rw", "ssd", "subvol=/@home"},
+			},
+		},
+		{
+			line: "25 1 8:1 / / rw - ext4 /dev/sda1",
+			expected: &MountInfo{
+				MountPoint:  "/",
+				Type:        "ext4",
+				MountID:     25,
+				ParentID:    1,
+				MajorMinor:  "8:1",
+				Root:        "/",
+				Source:      "/dev/sda1",
+				Options:     []string{"rw"},
+				Propagation: []string{},
+			},
+		},
+		{
+			line:     "25 1 8:1 / / rw ext4 /dev/sda1 rw",
+			expected: nil,
+		},
+		{
+			line:     "Indy, bad dates",
+			expected: nil,
+		},
+	}
+
+	for x, test := range tests {
+		actual := parseMountInfoEntry(test.line)
+		if test.expected == nil {
+			if actual != nil {
+				t.Fatalf("Expected nil, but got %v", actual)
+			}
+		} else if !reflect.DeepEqual(test.expected, actual) {
+			t.Fatalf("In test %d, expected %+v == %+v", x, test.expected, actual)
+		}
+	}
+}
+
+func TestDiskMounts(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-mounts-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// sda1 is mounted through its UUID symlink and bind-mounted twice into a
+	// container; sda2 is not mounted, sdb is mounted as a whole. sdc1 holds
+	// a btrfs filesystem, given an anonymous device number, whose subvolumes
+	// are mounted through its UUID symlink; sdc2 holds a LUKS container whose
+	// btrfs filesystem is mounted through its device-mapper name.
+	writeFiles(t, root, map[string]string{
+		"sys/block/sda/size":           "2048\n",
+		"sys/block/sda/dev":            "8:0\n",
+		"sys/block/sda/sda1/partition": "1\n",
+		"sys/block/sda/sda1/dev":       "8:1\n",
+		"sys/block/sda/sda2/partition": "2\n",
+		"sys/block/sda/sda2/dev":       "8:2\n",
+		"sys/block/sdb/size":           "2048\n",
+		"sys/block/sdb/dev":            "8:16\n",
+		"sys/block/sdc/size":           "2048\n",
+		"sys/block/sdc/dev":            "8:32\n",
+		"sys/block/sdc/sdc1/partition": "1\n",
+		"sys/block/sdc/sdc1/dev":       "8:33\n",
+		"sys/block/sdc/sdc2/partition": "2\n",
+		"sys/block/sdc/sdc2/dev":       "8:34\n",
+		"sys/block/dm-0/size":          "1024\n",
+		"sys/block/dm-0/dev":           "253:0\n",
+		"sys/block/dm-0/dm/name":       "luks-home\n",
+		"dev/sdc1":                     "",
+		"proc/self/mountinfo": "22 1 0:21 / /proc rw,nosuid - proc proc rw\n" +
+			"25 1 8:1 / /data rw,relatime shared:1 - xfs /dev/disk/by-uuid/3f1c2a9e-5b7d-4e08-9a61-c2d4e6f8a0b1 rw,attr2\n" +
+			"31 25 8:16 / /data/scratch rw shared:5 - ext4 /dev/sdb rw\n" +
+			"40 38 8:1 /volumes/web /var/lib/containers/web/data ro,relatime master:1 - xfs /dev/disk/by-uuid/3f1c2a9e-5b7d-4e08-9a61-c2d4e6f8a0b1 rw,attr2\n" +
+			"41 38 8:1 /volumes/db /var/lib/containers/db/data rw,relatime master:1 - xfs /dev/disk/by-uuid/3f1c2a9e-5b7d-4e08-9a61-c2d4e6f8a0b1 rw,attr2\n" +
+			"50 1 0:26 /@srv /srv rw,relatime shared:7 - btrfs /dev/disk/by-uuid/9b2d4c1e-7a3f-4e5d-8c6b-0f1e2d3c4b5a rw,ssd,subvol=/@srv\n" +
+			"51 1 0:26 /@logs /var/log rw,relatime shared:8 - btrfs /dev/disk/by-uuid/9b2d4c1e-7a3f-4e5d-8c6b-0f1e2d3c4b5a rw,ssd,subvol=/@logs\n" +
+			"52 1 0:27 /@home /home rw,relatime shared:9 - btrfs /dev/mapper/luks-home rw,ssd,subvol=/@home\n",
+	})
+	if err = os.MkdirAll(filepath.Join(root, "dev", "disk", "by-uuid"), os.ModePerm); err != nil {
+		t.Fatalf("Unable to create /dev/disk/by-uuid: %v", err)
+	}
+	if err = os.Symlink("../../sdc1", filepath.Join(root, "dev", "disk", "by-uuid", "9b2d4c1e-7a3f-4e5d-8c6b-0f1e2d3c4b5a")); err != nil {
+		t.Fatalf("Unable to create UUID link: %v", err)
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 4 {
+		t.Fatalf("Expected 4 disks, but got %d", len(info.Disks))
+	}
+	dm0 := info.Disks[0]
+	if len(dm0.Mounts) != 1 || dm0.MountInfo.MountPoint != "/home" || dm0.MountInfo.Root != "/@home" {
+		t.Fatalf("Expected luks-home to be mounted on /home, but got %v", dm0.Mounts)
+	}
+	sda := info.Disks[1]
+	if len(sda.Mounts) != 0 || sda.MountInfo != nil {
+		t.Fatalf("Expected sda not to be mounted, but got %v", sda.Mounts)
+	}
+	sda1 := sda.Partitions[0]
+	if len(sda1.Mounts) != 3 {
+		t.Fatalf("Expected sda1 to be mounted 3 times, but got %d", len(sda1.Mounts))
+	}
+	if sda1.MountInfo != sda1.Mounts[0] || sda1.MountInfo.MountPoint != "/data" {
+		t.Fatalf("Expected the first mount of sda1 to be /data, but got %v", sda1.MountInfo)
+	}
+	web := sda1.Mounts[1]
+	if web.Root != "/volumes/web" || !web.ReadOnly || web.ParentID != 38 || web.Propagation[0] != "master:1" {
+		t.Fatalf("Expected a read-only bind mount of /volumes/web, but got %+v", web)
+	}
+	if len(sda.Partitions[1].Mounts) != 0 {
+		t.Fatalf("Expected sda2 not to be mounted, but got %v", sda.Partitions[1].Mounts)
+	}
+	sdb := info.Disks[2]
+	if len(sdb.Mounts) != 1 || sdb.MountInfo.MountPoint != "/data/scratch" || sdb.MountInfo.Type != "ext4" {
+		t.Fatalf("Expected sdb to be mounted on /data/scratch, but got %v", sdb.Mounts)
+	}
+	sdc := info.Disks[3]
+	if len(sdc.Mounts) != 0 {
+		t.Fatalf("Expected sdc not to be mounted, but got %v", sdc.Mounts)
+	}
+	sdc1 := sdc.Partitions[0]
+	if len(sdc1.Mounts) != 2 || sdc1.Mounts[0].MountPoint != "/srv" || sdc1.Mounts[1].MountPoint != "/var/log" {
+		t.Fatalf("Expected the btrfs subvolumes of sdc1 to be mounted on /srv and /var/log, but got %v", sdc1.Mounts)
+	}
+	if len(sdc.Partitions[1].Mounts) != 0 {
+		t.Fatalf("Expected sdc2 not to be mounted, but got %v", sdc.Partitions[1].Mounts)
+	}
+}
+
 func TestDiskTypes(t *testing.T) {
 	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
 		t.Skip("Skipping block tests.")
@@ -178,3 +357,169 @@ func TestDiskTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestDiskPartitionTable(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-parttable-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, filepath.Join(root, "sys", "block"), map[string]string{
+		"sdb/size":                     "2048\n",
+		"sdb/queue/logical_block_size": "512\n",
+		"sdb/sdb1/size":                "1000\n",
+		"sdb/sdb1/partition":           "1\n",
+		"md127/size":                   "2048\n",
+		"md127/md/level":               "raid1\n",
+		"md127/md127p1/size":           "1000\n",
+		"md127/md127p1/partition":      "1\n",
+		"md127/md127p1/dev":            "259:1\n",
+	})
+	writeFiles(t, filepath.Join(root, "run", "udev", "data"), map[string]string{
+		"b259:1": "E:ID_PART_ENTRY_SCHEME=gpt\nE:ID_PART_ENTRY_TYPE=0fc63daf-8483-4772-8e79-3d69d8477de4\nE:ID_PART_ENTRY_NAME=data\n",
+	})
+
+	// The device node of the disk is replaced by a disk image with an MBR
+	// holding a single bootable Linux partition. The partition table of the
+	// md array, whose device node must not be read, is taken from udev.
+	img := make([]byte, 2048*512)
+	binary.LittleEndian.PutUint32(img[440:], 0x0badcafe)
+	img[446] = 0x80
+	img[446+4] = 0x83
+	binary.LittleEndian.PutUint32(img[446+8:], 2048)
+	binary.LittleEndian.PutUint32(img[446+12:], 1000)
+	img[510], img[511] = 0x55, 0xaa
+	writeFiles(t, filepath.Join(root, "dev"), map[string]string{
+		"sdb":   string(img),
+		"md127": string(img),
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 2 {
+		t.Fatalf("Expected 2 disks, but got %d", len(info.Disks))
+	}
+	md := info.Disks[0]
+	if md.PartitionTableType != parttable.TABLE_TYPE_UNKNOWN {
+		t.Fatalf("Expected the device node of md127 not to be read, but got a %s partition table", md.PartitionTableType)
+	}
+	if p := md.Partitions[0]; p.Label != "data" || p.TypeName != "Linux filesystem" {
+		t.Fatalf("Expected the Linux filesystem partition data on md127, but got %s (%s)", p.Label, p.TypeName)
+	}
+	d := info.Disks[1]
+	if d.PartitionTableType != parttable.TABLE_TYPE_MBR {
+		t.Fatalf("Expected an MBR partition table, but got %s", d.PartitionTableType)
+	}
+	if d.PartitionTableUUID != "0badcafe" {
+		t.Fatalf("Expected partition table UUID 0badcafe, but got %s", d.PartitionTableUUID)
+	}
+	if len(d.Partitions) != 1 {
+		t.Fatalf("Expected 1 partition, but got %d", len(d.Partitions))
+	}
+	p := d.Partitions[0]
+	if p.UUID != "0badcafe-01" {
+		t.Fatalf("Expected partition UUID 0badcafe-01, but got %s", p.UUID)
+	}
+	if p.TypeID != "0x83" || p.TypeName != "Linux" {
+		t.Fatalf("Expected a Linux partition, but got %s (%s)", p.TypeID, p.TypeName)
+	}
+	if p.Attributes != parttable.ATTRIBUTE_LEGACY_BIOS_BOOTABLE {
+		t.Fatalf("Expected a bootable partition, but got attributes %x", p.Attributes)
+	}
+	if p.FirstLBA != 2048 || p.LastLBA != 3047 {
+		t.Fatalf("Expected partition to span LBAs 2048-3047, but got %d-%d", p.FirstLBA, p.LastLBA)
+	}
+}
+
+func TestDiskFilesystem(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-fsprobe-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, filepath.Join(root, "sys", "block"), map[string]string{
+		"sdb/size":          "4096\n",
+		"sdb/dev":           "8:16\n",
+		"sdb/sdb1/size":     "2048\n",
+		"sdb/sdb1/dev":      "8:17\n",
+		"sdc/size":          "2048\n",
+		"sdc/dev":           "8:32\n",
+		"dm-0/size":         "2048\n",
+		"dm-0/dev":          "253:0\n",
+		"dm-0/dm/name":      "vg0-data\n",
+		"dm-0/dm/suspended": "1\n",
+	})
+	// The device node of sdb1 holds an LVM2 physical volume, while the one
+	// of sdc is missing, so its filesystem has to be taken from udev. The
+	// device node of dm-0, which may block on reads, must not be read.
+	pv := make([]byte, 2048)
+	copy(pv[512:], "LABELONE")
+	binary.LittleEndian.PutUint32(pv[512+20:], 32)
+	copy(pv[512+24:], "LVM2 001")
+	copy(pv[512+32:], "Wb5Hs3yTqLa7AEc0mJ2yR9kfXz1NpVd4")
+	writeFiles(t, filepath.Join(root, "dev"), map[string]string{
+		"sdb1": string(pv),
+		"dm-0": string(pv),
+	})
+	writeFiles(t, filepath.Join(root, "run", "udev", "data"), map[string]string{
+		"b8:17":  "E:ID_FS_TYPE=ext4\n",
+		"b8:32":  "S:disk/by-label/scratch\nS:disk/by-id/wwn-0x5000c500a1b2c3d4\nE:ID_FS_TYPE=xfs\nE:ID_FS_UUID=0b1e2f3a-4c5d-4e6f-8a9b-0c1d2e3f4a5b\nE:ID_FS_LABEL=scratch\nG:systemd\n",
+		"b253:0": "E:ID_FS_TYPE=ext4\nE:ID_FS_UUID=5d3c1b2a-9e8f-4a7b-b6c5-d4e3f2a1b0c9\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 3 {
+		t.Fatalf("Expected 3 disks, but got %d", len(info.Disks))
+	}
+
+	fs := info.Disks[0].Filesystem
+	if fs == nil || fs.Type != "ext4" || fs.UUID != "5d3c1b2a-9e8f-4a7b-b6c5-d4e3f2a1b0c9" {
+		t.Fatalf("Expected the filesystem identity of dm-0 recorded by udev, but got %v", fs)
+	}
+
+	sdb := info.Disks[1]
+	if sdb.Filesystem != nil {
+		t.Fatalf("Expected no filesystem on partitioned disk sdb, but got %v", sdb.Filesystem)
+	}
+	fs = sdb.Partitions[0].Filesystem
+	if fs == nil || fs.Type != "LVM2_member" {
+		t.Fatalf("Expected an LVM2 physical volume on sdb1, but got %v", fs)
+	}
+	if fs.UUID != "Wb5Hs3-yTqL-a7AE-c0mJ-2yR9-kfXz-1NpVd4" {
+		t.Fatalf("Expected physical volume UUID Wb5Hs3-yTqL-a7AE-c0mJ-2yR9-kfXz-1NpVd4, but got %s", fs.UUID)
+	}
+
+	fs = info.Disks[2].Filesystem
+	if fs == nil || fs.Type != "xfs" {
+		t.Fatalf("Expected an xfs filesystem on sdc, but got %v", fs)
+	}
+	if fs.UUID != "0b1e2f3a-4c5d-4e6f-8a9b-0c1d2e3f4a5b" || fs.Label != "scratch" {
+		t.Fatalf("Expected the filesystem identity recorded by udev, but got %v", fs)
+	}
+
+	if sdb.Udev != nil || sdb.Partitions[0].Udev == nil {
+		t.Fatalf("Expected only sdb1 to have a udev entry, but got %v and %v", sdb.Udev, sdb.Partitions[0].Udev)
+	}
+	ud := info.Disks[2].Udev
+	if ud == nil || ud.Property("ID_FS_LABEL") != "scratch" {
+		t.Fatalf("Expected the udev properties of sdc, but got %v", ud)
+	}
+	if links := ud.LinksIn("/dev/disk/by-id"); len(links) != 1 || links[0] != "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4" {
+		t.Fatalf("Expected sdc to be /dev/disk/by-id/wwn-0x5000c500a1b2c3d4, but got %v", links)
+	}
+}
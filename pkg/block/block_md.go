@@ -0,0 +1,84 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+)
+
+// MDArray describes a Linux software RAID (md) array, e.g. "md0"
+type MDArray struct {
+	// Disk is the Disk entry for the array itself
+	Disk *Disk `json:"-"`
+	// Name is the kernel name of the array, e.g. "md127"
+	Name string `json:"name"`
+	// Level is the RAID level of the array, e.g. "raid1" or "raid10"
+	Level string `json:"level"`
+	// State is the state of the array as reported by the kernel, e.g.
+	// "clean", "active" or "inactive"
+	State string `json:"state"`
+	// NumRaidDisks is the number of member devices the array is meant to have
+	NumRaidDisks int `json:"raid_disks"`
+	// ChunkSizeBytes is the chunk size of striped arrays. It is zero for
+	// levels not using chunks, like raid1
+	ChunkSizeBytes uint64 `json:"chunk_size_bytes"`
+	// NumDegraded is the number of member devices missing from the array
+	NumDegraded int `json:"degraded"`
+	// SyncAction is the current synchronisation action of the array, e.g.
+	// "idle", "resync", "recover" or "check"
+	SyncAction string `json:"sync_action"`
+	// SyncCompleted is the progress of the current synchronisation action, as
+	// "<done sectors> / <total sectors>", or "none"
+	SyncCompleted string      `json:"sync_completed"`
+	Members       []*MDMember `json:"members"`
+}
+
+// IsDegraded returns true if the array is missing at least one member device
+func (a *MDArray) IsDegraded() bool {
+	return a.NumDegraded > 0
+}
+
+func (a *MDArray) String() string {
+	degraded := ""
+	if a.IsDegraded() {
+		degraded = fmt.Sprintf(" degraded=%d", a.NumDegraded)
+	}
+	sync := ""
+	if a.SyncAction != "" && a.SyncAction != "idle" {
+		sync = fmt.Sprintf(" sync_action=%s (%s)", a.SyncAction, a.SyncCompleted)
+	}
+	return fmt.Sprintf(
+		"%s %s [%s] (%d/%d members)%s%s",
+		a.Name,
+		a.Level,
+		a.State,
+		len(a.Members),
+		a.NumRaidDisks,
+		degraded,
+		sync,
+	)
+}
+
+// MDMember describes a block device which is a member of a software RAID
+// array
+type MDMember struct {
+	Array *MDArray `json:"-"`
+	// ArrayName is the kernel name of the array, e.g. "md0"
+	ArrayName string `json:"array"`
+	// Name is the kernel name of the member device, e.g. "sda1"
+	Name string `json:"name"`
+	// Slot is the role of the member within the array, or -1 for spares and
+	// failed devices
+	Slot int `json:"slot"`
+	// State contains the state flags of the member, e.g. "in_sync", "faulty",
+	// "spare" or "write_mostly"
+	State []string `json:"state"`
+	// Disk points to the member's Disk, if the member is a whole disk
+	Disk *Disk `json:"-"`
+	// Partition points to the member's Partition, if the member is a partition
+	Partition *Partition `json:"-"`
+}
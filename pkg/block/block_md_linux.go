@@ -0,0 +1,96 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// mdArrays returns a slice of pointers to MDArray structs, one for each
+// software RAID array found in the supplied Disks. Each member of the arrays
+// is linked back to its Disk or Partition, and vice versa.
+func mdArrays(paths *linuxpath.Paths, disks []*Disk) []*MDArray {
+	// The md devices are listed in /sys/block like any other block device.
+	// They can be told apart by the /sys/block/$DEVICE/md directory, which
+	// describes the array and contains one dev-$MEMBER subdirectory for each
+	// member device.
+	disksByName := make(map[string]*Disk, len(disks))
+	partsByName := make(map[string]*Partition)
+	for _, d := range disks {
+		disksByName[d.Name] = d
+		for _, p := range d.Partitions {
+			partsByName[p.Name] = p
+		}
+	}
+
+	out := make([]*MDArray, 0)
+	for _, d := range disks {
+		mdPath := filepath.Join(paths.SysBlock, d.Name, "md")
+		if _, err := os.Stat(mdPath); err != nil {
+			continue
+		}
+		a := &MDArray{
+			Disk:           d,
+			Name:           d.Name,
+			Level:          sysfsAttr(mdPath, "level"),
+			State:          sysfsAttr(mdPath, "array_state"),
+			NumRaidDisks:   int(sysfsUintAttr(mdPath, "raid_disks")),
+			ChunkSizeBytes: sysfsUintAttr(mdPath, "chunk_size"),
+			NumDegraded:    int(sysfsUintAttr(mdPath, "degraded")),
+			SyncAction:     sysfsAttr(mdPath, "sync_action"),
+			SyncCompleted:  sysfsAttr(mdPath, "sync_completed"),
+			Members:        make([]*MDMember, 0),
+		}
+		devPaths, _ := filepath.Glob(filepath.Join(mdPath, "dev-*"))
+		for _, devPath := range devPaths {
+			m := &MDMember{
+				Array:     a,
+				ArrayName: a.Name,
+				Name:      strings.TrimPrefix(filepath.Base(devPath), "dev-"),
+				Slot:      mdMemberSlot(sysfsAttr(devPath, "slot")),
+				State:     mdMemberState(sysfsAttr(devPath, "state")),
+			}
+			if md, ok := disksByName[m.Name]; ok {
+				m.Disk = md
+				md.MDMember = m
+			} else if mp, ok := partsByName[m.Name]; ok {
+				m.Partition = mp
+				mp.MDMember = m
+			}
+			a.Members = append(a.Members, m)
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// mdMemberSlot parses the contents of the md/dev-$MEMBER/slot file, which is
+// either the numeric role of the member in the array or "none" for spares and
+// failed devices
+func mdMemberSlot(slot string) int {
+	val, err := strconv.Atoi(slot)
+	if err != nil {
+		return -1
+	}
+	return val
+}
+
+// mdMemberState parses the contents of the md/dev-$MEMBER/state file, a
+// comma-separated list of flags, into an empty slice when it is missing
+func mdMemberState(state string) []string {
+	out := make([]string, 0)
+	for _, flag := range strings.Split(state, ",") {
+		if flag != "" {
+			out = append(out, flag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestMDArrays(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-md-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, filepath.Join(root, "sys", "block"), map[string]string{
+		"sda/size":              "2048\n",
+		"sda/sda1/size":         "2048\n",
+		"sda/sda1/holders/md0":  "",
+		"sdb/size":              "2048\n",
+		"sdb/holders/md0":       "",
+		"md0/size":              "2048\n",
+		"md0/md/level":          "raid1\n",
+		"md0/md/array_state":    "clean\n",
+		"md0/md/raid_disks":     "2\n",
+		"md0/md/chunk_size":     "0\n",
+		"md0/md/degraded":       "1\n",
+		"md0/md/sync_action":    "recover\n",
+		"md0/md/sync_completed": "512 / 2048\n",
+		"md0/md/dev-sda1/slot":  "0\n",
+		"md0/md/dev-sda1/state": "in_sync\n",
+		"md0/md/dev-sdb/slot":   "none\n",
+		"md0/md/dev-sdb/state":  "spare\n",
+		"md0/md/dev-sdb/errors": "0\n",
+		"md0/md/dev-sdc/slot":   "none\n",
+		"md0/slaves/sda1":       "",
+		"md0/slaves/sdb":        "",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.MDArrays) != 1 {
+		t.Fatalf("Expected 1 md array, but got %d", len(info.MDArrays))
+	}
+	a := info.MDArrays[0]
+	if a.Level != "raid1" || a.State != "clean" || a.NumRaidDisks != 2 {
+		t.Fatalf("Unexpected md array description: %v", a)
+	}
+	if !a.IsDegraded() || a.SyncAction != "recover" {
+		t.Fatalf("Expected a degraded array under recovery, but got %v", a)
+	}
+	if len(a.Members) != 3 {
+		t.Fatalf("Expected 3 array members, but got %d", len(a.Members))
+	}
+
+	m0 := a.Members[0]
+	if m0.Name != "sda1" || m0.Slot != 0 || !reflect.DeepEqual(m0.State, []string{"in_sync"}) {
+		t.Fatalf("Unexpected array member: %+v", m0)
+	}
+	if m0.Partition == nil || m0.Partition.MDMember != m0 {
+		t.Fatalf("Expected member sda1 to be linked to its partition")
+	}
+	m1 := a.Members[1]
+	if m1.Name != "sdb" || m1.Slot != -1 || !reflect.DeepEqual(m1.State, []string{"spare"}) {
+		t.Fatalf("Unexpected array member: %+v", m1)
+	}
+	if m1.Disk == nil || m1.Disk.MDMember != m1 {
+		t.Fatalf("Expected member sdb to be linked to its disk")
+	}
+	if m1.Disk.MDMember.ArrayName != "md0" {
+		t.Fatalf("Expected sdb to be a member of md0, but got %s", m1.Disk.MDMember.ArrayName)
+	}
+	// The state file of sdc is missing
+	if m2 := a.Members[2]; m2.State == nil || len(m2.State) != 0 {
+		t.Fatalf("Expected member sdc to have no state flags, but got %q", m2.State)
+	}
+}
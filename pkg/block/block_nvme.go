@@ -0,0 +1,136 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NVMeTransport describes the transport used to reach an NVMe controller
+type NVMeTransport int
+
+const (
+	NVME_TRANSPORT_UNKNOWN NVMeTransport = iota
+	NVME_TRANSPORT_PCIE                  // Locally attached PCIe controller
+	NVME_TRANSPORT_TCP                   // NVMe over TCP
+	NVME_TRANSPORT_RDMA                  // NVMe over RDMA (RoCE, iWARP, InfiniBand)
+	NVME_TRANSPORT_FC                    // NVMe over Fibre Channel
+	NVME_TRANSPORT_LOOP                  // Loopback target, used for testing
+)
+
+var (
+	nvmeTransportString = map[NVMeTransport]string{
+		NVME_TRANSPORT_UNKNOWN: "Unknown",
+		NVME_TRANSPORT_PCIE:    "PCIe",
+		NVME_TRANSPORT_TCP:     "TCP",
+		NVME_TRANSPORT_RDMA:    "RDMA",
+		NVME_TRANSPORT_FC:      "FC",
+		NVME_TRANSPORT_LOOP:    "loop",
+	}
+
+	// nvmeTransportByName maps the contents of the
+	// /sys/class/nvme/$CONTROLLER/transport file to an NVMeTransport
+	nvmeTransportByName = map[string]NVMeTransport{
+		"pcie": NVME_TRANSPORT_PCIE,
+		"tcp":  NVME_TRANSPORT_TCP,
+		"rdma": NVME_TRANSPORT_RDMA,
+		"fc":   NVME_TRANSPORT_FC,
+		"loop": NVME_TRANSPORT_LOOP,
+	}
+)
+
+func (t NVMeTransport) String() string {
+	return nvmeTransportString[t]
+}
+
+// MarshalJSON encodes the NVMe transport as its lowercased name
+func (t NVMeTransport) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + strings.ToLower(t.String()) + "\""), nil
+}
+
+// NVMeController describes an NVMe controller, either a local PCIe device or
+// a connection to an NVMe over Fabrics target
+type NVMeController struct {
+	// Name is the kernel name of the controller, e.g. "nvme0"
+	Name             string        `json:"name"`
+	Model            string        `json:"model"`
+	SerialNumber     string        `json:"serial_number"`
+	FirmwareRevision string        `json:"firmware_revision"`
+	Transport        NVMeTransport `json:"transport"`
+	// Address is the transport address of the controller: the PCI address
+	// for PCIe controllers, or e.g. "traddr=10.0.0.1,trsvcid=4420" for
+	// fabrics controllers
+	Address string `json:"address"`
+	// PCIAddress is the PCI address of PCIe controllers, e.g.
+	// "0000:01:00.0". It is empty for fabrics controllers.
+	PCIAddress string `json:"pci_address,omitempty"`
+	// SubsystemNQN is the NVMe Qualified Name of the subsystem the
+	// controller belongs to
+	SubsystemNQN string `json:"subsystem_nqn"`
+	// ControllerID is the controller identifier within its subsystem
+	// (CNTLID)
+	ControllerID int `json:"controller_id"`
+	// State is the state of the controller, e.g. "live", "connecting" or
+	// "resetting"
+	State string `json:"state"`
+	// Namespaces contains the kernel names of the namespace block devices
+	// reachable through the controller, e.g. "nvme0n1"
+	Namespaces []string `json:"namespaces"`
+}
+
+func (c *NVMeController) String() string {
+	return fmt.Sprintf(
+		"%s %s serial=%s firmware=%s [%s %s] nqn=%s cntlid=%d state=%s (%d namespaces)",
+		c.Name,
+		c.Model,
+		c.SerialNumber,
+		c.FirmwareRevision,
+		c.Transport.String(),
+		c.Address,
+		c.SubsystemNQN,
+		c.ControllerID,
+		c.State,
+		len(c.Namespaces),
+	)
+}
+
+// NVMeNamespace describes the NVMe namespace backing a Disk
+type NVMeNamespace struct {
+	// NSID is the namespace identifier within its subsystem
+	NSID int `json:"nsid"`
+	// WWID is the world wide identifier of the namespace, built by the
+	// kernel from the first available of its EUI-64, NGUID or UUID
+	WWID string `json:"wwid"`
+	// LBASizeBytes is the size of the logical blocks of the namespace's
+	// current LBA format
+	LBASizeBytes uint64 `json:"lba_size_bytes"`
+	// MetadataSizeBytes is the size of the metadata stored with each logical
+	// block in the namespace's current LBA format
+	MetadataSizeBytes uint64 `json:"metadata_size_bytes"`
+	// Controller points to the controller serving the namespace. For
+	// multipath namespaces, it is the controller of the first path.
+	Controller *NVMeController `json:"-"`
+	// ControllerName is the kernel name of Controller
+	ControllerName string `json:"controller"`
+	// Paths contains the paths to a namespace shared by several controllers,
+	// when native NVMe multipathing is enabled
+	Paths []*NVMePath `json:"paths,omitempty"`
+}
+
+// NVMePath describes one of the paths to a multipath NVMe namespace
+type NVMePath struct {
+	// Name is the kernel name of the path device, e.g. "nvme0c1n1"
+	Name       string          `json:"name"`
+	Controller *NVMeController `json:"-"`
+	// ControllerName is the kernel name of the controller the path goes
+	// through
+	ControllerName string `json:"controller"`
+	// ANAState is the Asymmetric Namespace Access state of the path, e.g.
+	// "optimized", "non-optimized" or "inaccessible"
+	ANAState string `json:"ana_state"`
+}
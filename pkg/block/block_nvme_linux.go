@@ -0,0 +1,149 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+	"github.com/jaypipes/ghw/pkg/util"
+)
+
+var (
+	// Namespaces attached to a single controller are named
+	// nvme$CONTROLLERn$NAMESPACE, e.g. "nvme0n1". With native multipathing,
+	// the namespace block device is named nvme$SUBSYSTEMn$NAMESPACE and each
+	// of its paths is a hidden block device named
+	// nvme$SUBSYSTEMc$CONTROLLERn$NAMESPACE, e.g. "nvme0c1n1"
+	nvmeNamespaceRegex = regexp.MustCompile(`^nvme\d+n\d+$`)
+	nvmePathRegex      = regexp.MustCompile(`^(nvme\d+)c\d+(n\d+)$`)
+)
+
+// nvmeControllers returns a slice of pointers to NVMeController structs, one
+// for each NVMe controller found in /sys/class/nvme. The NVMe namespaces in
+// the supplied Disks are linked to their controllers.
+func nvmeControllers(paths *linuxpath.Paths, disks []*Disk) []*NVMeController {
+	out := make([]*NVMeController, 0)
+	entries, err := ioutil.ReadDir(paths.SysClassNVMe)
+	if err != nil {
+		return out
+	}
+	disksByName := make(map[string]*Disk, len(disks))
+	for _, d := range disks {
+		disksByName[d.Name] = d
+	}
+
+	for _, entry := range entries {
+		cname := entry.Name()
+		cpath := filepath.Join(paths.SysClassNVMe, cname)
+		c := &NVMeController{
+			Name:             cname,
+			Model:            sysfsAttr(cpath, "model"),
+			SerialNumber:     sysfsAttr(cpath, "serial"),
+			FirmwareRevision: sysfsAttr(cpath, "firmware_rev"),
+			Transport:        nvmeTransportByName[sysfsAttr(cpath, "transport")],
+			Address:          sysfsAttr(cpath, "address"),
+			SubsystemNQN:     sysfsAttr(cpath, "subsysnqn"),
+			ControllerID:     int(sysfsUintAttr(cpath, "cntlid")),
+			State:            sysfsAttr(cpath, "state"),
+			Namespaces:       make([]string, 0),
+		}
+		if c.Transport == NVME_TRANSPORT_PCIE {
+			c.PCIAddress = nvmePCIAddress(cpath, c.Address)
+		}
+
+		nsEntries, err := ioutil.ReadDir(cpath)
+		if err != nil {
+			out = append(out, c)
+			continue
+		}
+		for _, nsEntry := range nsEntries {
+			name := nsEntry.Name()
+			if nvmeNamespaceRegex.MatchString(name) {
+				d, ok := disksByName[name]
+				if !ok {
+					continue
+				}
+				ns := nvmeNamespace(paths, d)
+				ns.Controller = c
+				ns.ControllerName = c.Name
+				c.Namespaces = append(c.Namespaces, name)
+			} else if m := nvmePathRegex.FindStringSubmatch(name); m != nil {
+				d, ok := disksByName[m[1]+m[2]]
+				if !ok {
+					continue
+				}
+				ns := nvmeNamespace(paths, d)
+				if ns.Controller == nil {
+					ns.Controller = c
+					ns.ControllerName = c.Name
+				}
+				ns.Paths = append(ns.Paths, &NVMePath{
+					Name:           name,
+					Controller:     c,
+					ControllerName: c.Name,
+					ANAState:       sysfsAttr(filepath.Join(cpath, name), "ana_state"),
+				})
+				c.Namespaces = append(c.Namespaces, d.Name)
+			}
+		}
+		out = append(out, c)
+	}
+
+	// Without access to the udev database, the model and serial number of
+	// the namespaces can still be taken from their controller. So is their
+	// transport, since multipath namespaces are not nested in the sysfs
+	// directory of any controller.
+	for _, d := range disks {
+		ns := d.NVMeNamespace
+		if ns == nil || ns.Controller == nil {
+			continue
+		}
+		if t, ok := transportByNVMeTransport[ns.Controller.Transport]; ok {
+			d.Transport = t
+		}
+		if d.Model == util.UNKNOWN {
+			d.Model = ns.Controller.Model
+		}
+		if d.SerialNumber == util.UNKNOWN {
+			d.SerialNumber = ns.Controller.SerialNumber
+		}
+	}
+	return out
+}
+
+// nvmeNamespace returns the NVMeNamespace of the supplied Disk, creating it
+// from the /sys/block/$DEVICE attributes if needed
+func nvmeNamespace(paths *linuxpath.Paths, d *Disk) *NVMeNamespace {
+	if d.NVMeNamespace != nil {
+		return d.NVMeNamespace
+	}
+	dpath := filepath.Join(paths.SysBlock, d.Name)
+	d.NVMeNamespace = &NVMeNamespace{
+		NSID:              int(sysfsUintAttr(dpath, "nsid")),
+		WWID:              sysfsAttr(dpath, "wwid"),
+		LBASizeBytes:      diskLogicalBlockSizeBytes(paths, d.Name),
+		MetadataSizeBytes: sysfsUintAttr(dpath, "metadata_bytes"),
+	}
+	return d.NVMeNamespace
+}
+
+// nvmePCIAddress returns the PCI address of a PCIe controller. Recent kernels
+// report it in the "address" attribute; older ones only link the controller
+// to its PCI device through the "device" symlink.
+func nvmePCIAddress(cpath string, address string) string {
+	if address != "" {
+		return address
+	}
+	dest, err := os.Readlink(filepath.Join(cpath, "device"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(dest)
+}
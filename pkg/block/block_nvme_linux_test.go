@@ -0,0 +1,118 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestNVMeControllers(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-nvme-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, filepath.Join(root, "sys", "block"), map[string]string{
+		"nvme0n1/size":                     "2048\n",
+		"nvme0n1/nsid":                     "1\n",
+		"nvme0n1/wwid":                     "eui.0025388b91b2c1d5\n",
+		"nvme0n1/metadata_bytes":           "8\n",
+		"nvme0n1/queue/logical_block_size": "4096\n",
+		"nvme2n1/size":                     "2048\n",
+		"nvme2n1/nsid":                     "3\n",
+		"nvme2n1/wwid":                     "uuid.6a3b2c1d-5e4f-4a3b-9c2d-1e0f9a8b7c6d\n",
+		"nvme2n1/queue/logical_block_size": "512\n",
+	})
+	// nvme0 is a local PCIe drive. nvme1 and nvme2 are two NVMe/TCP
+	// connections to the same subsystem, whose namespace 3 is reachable
+	// through both of them.
+	writeFiles(t, filepath.Join(root, "sys", "class", "nvme"), map[string]string{
+		"nvme0/model":               "Samsung SSD 980 PRO 1TB\n",
+		"nvme0/serial":              "S5GXNF0R123456\n",
+		"nvme0/firmware_rev":        "5B2QGXA7\n",
+		"nvme0/transport":           "pcie\n",
+		"nvme0/address":             "0000:01:00.0\n",
+		"nvme0/subsysnqn":           "nqn.1994-11.com.samsung:nvme:980PRO:M.2:S5GXNF0R123456\n",
+		"nvme0/cntlid":              "6\n",
+		"nvme0/state":               "live\n",
+		"nvme0/nvme0n1/nsid":        "1\n",
+		"nvme1/model":               "Linux\n",
+		"nvme1/serial":              "7c3b0a3f9e2d1c4b\n",
+		"nvme1/firmware_rev":        "6.1.0\n",
+		"nvme1/transport":           "tcp\n",
+		"nvme1/address":             "traddr=10.0.0.1,trsvcid=4420,src_addr=10.0.0.9\n",
+		"nvme1/subsysnqn":           "nqn.2014-08.org.nvmexpress:target1\n",
+		"nvme1/cntlid":              "1\n",
+		"nvme1/state":               "live\n",
+		"nvme1/nvme2c1n1/ana_state": "optimized\n",
+		"nvme2/model":               "Linux\n",
+		"nvme2/transport":           "tcp\n",
+		"nvme2/address":             "traddr=10.0.1.1,trsvcid=4420,src_addr=10.0.1.9\n",
+		"nvme2/subsysnqn":           "nqn.2014-08.org.nvmexpress:target1\n",
+		"nvme2/cntlid":              "2\n",
+		"nvme2/state":               "connecting\n",
+		"nvme2/nvme2c2n1/ana_state": "inaccessible\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.NVMeControllers) != 3 {
+		t.Fatalf("Expected 3 NVMe controllers, but got %d", len(info.NVMeControllers))
+	}
+	c := info.NVMeControllers[0]
+	if c.Transport != NVME_TRANSPORT_PCIE || c.PCIAddress != "0000:01:00.0" {
+		t.Fatalf("Expected nvme0 to be a PCIe controller at 0000:01:00.0, but got %s", c)
+	}
+	if c.ControllerID != 6 || c.State != "live" || c.FirmwareRevision != "5B2QGXA7" {
+		t.Fatalf("Unexpected nvme0 controller attributes: %s", c)
+	}
+	if tc := info.NVMeControllers[1]; tc.Transport != NVME_TRANSPORT_TCP || tc.PCIAddress != "" {
+		t.Fatalf("Expected nvme1 to be an NVMe/TCP controller, but got %s", tc)
+	}
+
+	if len(info.Disks) != 2 {
+		t.Fatalf("Expected 2 disks, but got %d", len(info.Disks))
+	}
+	ns := info.Disks[0].NVMeNamespace
+	if ns == nil || ns.Controller != c {
+		t.Fatalf("Expected nvme0n1 to be a namespace of nvme0, but got %v", ns)
+	}
+	if ns.NSID != 1 || ns.LBASizeBytes != 4096 || ns.MetadataSizeBytes != 8 {
+		t.Fatalf("Expected nsid 1 with a 4096+8 LBA format, but got nsid %d with %d+%d", ns.NSID, ns.LBASizeBytes, ns.MetadataSizeBytes)
+	}
+	if info.Disks[0].Model != "Samsung SSD 980 PRO 1TB" {
+		t.Fatalf("Expected nvme0n1 model to be taken from its controller, but got %s", info.Disks[0].Model)
+	}
+	if info.Disks[0].Transport != TRANSPORT_NVME_PCIE || info.Disks[1].Transport != TRANSPORT_NVME_TCP {
+		t.Fatalf("Expected nvme0n1 and nvme2n1 to be reached over PCIe and TCP, but got %s and %s", info.Disks[0].Transport, info.Disks[1].Transport)
+	}
+
+	ns = info.Disks[1].NVMeNamespace
+	if ns == nil || ns.ControllerName != "nvme1" || ns.NSID != 3 {
+		t.Fatalf("Expected nvme2n1 to be namespace 3 of nvme1, but got %v", ns)
+	}
+	if len(ns.Paths) != 2 {
+		t.Fatalf("Expected 2 paths to nvme2n1, but got %d", len(ns.Paths))
+	}
+	if ns.Paths[1].ControllerName != "nvme2" || ns.Paths[1].ANAState != "inaccessible" {
+		t.Fatalf("Expected an inaccessible path through nvme2, but got %v", ns.Paths[1])
+	}
+}
@@ -0,0 +1,107 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BtrfsFilesystem describes a btrfs filesystem, which may span several block
+// devices
+type BtrfsFilesystem struct {
+	UUID  string `json:"uuid"`
+	Label string `json:"label"`
+	// Devices contains the names of the block devices the filesystem spans,
+	// e.g. "sda2" or "dm-0"
+	Devices []string `json:"devices"`
+	// Data, Metadata and System describe the space allocated to each type
+	// of block group
+	Data     *BtrfsAllocation `json:"data,omitempty"`
+	Metadata *BtrfsAllocation `json:"metadata,omitempty"`
+	System   *BtrfsAllocation `json:"system,omitempty"`
+}
+
+func (fs *BtrfsFilesystem) String() string {
+	profiles := ""
+	if fs.Data != nil && fs.Metadata != nil {
+		profiles = fmt.Sprintf(" data=%s metadata=%s", fs.Data.Profile, fs.Metadata.Profile)
+	}
+	return fmt.Sprintf(
+		"btrfs %s label=%q devices=[%s]%s",
+		fs.UUID,
+		fs.Label,
+		strings.Join(fs.Devices, " "),
+		profiles,
+	)
+}
+
+// BtrfsAllocation describes the space allocated to a type of btrfs block
+// group
+type BtrfsAllocation struct {
+	// Profile is the RAID profile of the block groups, e.g. "single", "dup"
+	// or "raid1"
+	Profile string `json:"profile"`
+	// TotalBytes and UsedBytes are the allocated and used space, as seen by
+	// the filesystem
+	TotalBytes uint64 `json:"total_bytes"`
+	UsedBytes  uint64 `json:"used_bytes"`
+	// DiskTotalBytes and DiskUsedBytes are the allocated and used raw space
+	// on the devices, e.g. twice TotalBytes and UsedBytes with the raid1
+	// profile
+	DiskTotalBytes uint64 `json:"disk_total_bytes"`
+	DiskUsedBytes  uint64 `json:"disk_used_bytes"`
+}
+
+// ZFSPool describes a ZFS storage pool imported on the host
+type ZFSPool struct {
+	Name string `json:"name"`
+	// GUID is the GUID of the pool, as recorded in the labels of its member
+	// devices. It is empty when none of them could be read, or when labels
+	// of several pools of that name were found and the zfs module does not
+	// report which one is imported.
+	GUID string `json:"guid"`
+	// State is the state of the pool, e.g. "ONLINE" or "DEGRADED"
+	State string `json:"state"`
+	// Devices contains the names of the disks and partitions holding a
+	// vdev of the pool, e.g. "sdb" or "nvme0n1p3"
+	Devices []string `json:"devices"`
+	// Vdevs contains the top-level vdevs of the pool the member devices
+	// belong to. Devices whose label could not be read, and whose filesystem
+	// identity was taken from udev, are not part of any of them.
+	Vdevs []*ZFSVdev `json:"vdevs"`
+}
+
+func (p *ZFSPool) String() string {
+	return fmt.Sprintf(
+		"zfs pool %s state=%s devices=[%s]",
+		p.Name,
+		p.State,
+		strings.Join(p.Devices, " "),
+	)
+}
+
+// ZFSVdev describes a top-level vdev of a ZFS pool, as recorded in the labels
+// of its member devices
+type ZFSVdev struct {
+	GUID string `json:"guid"`
+	// Type is the type of the vdev: "mirror", "raidz1", "raidz2", "raidz3" or
+	// "draid", or "disk" or "file" when a single device makes up the vdev
+	Type string `json:"type"`
+	// Devices contains the names of the disks and partitions holding the
+	// vdev, e.g. the legs of a mirror
+	Devices []string `json:"devices"`
+}
+
+func (v *ZFSVdev) String() string {
+	return fmt.Sprintf(
+		"%s guid=%s devices=[%s]",
+		v.Type,
+		v.GUID,
+		strings.Join(v.Devices, " "),
+	)
+}
@@ -0,0 +1,209 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/block/fsprobe"
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// btrfsFilesystems returns a slice of pointers to BtrfsFilesystem structs,
+// one for each mounted btrfs filesystem found in /sys/fs/btrfs. The supplied
+// Disks and Partitions the filesystems span are linked to them.
+func btrfsFilesystems(paths *linuxpath.Paths, disks []*Disk) []*BtrfsFilesystem {
+	out := make([]*BtrfsFilesystem, 0)
+	entries, err := ioutil.ReadDir(paths.SysFsBtrfs)
+	if err != nil {
+		return out
+	}
+	for _, entry := range entries {
+		uuid := entry.Name()
+		fspath := filepath.Join(paths.SysFsBtrfs, uuid)
+		// /sys/fs/btrfs also holds the features supported by the kernel
+		if _, err := os.Stat(filepath.Join(fspath, "devices")); err != nil {
+			continue
+		}
+		fs := &BtrfsFilesystem{
+			UUID:     uuid,
+			Label:    sysfsAttr(fspath, "label"),
+			Devices:  blockDeviceLinks(filepath.Join(fspath, "devices")),
+			Data:     btrfsAllocation(fspath, "data"),
+			Metadata: btrfsAllocation(fspath, "metadata"),
+			System:   btrfsAllocation(fspath, "system"),
+		}
+		forEachBlockDevice(disks, fs.Devices, func(d *Disk, p *Partition) {
+			if p != nil {
+				p.BtrfsFilesystem = fs
+			} else {
+				d.BtrfsFilesystem = fs
+			}
+		})
+		out = append(out, fs)
+	}
+	return out
+}
+
+// btrfsAllocation returns the allocation of the supplied type of block group
+// found in /sys/fs/btrfs/$UUID/allocation/$TYPE, or nil. The profile of the
+// block groups is the name of the only subdirectory there, e.g. "raid1".
+func btrfsAllocation(fspath string, groupType string) *BtrfsAllocation {
+	apath := filepath.Join(fspath, "allocation", groupType)
+	entries, err := ioutil.ReadDir(apath)
+	if err != nil {
+		return nil
+	}
+	a := &BtrfsAllocation{
+		TotalBytes:     sysfsUintAttr(apath, "total_bytes"),
+		UsedBytes:      sysfsUintAttr(apath, "bytes_used"),
+		DiskTotalBytes: sysfsUintAttr(apath, "disk_total"),
+		DiskUsedBytes:  sysfsUintAttr(apath, "disk_used"),
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			a.Profile = entry.Name()
+			break
+		}
+	}
+	return a
+}
+
+// zfsPools returns a slice of pointers to ZFSPool structs, one for each pool
+// found in /proc/spl/kstat/zfs when the zfs module is loaded. The member
+// devices of each pool are the supplied Disks and Partitions holding a ZFS
+// label of that pool, and are linked to it and grouped by top-level vdev.
+// Labels naming the pool are grouped by pool GUID: the labels of an exported
+// or destroyed pool of the same name are left out when the module reports
+// the GUID of the imported pool, and none are used when it does not and they
+// name several GUIDs.
+func zfsPools(ctx *context.Context, paths *linuxpath.Paths, disks []*Disk) []*ZFSPool {
+	out := make([]*ZFSPool, 0)
+	if _, err := os.Stat(paths.SysModuleZFS); err != nil {
+		return out
+	}
+	entries, err := ioutil.ReadDir(paths.ProcSplKstatZFS)
+	if err != nil {
+		return out
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		kstatPath := filepath.Join(paths.ProcSplKstatZFS, name)
+		state := sysfsAttr(kstatPath, "state")
+		if !entry.IsDir() || state == "" {
+			continue
+		}
+		pool := &ZFSPool{
+			Name:    name,
+			State:   state,
+			Devices: make([]string, 0),
+			Vdevs:   make([]*ZFSVdev, 0),
+		}
+		members := zfsPoolMembers(disks, name)
+		// Recent zfs modules report the GUID of the pool, in decimal like the
+		// labels do
+		guid := sysfsAttr(kstatPath, "guid")
+		if guid == "" {
+			if len(members) > 1 {
+				guids := make([]string, 0, len(members))
+				for g := range members {
+					guids = append(guids, g)
+				}
+				sort.Strings(guids)
+				ctx.Warn(
+					"found ZFS labels of several pools named %s (GUIDs %s), not linking any device to it\n",
+					name, strings.Join(guids, ", "),
+				)
+				out = append(out, pool)
+				continue
+			}
+			for g := range members {
+				guid = g
+			}
+		}
+		pool.GUID = guid
+		vdevs := make(map[string]*ZFSVdev)
+		forEachBlockDevice(disks, members[guid], func(d *Disk, p *Partition) {
+			name, fs := d.Name, d.Filesystem
+			if p != nil {
+				p.ZFSPool = pool
+				name, fs = p.Name, p.Filesystem
+			} else {
+				d.ZFSPool = pool
+			}
+			if fs.VdevGUID == "" {
+				return
+			}
+			vdev, ok := vdevs[fs.VdevGUID]
+			if !ok {
+				vdev = &ZFSVdev{
+					GUID:    fs.VdevGUID,
+					Type:    fs.VdevType,
+					Devices: make([]string, 0),
+				}
+				vdevs[fs.VdevGUID] = vdev
+				pool.Vdevs = append(pool.Vdevs, vdev)
+			}
+			vdev.Devices = append(vdev.Devices, name)
+		})
+		pool.Devices = append(pool.Devices, members[guid]...)
+		out = append(out, pool)
+	}
+	return out
+}
+
+// zfsPoolMembers returns the names of the supplied Disks and Partitions
+// holding a ZFS label of a pool of the supplied name, keyed by the GUID of
+// the pool recorded in the label
+func zfsPoolMembers(disks []*Disk, pool string) map[string][]string {
+	members := make(map[string][]string)
+	for _, d := range disks {
+		if zfsPoolMember(d.Filesystem, pool) {
+			members[d.Filesystem.UUID] = append(members[d.Filesystem.UUID], d.Name)
+		}
+		for _, p := range d.Partitions {
+			if zfsPoolMember(p.Filesystem, pool) {
+				members[p.Filesystem.UUID] = append(members[p.Filesystem.UUID], p.Name)
+			}
+		}
+	}
+	return members
+}
+
+// zfsPoolMember returns whether the supplied filesystem is the label of a
+// member device of the supplied ZFS pool
+func zfsPoolMember(fs *fsprobe.Filesystem, pool string) bool {
+	return fs != nil && fs.Type == "zfs_member" && fs.Label == pool
+}
+
+// zfsVersion returns the version of the loaded zfs module, or an empty string
+func zfsVersion(paths *linuxpath.Paths) string {
+	return sysfsAttr(paths.SysModuleZFS, "version")
+}
+
+// forEachBlockDevice calls fn with each of the supplied Disks, or Partitions
+// along with their Disk, whose name is among the supplied names
+func forEachBlockDevice(disks []*Disk, names []string, fn func(*Disk, *Partition)) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	for _, d := range disks {
+		if wanted[d.Name] {
+			fn(d, nil)
+		}
+		for _, p := range d.Partitions {
+			if wanted[p.Name] {
+				fn(d, p)
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestPools(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-pool-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// A btrfs raid1 filesystem spans sda2 and sdb, and the tank ZFS pool
+	// spans sdc1 and sdd
+	btrfs := "sys/fs/btrfs/5d2a3c1e-8f4b-4e6a-9c7d-1b2e3f4a5b6c/"
+	writeFiles(t, root, map[string]string{
+		"sys/block/sda/size":                         "2048\n",
+		"sys/block/sda/sda1/partition":               "1\n",
+		"sys/block/sda/sda2/partition":               "2\n",
+		"sys/block/sdb/size":                         "2048\n",
+		"sys/block/sdc/size":                         "2048\n",
+		"sys/block/sdc/sdc1/partition":               "1\n",
+		"sys/block/sdc/sdc1/dev":                     "8:33\n",
+		"sys/block/sdd/size":                         "2048\n",
+		"sys/block/sdd/dev":                          "8:48\n",
+		"sys/fs/btrfs/features/raid1c34":             "0\n",
+		btrfs + "label":                              "pool\n",
+		btrfs + "devices/sda2":                       "",
+		btrfs + "devices/sdb":                        "",
+		btrfs + "allocation/data/total_bytes":        "10737418240\n",
+		btrfs + "allocation/data/bytes_used":         "5368709120\n",
+		btrfs + "allocation/data/disk_total":         "21474836480\n",
+		btrfs + "allocation/data/disk_used":          "10737418240\n",
+		btrfs + "allocation/data/raid1/used_bytes":   "5368709120\n",
+		btrfs + "allocation/metadata/dup/used_bytes": "0\n",
+		"proc/spl/kstat/zfs/tank/state":              "DEGRADED\n",
+		"sys/module/zfs/version":                     "2.1.5-1ubuntu6\n",
+		"run/udev/data/b8:33":                        "E:ID_FS_TYPE=zfs_member\nE:ID_FS_LABEL=tank\nE:ID_FS_UUID=9507823112334467211\n",
+		"run/udev/data/b8:48":                        "E:ID_FS_TYPE=zfs_member\nE:ID_FS_LABEL=tank\nE:ID_FS_UUID=9507823112334467211\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+
+	if len(info.BtrfsFilesystems) != 1 {
+		t.Fatalf("Expected 1 btrfs filesystem, but got %d", len(info.BtrfsFilesystems))
+	}
+	fs := info.BtrfsFilesystems[0]
+	if fs.Label != "pool" || !reflect.DeepEqual(fs.Devices, []string{"sda2", "sdb"}) {
+		t.Fatalf("Expected the pool filesystem to span sda2 and sdb, but got %s", fs)
+	}
+	expected := &BtrfsAllocation{
+		Profile:        "raid1",
+		TotalBytes:     10737418240,
+		UsedBytes:      5368709120,
+		DiskTotalBytes: 21474836480,
+		DiskUsedBytes:  10737418240,
+	}
+	if !reflect.DeepEqual(fs.Data, expected) {
+		t.Fatalf("Expected data allocation %+v, but got %+v", expected, fs.Data)
+	}
+	if fs.Metadata == nil || fs.Metadata.Profile != "dup" || fs.System != nil {
+		t.Fatalf("Expected dup metadata and no system allocation, but got %+v and %+v", fs.Metadata, fs.System)
+	}
+	sda, sdb := info.Disks[0], info.Disks[1]
+	if sda.BtrfsFilesystem != nil || sda.Partitions[0].BtrfsFilesystem != nil || sda.Partitions[1].BtrfsFilesystem != fs {
+		t.Fatalf("Expected only sda2 of sda to belong to the btrfs filesystem")
+	}
+	if sdb.BtrfsFilesystem != fs {
+		t.Fatalf("Expected sdb to belong to the btrfs filesystem")
+	}
+
+	if info.ZFSVersion != "2.1.5-1ubuntu6" {
+		t.Fatalf("Expected zfs module version 2.1.5-1ubuntu6, but got %q", info.ZFSVersion)
+	}
+	if len(info.ZFSPools) != 1 {
+		t.Fatalf("Expected 1 ZFS pool, but got %d", len(info.ZFSPools))
+	}
+	pool := info.ZFSPools[0]
+	if pool.Name != "tank" || pool.State != "DEGRADED" || pool.GUID != "9507823112334467211" {
+		t.Fatalf("Unexpected ZFS pool attributes: %s", pool)
+	}
+	if !reflect.DeepEqual(pool.Devices, []string{"sdc1", "sdd"}) {
+		t.Fatalf("Expected the tank pool to span sdc1 and sdd, but got %v", pool.Devices)
+	}
+	if info.Disks[2].Partitions[0].ZFSPool != pool || info.Disks[3].ZFSPool != pool {
+		t.Fatalf("Expected sdc1 and sdd to belong to the tank pool")
+	}
+}
+
+func TestZFSPoolsStaleLabels(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-pool-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// sdc holds a label of the imported tank pool, and sdd a stale label of
+	// a destroyed pool of the same name
+	writeFiles(t, root, map[string]string{
+		"sys/block/sdc/size":            "2048\n",
+		"sys/block/sdc/dev":             "8:32\n",
+		"sys/block/sdd/size":            "2048\n",
+		"sys/block/sdd/dev":             "8:48\n",
+		"proc/spl/kstat/zfs/tank/state": "ONLINE\n",
+		"sys/module/zfs/version":        "2.1.5-1ubuntu6\n",
+		"run/udev/data/b8:32":           "E:ID_FS_TYPE=zfs_member\nE:ID_FS_LABEL=tank\nE:ID_FS_UUID=9507823112334467211\n",
+		"run/udev/data/b8:48":           "E:ID_FS_TYPE=zfs_member\nE:ID_FS_LABEL=tank\nE:ID_FS_UUID=1133829604357223044\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	pool := info.ZFSPools[0]
+	if pool.GUID != "" || len(pool.Devices) != 0 || info.Disks[0].ZFSPool != nil || info.Disks[1].ZFSPool != nil {
+		t.Fatalf("Expected no device linked to the tank pool, but got %s with GUID %q", pool, pool.GUID)
+	}
+
+	// The zfs module reports the GUID of the imported pool
+	writeFiles(t, root, map[string]string{
+		"proc/spl/kstat/zfs/tank/guid": "9507823112334467211\n",
+	})
+	info, err = New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	pool = info.ZFSPools[0]
+	if pool.GUID != "9507823112334467211" || !reflect.DeepEqual(pool.Devices, []string{"sdc"}) {
+		t.Fatalf("Expected the tank pool to span sdc only, but got %s with GUID %q", pool, pool.GUID)
+	}
+	if info.Disks[0].ZFSPool != pool || info.Disks[1].ZFSPool != nil {
+		t.Fatalf("Expected only sdc to belong to the tank pool")
+	}
+}
+
+func TestZFSPoolVdevs(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-pool-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// sdb and sdc are the legs of a mirror and sdd is striped with it, while
+	// the device node of sde is missing, so its label has to be taken from
+	// udev, which does not record its vdev
+	writeFiles(t, root, map[string]string{
+		"sys/block/sdb/size":            "2048\n",
+		"sys/block/sdc/size":            "2048\n",
+		"sys/block/sdd/size":            "2048\n",
+		"sys/block/sde/size":            "2048\n",
+		"sys/block/sde/dev":             "8:64\n",
+		"proc/spl/kstat/zfs/tank/state": "ONLINE\n",
+		"sys/module/zfs/version":        "2.1.5-1ubuntu6\n",
+		"run/udev/data/b8:64":           "E:ID_FS_TYPE=zfs_member\nE:ID_FS_LABEL=tank\nE:ID_FS_UUID=9507823112334467211\n",
+	})
+	writeFiles(t, filepath.Join(root, "dev"), map[string]string{
+		"sdb": zfsLabel("mirror", 2214916324137531262, 4920357314812617421),
+		"sdc": zfsLabel("mirror", 2214916324137531262, 11835087256712960532),
+		"sdd": zfsLabel("disk", 15425631925519313409, 15425631925519313409),
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	pool := info.ZFSPools[0]
+	if !reflect.DeepEqual(pool.Devices, []string{"sdb", "sdc", "sdd", "sde"}) {
+		t.Fatalf("Expected the tank pool to span sdb, sdc, sdd and sde, but got %s", pool)
+	}
+	expected := []*ZFSVdev{
+		{GUID: "2214916324137531262", Type: "mirror", Devices: []string{"sdb", "sdc"}},
+		{GUID: "15425631925519313409", Type: "disk", Devices: []string{"sdd"}},
+	}
+	if !reflect.DeepEqual(pool.Vdevs, expected) {
+		t.Fatalf("Expected vdevs %v, but got %v", expected, pool.Vdevs)
+	}
+}
+
+// zfsLabel returns the contents of a device holding the first label of a
+// member of the tank ZFS pool, of the supplied GUID, belonging to a top-level
+// vdev of the supplied type and GUID
+func zfsLabel(vdevType string, vdevGUID uint64, guid uint64) string {
+	str := func(s string) []byte {
+		b := make([]byte, 4+(len(s)+3)&^3)
+		binary.BigEndian.PutUint32(b, uint32(len(s)))
+		copy(b[4:], s)
+		return b
+	}
+	u64 := func(v uint64) []byte {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, v)
+		return b
+	}
+	pair := func(name string, dataType uint32, value []byte) []byte {
+		body := append(str(name), make([]byte, 8)...)
+		binary.BigEndian.PutUint32(body[len(body)-8:], dataType)
+		binary.BigEndian.PutUint32(body[len(body)-4:], 1)
+		body = append(body, value...)
+		b := make([]byte, 8, 8+len(body))
+		binary.BigEndian.PutUint32(b[0:], uint32(8+len(body)))
+		binary.BigEndian.PutUint32(b[4:], uint32(8+len(body)))
+		return append(b, body...)
+	}
+	nvlist := func(pairs ...[]byte) []byte {
+		b := make([]byte, 8)
+		for _, p := range pairs {
+			b = append(b, p...)
+		}
+		return append(b, make([]byte, 8)...)
+	}
+
+	img := make([]byte, 128<<10)
+	img[16<<10] = 1 // XDR encoding
+	copy(img[16<<10+4:], nvlist(
+		pair("version", 8, u64(5000)),
+		pair("name", 9, str("tank")),
+		pair("pool_guid", 8, u64(9507823112334467211)),
+		pair("vdev_tree", 19, nvlist(
+			pair("type", 9, str(vdevType)),
+			pair("guid", 8, u64(vdevGUID)),
+		)),
+		pair("guid", 8, u64(guid)),
+	))
+	return string(img)
+}
@@ -0,0 +1,104 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ZonedModel describes how a block device exposes its zones, if any
+type ZonedModel int
+
+const (
+	// ZONED_MODEL_UNKNOWN means the kernel did not report a zoned model
+	ZONED_MODEL_UNKNOWN ZonedModel = iota
+	// ZONED_MODEL_NONE is a regular, non-zoned device. Drive-managed SMR hard
+	// disks hide their zones and are reported as such.
+	ZONED_MODEL_NONE
+	// ZONED_MODEL_HOST_AWARE is a zoned device still accepting random writes,
+	// like host-aware SMR hard disks
+	ZONED_MODEL_HOST_AWARE
+	// ZONED_MODEL_HOST_MANAGED is a zoned device that must be written
+	// sequentially within each zone, like host-managed SMR hard disks and
+	// ZNS SSDs
+	ZONED_MODEL_HOST_MANAGED
+)
+
+var (
+	zonedModelString = map[ZonedModel]string{
+		ZONED_MODEL_UNKNOWN:      "Unknown",
+		ZONED_MODEL_NONE:         "none",
+		ZONED_MODEL_HOST_AWARE:   "host-aware",
+		ZONED_MODEL_HOST_MANAGED: "host-managed",
+	}
+
+	// the strings used by the kernel in /sys/block/$DEVICE/queue/zoned
+	zonedModelByName = map[string]ZonedModel{
+		"none":         ZONED_MODEL_NONE,
+		"host-aware":   ZONED_MODEL_HOST_AWARE,
+		"host-managed": ZONED_MODEL_HOST_MANAGED,
+	}
+)
+
+func (zm ZonedModel) String() string {
+	return zonedModelString[zm]
+}
+
+// MarshalJSON encodes the zoned model as its lowercased name
+func (zm ZonedModel) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + strings.ToLower(zm.String()) + "\""), nil
+}
+
+// Queue describes the request queue of a disk: its I/O limits, alignment
+// and scheduling settings
+type Queue struct {
+	LogicalBlockSizeBytes  uint64 `json:"logical_block_size_bytes"`
+	PhysicalBlockSizeBytes uint64 `json:"physical_block_size_bytes"`
+	// MinimumIOSizeBytes and OptimalIOSizeBytes are the preferred minimum
+	// and optimal request sizes, e.g. the chunk size and stripe width of a
+	// RAID array. OptimalIOSizeBytes is 0 when the device does not report
+	// one.
+	MinimumIOSizeBytes uint64 `json:"minimum_io_size_bytes"`
+	OptimalIOSizeBytes uint64 `json:"optimal_io_size_bytes"`
+	// DiscardGranularityBytes and DiscardMaxBytes are both 0 for devices
+	// not supporting discard (TRIM/UNMAP)
+	DiscardGranularityBytes uint64 `json:"discard_granularity_bytes"`
+	DiscardMaxBytes         uint64 `json:"discard_max_bytes"`
+	// WriteCache is the write cache mode of the device, "write back" or
+	// "write through"
+	WriteCache string `json:"write_cache"`
+	// Scheduler is the name of the active I/O scheduler, e.g. "mq-deadline"
+	// or "none", and AvailableSchedulers lists the schedulers it can be
+	// switched to
+	Scheduler           string   `json:"scheduler"`
+	AvailableSchedulers []string `json:"available_schedulers"`
+	NumRequests         uint64   `json:"nr_requests"`
+	ReadAheadKB         uint64   `json:"read_ahead_kb"`
+	// DAX is true for devices supporting direct access, bypassing the page
+	// cache, e.g. persistent memory
+	DAX        bool       `json:"dax"`
+	ZonedModel ZonedModel `json:"zoned_model"`
+	// NumZones and ZoneSizeBytes are only set for zoned devices
+	NumZones      uint64 `json:"nr_zones,omitempty"`
+	ZoneSizeBytes uint64 `json:"zone_size_bytes,omitempty"`
+}
+
+func (q *Queue) String() string {
+	zoned := ""
+	if q.ZonedModel == ZONED_MODEL_HOST_AWARE || q.ZonedModel == ZONED_MODEL_HOST_MANAGED {
+		zoned = fmt.Sprintf(" zoned=%s (%d zones)", q.ZonedModel, q.NumZones)
+	}
+	return fmt.Sprintf(
+		"scheduler=%s logical_block_size=%d minimum_io_size=%d optimal_io_size=%d%s",
+		q.Scheduler,
+		q.LogicalBlockSizeBytes,
+		q.MinimumIOSizeBytes,
+		q.OptimalIOSizeBytes,
+		zoned,
+	)
+}
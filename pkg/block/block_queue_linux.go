@@ -0,0 +1,63 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// diskQueue returns a pointer to a Queue struct describing the request queue
+// found in the /sys/block/$DEVICE/queue directory, or nil if there is none
+func diskQueue(paths *linuxpath.Paths, disk string) *Queue {
+	qpath := filepath.Join(paths.SysBlock, disk, "queue")
+	if _, err := ioutil.ReadDir(qpath); err != nil {
+		return nil
+	}
+	q := &Queue{
+		LogicalBlockSizeBytes:   sysfsUintAttr(qpath, "logical_block_size"),
+		PhysicalBlockSizeBytes:  sysfsUintAttr(qpath, "physical_block_size"),
+		MinimumIOSizeBytes:      sysfsUintAttr(qpath, "minimum_io_size"),
+		OptimalIOSizeBytes:      sysfsUintAttr(qpath, "optimal_io_size"),
+		DiscardGranularityBytes: sysfsUintAttr(qpath, "discard_granularity"),
+		DiscardMaxBytes:         sysfsUintAttr(qpath, "discard_max_bytes"),
+		WriteCache:              sysfsAttr(qpath, "write_cache"),
+		NumRequests:             sysfsUintAttr(qpath, "nr_requests"),
+		ReadAheadKB:             sysfsUintAttr(qpath, "read_ahead_kb"),
+		DAX:                     sysfsAttr(qpath, "dax") == "1",
+		ZonedModel:              zonedModelByName[sysfsAttr(qpath, "zoned")],
+	}
+	q.Scheduler, q.AvailableSchedulers = parseChoices(sysfsAttr(qpath, "scheduler"))
+	if q.ZonedModel == ZONED_MODEL_HOST_AWARE || q.ZonedModel == ZONED_MODEL_HOST_MANAGED {
+		q.NumZones = sysfsUintAttr(qpath, "nr_zones")
+		// The zone size is reported in 512-byte sectors
+		q.ZoneSizeBytes = sysfsUintAttr(qpath, "chunk_sectors") * sectorSize
+	}
+	return q
+}
+
+// parseChoices parses the contents of a sysfs attribute listing the available
+// choices with the active one in brackets, like queue/scheduler, e.g.
+// "mq-deadline kyber [bfq] none". Devices without a scheduler (e.g. some
+// device-mapper devices) report "none".
+func parseChoices(contents string) (string, []string) {
+	current := ""
+	available := make([]string, 0)
+	for _, field := range strings.Fields(contents) {
+		if strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]") {
+			field = strings.Trim(field, "[]")
+			current = field
+		}
+		available = append(available, field)
+	}
+	if current == "" && len(available) == 1 {
+		current = available[0]
+	}
+	return current, available
+}
@@ -0,0 +1,114 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestDiskQueue(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-queue-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// nvme0n2 is a ZNS namespace, sda is a regular SATA SSD
+	writeFiles(t, filepath.Join(root, "sys", "block"), map[string]string{
+		"nvme0n2/size":                      "2048\n",
+		"nvme0n2/queue/logical_block_size":  "4096\n",
+		"nvme0n2/queue/physical_block_size": "4096\n",
+		"nvme0n2/queue/minimum_io_size":     "4096\n",
+		"nvme0n2/queue/optimal_io_size":     "0\n",
+		"nvme0n2/queue/scheduler":           "[mq-deadline] none\n",
+		"nvme0n2/queue/zoned":               "host-managed\n",
+		"nvme0n2/queue/nr_zones":            "3688\n",
+		"nvme0n2/queue/chunk_sectors":       "2097152\n",
+		"sda/size":                          "2048\n",
+		"sda/queue/logical_block_size":      "512\n",
+		"sda/queue/physical_block_size":     "4096\n",
+		"sda/queue/minimum_io_size":         "4096\n",
+		"sda/queue/optimal_io_size":         "65536\n",
+		"sda/queue/discard_granularity":     "512\n",
+		"sda/queue/discard_max_bytes":       "2147450880\n",
+		"sda/queue/write_cache":             "write back\n",
+		"sda/queue/scheduler":               "mq-deadline kyber [bfq] none\n",
+		"sda/queue/nr_requests":             "64\n",
+		"sda/queue/read_ahead_kb":           "128\n",
+		"sda/queue/dax":                     "0\n",
+		"sda/queue/zoned":                   "none\n",
+		"sda/queue/chunk_sectors":           "0\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 2 {
+		t.Fatalf("Expected 2 disks, but got %d", len(info.Disks))
+	}
+
+	zns := info.Disks[0].Queue
+	if zns == nil || zns.ZonedModel != ZONED_MODEL_HOST_MANAGED {
+		t.Fatalf("Expected nvme0n2 to be host-managed, but got %v", zns)
+	}
+	if zns.NumZones != 3688 || zns.ZoneSizeBytes != 1<<30 {
+		t.Fatalf("Expected 3688 zones of 1GiB, but got %d zones of %d bytes", zns.NumZones, zns.ZoneSizeBytes)
+	}
+	if zns.Scheduler != "mq-deadline" {
+		t.Fatalf("Expected nvme0n2 to use mq-deadline, but got %s", zns.Scheduler)
+	}
+
+	expected := &Queue{
+		LogicalBlockSizeBytes:   512,
+		PhysicalBlockSizeBytes:  4096,
+		MinimumIOSizeBytes:      4096,
+		OptimalIOSizeBytes:      65536,
+		DiscardGranularityBytes: 512,
+		DiscardMaxBytes:         2147450880,
+		WriteCache:              "write back",
+		Scheduler:               "bfq",
+		AvailableSchedulers:     []string{"mq-deadline", "kyber", "bfq", "none"},
+		NumRequests:             64,
+		ReadAheadKB:             128,
+		ZonedModel:              ZONED_MODEL_NONE,
+	}
+	if q := info.Disks[1].Queue; !reflect.DeepEqual(q, expected) {
+		t.Fatalf("Expected sda queue %+v, but got %+v", expected, q)
+	}
+}
+
+func TestParseChoices(t *testing.T) {
+	tests := []struct {
+		contents  string
+		current   string
+		available []string
+	}{
+		{"mq-deadline kyber [bfq] none", "bfq", []string{"mq-deadline", "kyber", "bfq", "none"}},
+		{"[none] mq-deadline", "none", []string{"none", "mq-deadline"}},
+		{"none", "none", []string{"none"}},
+		{"", "", []string{}},
+	}
+	for _, test := range tests {
+		current, available := parseChoices(test.contents)
+		if current != test.current || !reflect.DeepEqual(available, test.available) {
+			t.Fatalf("For %q, expected %q and %v, but got %q and %v", test.contents, test.current, test.available, current, available)
+		}
+	}
+}
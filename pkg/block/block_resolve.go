@@ -0,0 +1,147 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/option"
+	"github.com/jaypipes/ghw/pkg/pci"
+	"github.com/jaypipes/ghw/pkg/topology"
+)
+
+// PathChain describes the storage stack serving a filesystem path, from the
+// mount the path lives on down to the controllers of the disks holding it.
+// Stacked devices are listed from the top of the stack downwards.
+type PathChain struct {
+	// Path is the path that was resolved, with its symlinks evaluated
+	Path string `json:"path"`
+	// Mount is the mount the path lives on
+	Mount *MountInfo `json:"mount"`
+	// MappedDevices and MDArrays contain the device-mapper devices and
+	// software RAID arrays found between the mount and the disks
+	MappedDevices []*MappedDevice `json:"mapped_devices,omitempty"`
+	MDArrays      []*MDArray      `json:"md_arrays,omitempty"`
+	// Partitions contains the partitions holding the filesystem or the
+	// devices stacked upon them
+	Partitions []*Partition `json:"-"`
+	// Disks contains the disks at the bottom of the stack
+	Disks []*Disk `json:"-"`
+	// Controllers contains the PCI devices the disks hang off, e.g. the NVMe
+	// controllers or the HBAs
+	Controllers []*pci.Device `json:"controllers,omitempty"`
+	// NUMANodes contains the NUMA nodes the controllers are attached to,
+	// with their local processor cores. It is empty on non-NUMA systems.
+	NUMANodes []*topology.Node `json:"numa_nodes,omitempty"`
+}
+
+func (c *PathChain) String() string {
+	names := make([]string, 0, len(c.Disks))
+	for _, d := range c.Disks {
+		names = append(names, d.Name)
+	}
+	mountPoint := ""
+	if c.Mount != nil {
+		mountPoint = c.Mount.MountPoint
+	}
+	return fmt.Sprintf(
+		"%s on %s (disks %s)",
+		c.Path,
+		mountPoint,
+		strings.Join(names, " "),
+	)
+}
+
+// ResolvePath returns a pointer to a PathChain struct describing the mount,
+// the block devices, the storage controllers and the NUMA nodes serving the
+// supplied path. Only the block devices and the way they are stacked are
+// read, so the Disks of the PathChain lack the details New gathers from
+// their device nodes and from smartctl. Use the ResolvePath method of an
+// existing Info to get those.
+func ResolvePath(path string, opts ...*option.Option) (*PathChain, error) {
+	ctx := context.New(opts...)
+	info := &Info{ctx: ctx}
+	if err := ctx.Do(info.loadStack); err != nil {
+		return nil, err
+	}
+	return info.ResolvePath(path)
+}
+
+// ResolvePath returns a pointer to a PathChain struct describing the storage
+// stack serving the supplied path, built from the disks of the Info.
+func (i *Info) ResolvePath(path string) (*PathChain, error) {
+	chain := &PathChain{
+		MappedDevices: make([]*MappedDevice, 0),
+		MDArrays:      make([]*MDArray, 0),
+		Partitions:    make([]*Partition, 0),
+		Disks:         make([]*Disk, 0),
+		Controllers:   make([]*pci.Device, 0),
+		NUMANodes:     make([]*topology.Node, 0),
+	}
+	if err := i.ctx.Do(func() error {
+		return i.resolvePath(chain, path)
+	}); err != nil {
+		return nil, err
+	}
+	i.resolveControllers(chain)
+	return chain, nil
+}
+
+// resolveControllers looks up the PCI devices and the NUMA nodes the disks
+// of the supplied PathChain are attached to.
+func (i *Info) resolveControllers(chain *PathChain) {
+	var pciInfo *pci.Info
+	var topo *topology.Info
+	for _, d := range chain.Disks {
+		if d.PCIAddress != nil {
+			if pciInfo == nil {
+				var err error
+				if pciInfo, err = pci.NewWithContext(i.ctx); err != nil {
+					i.ctx.Warn("error getting PCI device info: %v", err)
+					return
+				}
+			}
+			if dev := pciInfo.GetDevice(*d.PCIAddress); dev != nil && !hasPCIDevice(chain.Controllers, dev) {
+				chain.Controllers = append(chain.Controllers, dev)
+			}
+		}
+		if d.NUMANodeID >= 0 {
+			if topo == nil {
+				var err error
+				if topo, err = topology.NewWithContext(i.ctx); err != nil {
+					i.ctx.Warn("error getting topology info: %v", err)
+					return
+				}
+			}
+			for _, node := range topo.Nodes {
+				if node.ID == d.NUMANodeID && !hasNode(chain.NUMANodes, node) {
+					chain.NUMANodes = append(chain.NUMANodes, node)
+				}
+			}
+		}
+	}
+}
+
+func hasPCIDevice(devs []*pci.Device, dev *pci.Device) bool {
+	for _, d := range devs {
+		if d.Address == dev.Address {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNode(nodes []*topology.Node, node *topology.Node) bool {
+	for _, n := range nodes {
+		if n.ID == node.ID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// maxSymlinks is the number of symlinks followed when resolving a path
+// before giving up, as the kernel does with ELOOP
+const maxSymlinks = 40
+
+func (i *Info) resolvePath(chain *PathChain, path string) error {
+	paths := linuxpath.New(i.ctx)
+	if !filepath.IsAbs(path) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		path = abs
+	}
+	resolved, err := evalSymlinks(i.ctx.Chroot, path)
+	if err != nil {
+		return err
+	}
+	chain.Path = resolved
+
+	entries, err := mountInfoEntries(paths)
+	if err != nil {
+		entries = legacyMountTable(i.ctx, paths)
+	}
+	chain.Mount = pathMount(entries, resolved)
+	if chain.Mount == nil {
+		return fmt.Errorf("%s: no mount found", resolved)
+	}
+	if chain.Mount.Usage == nil {
+		chain.Mount.Usage = mountUsage(i.ctx, paths, chain.Mount.MountPoint)
+	}
+
+	names := i.mountDevices(chain.Mount)
+	if len(names) == 0 {
+		return fmt.Errorf(
+			"%s: %s filesystem mounted on %s is not backed by a block device",
+			resolved,
+			chain.Mount.Type,
+			chain.Mount.MountPoint,
+		)
+	}
+	i.resolveDevices(chain, names, make(map[string]bool))
+	return nil
+}
+
+// evalSymlinks returns the supplied absolute path with its symlinks
+// evaluated, as if the supplied root directory was the root of the
+// filesystem. Unlike filepath.EvalSymlinks, absolute symlinks found under a
+// chroot or in a snapshot are resolved within it.
+func evalSymlinks(root string, path string) (string, error) {
+	resolved := "/"
+	rest := strings.Split(path, "/")
+	links := 0
+	for len(rest) > 0 {
+		name := rest[0]
+		rest = rest[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, name)
+		fi, err := os.Lstat(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		links++
+		if links > maxSymlinks {
+			return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+		}
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return resolved, nil
+}
+
+// pathMount returns the entry of the supplied mount table the supplied path
+// lives on: the one with the longest mount point containing the path. When
+// several filesystems are mounted on that mount point, the last one mounted
+// hides the others.
+func pathMount(mounts []*MountInfo, path string) *MountInfo {
+	var found *MountInfo
+	for _, mi := range mounts {
+		mp := mi.MountPoint
+		if mp != "/" && path != mp && !strings.HasPrefix(path, mp+"/") {
+			continue
+		}
+		if found == nil || len(mp) >= len(found.MountPoint) {
+			found = mi
+		}
+	}
+	return found
+}
+
+// mountDevices returns the names of the disks and partitions holding the
+// filesystem of the supplied mount
+func (i *Info) mountDevices(mount *MountInfo) []string {
+	names := make([]string, 0)
+	// A btrfs filesystem spanning several devices is mounted from any of
+	// them, so all of its devices are returned
+	addMounted := func(name string, mounts []*MountInfo, fs *BtrfsFilesystem) {
+		if !hasMount(mounts, mount) {
+			return
+		}
+		if fs != nil {
+			names = append(names, fs.Devices...)
+		} else {
+			names = append(names, name)
+		}
+	}
+	for _, d := range i.Disks {
+		addMounted(d.Name, d.Mounts, d.BtrfsFilesystem)
+		for _, p := range d.Partitions {
+			addMounted(p.Name, p.Mounts, p.BtrfsFilesystem)
+		}
+	}
+	if len(names) > 0 || mount.Type != "zfs" {
+		return names
+	}
+
+	// A ZFS dataset is named after its pool, and is given an anonymous
+	// device number matching none of the block devices
+	poolName := strings.SplitN(mount.Source, "/", 2)[0]
+	for _, pool := range i.ZFSPools {
+		if pool.Name == poolName {
+			return pool.Devices
+		}
+	}
+	return names
+}
+
+// hasMount returns whether the supplied mount is among the supplied mounts
+// of a block device
+func hasMount(mounts []*MountInfo, mount *MountInfo) bool {
+	for _, mi := range mounts {
+		if mi.MountID != 0 && mi.MountID == mount.MountID {
+			return true
+		}
+		if mi.MountID == 0 && mi.MountPoint == mount.MountPoint && mi.Source == mount.Source {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDevices adds the supplied disks and partitions to the PathChain,
+// along with the devices they are built upon. Device-mapper devices and
+// software RAID arrays are followed down to their members, and partitions
+// down to their disks.
+func (i *Info) resolveDevices(chain *PathChain, names []string, seen map[string]bool) {
+	forEachBlockDevice(i.Disks, names, func(d *Disk, p *Partition) {
+		if p != nil {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				chain.Partitions = append(chain.Partitions, p)
+				i.resolveDevices(chain, []string{d.Name}, seen)
+			}
+			return
+		}
+		if seen[d.Name] {
+			return
+		}
+		seen[d.Name] = true
+		for _, m := range i.MappedDevices {
+			if m.Disk == d {
+				chain.MappedDevices = append(chain.MappedDevices, m)
+				i.resolveDevices(chain, m.Slaves, seen)
+				return
+			}
+		}
+		for _, a := range i.MDArrays {
+			if a.Disk == d {
+				chain.MDArrays = append(chain.MDArrays, a)
+				members := make([]string, 0, len(a.Members))
+				for _, member := range a.Members {
+					members = append(members, member.Name)
+				}
+				i.resolveDevices(chain, members, seen)
+				return
+			}
+		}
+		chain.Disks = append(chain.Disks, d)
+	})
+}
@@ -0,0 +1,125 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestResolvePath(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-resolve-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// /var/lib is an LVM logical volume on the first partition of a NVMe
+	// namespace, whose controller is attached to NUMA node 1. /data is a
+	// symlink to /var/lib/db, and /var/lib/db/tmp a tmpfs.
+	ctrl := "pci0000:00/0000:00:1d.0/0000:03:00.0"
+	disks := map[string]string{
+		"nvme0n1": ctrl + "/nvme/nvme0/nvme0n1",
+		"dm-0":    "virtual/block/dm-0",
+	}
+	writeFiles(t, filepath.Join(root, "sys", "devices"), map[string]string{
+		ctrl + "/numa_node":                                 "1\n",
+		ctrl + "/local_cpulist":                             "2-3\n",
+		ctrl + "/modalias":                                  "pci:v0000144Dd0000A80Asv0000144Dsd0000A801bc01sc08i02\n",
+		ctrl + "/nvme/nvme0/nvme0n1/size":                   "2048\n",
+		ctrl + "/nvme/nvme0/nvme0n1/dev":                    "259:0\n",
+		ctrl + "/nvme/nvme0/nvme0n1/nvme0n1p1/size":         "1024\n",
+		ctrl + "/nvme/nvme0/nvme0n1/nvme0n1p1/dev":          "259:1\n",
+		ctrl + "/nvme/nvme0/nvme0n1/nvme0n1p1/holders/dm-0": "",
+		"virtual/block/dm-0/size":                           "1024\n",
+		"virtual/block/dm-0/dev":                            "253:0\n",
+		"virtual/block/dm-0/dm/name":                        "vg0-data\n",
+		"virtual/block/dm-0/dm/uuid":                        "LVM-q3bDKmvK3Hh0ZIXNd7fo8FFYDMR3ye6Hd5DOSyNQCOhhPy4Kx9Y9V6tDBOVKq2Hb\n",
+		"virtual/block/dm-0/slaves/nvme0n1p1":               "",
+		"system/node/node1/cpu2/topology/core_id":           "2\n",
+		"system/node/node1/cpu3/topology/core_id":           "2\n",
+		"system/node/node1/distance":                        "10\n",
+	})
+	writeFiles(t, root, map[string]string{
+		"proc/self/mountinfo": "22 1 259:2 / / rw - ext4 /dev/sda1 rw\n" +
+			"23 22 253:0 / /var/lib rw,noatime - xfs /dev/mapper/vg0-data rw\n" +
+			"24 23 0:45 / /var/lib/db/tmp rw - tmpfs tmpfs rw\n",
+		"usr/share/hwdata/pci.ids": "144d  Samsung Electronics Co Ltd\n" +
+			"\ta80a  NVMe SSD Controller PM9A1/PM9A3/980PRO\n",
+		"var/lib/db/tmp/.keep": "",
+	})
+	if err = os.Symlink("/var/lib/db", filepath.Join(root, "data")); err != nil {
+		t.Fatalf("Unable to create data link: %v", err)
+	}
+	if err = os.MkdirAll(filepath.Join(root, "sys", "block"), os.ModePerm); err != nil {
+		t.Fatalf("Unable to create /sys/block: %v", err)
+	}
+	for name, path := range disks {
+		if err = os.Symlink(filepath.Join("..", "devices", path), filepath.Join(root, "sys", "block", name)); err != nil {
+			t.Fatalf("Unable to create block device link: %v", err)
+		}
+	}
+	if err = os.MkdirAll(filepath.Join(root, "sys", "bus", "pci", "devices"), os.ModePerm); err != nil {
+		t.Fatalf("Unable to create /sys/bus/pci/devices: %v", err)
+	}
+	if err = os.Symlink(filepath.Join("..", "..", "..", "devices", ctrl), filepath.Join(root, "sys", "bus", "pci", "devices", "0000:03:00.0")); err != nil {
+		t.Fatalf("Unable to create PCI device link: %v", err)
+	}
+
+	chain, err := ResolvePath("/data", option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if chain.Path != "/var/lib/db" {
+		t.Fatalf("Expected /data to resolve to /var/lib/db, but got %s", chain.Path)
+	}
+	if chain.Mount == nil || chain.Mount.MountPoint != "/var/lib" || chain.Mount.Type != "xfs" {
+		t.Fatalf("Expected /var/lib/db to live on the xfs filesystem mounted on /var/lib, but got %v", chain.Mount)
+	}
+	if len(chain.MappedDevices) != 1 || chain.MappedDevices[0].MapperName != "vg0-data" {
+		t.Fatalf("Expected /var/lib to be on vg0-data, but got %v", chain.MappedDevices)
+	}
+	if len(chain.Partitions) != 1 || chain.Partitions[0].Name != "nvme0n1p1" {
+		t.Fatalf("Expected vg0-data to be on nvme0n1p1, but got %v", chain.Partitions)
+	}
+	if len(chain.Disks) != 1 || chain.Disks[0].Name != "nvme0n1" {
+		t.Fatalf("Expected nvme0n1p1 to be on nvme0n1, but got %v", chain.Disks)
+	}
+	if len(chain.Controllers) != 1 || chain.Controllers[0].Address != "0000:03:00.0" {
+		t.Fatalf("Expected nvme0n1 to hang off 0000:03:00.0, but got %v", chain.Controllers)
+	}
+	if chain.Controllers[0].Vendor.Name != "Samsung Electronics Co Ltd" {
+		t.Fatalf("Expected a Samsung controller, but got %s", chain.Controllers[0].Vendor.Name)
+	}
+	if lps := chain.Controllers[0].LocalLogicalProcessors; !reflect.DeepEqual(lps, []int{2, 3}) {
+		t.Fatalf("Expected the controller to be local to logical processors 2 and 3, but got %v", lps)
+	}
+	if len(chain.NUMANodes) != 1 || chain.NUMANodes[0].ID != 1 {
+		t.Fatalf("Expected the controller to be attached to NUMA node 1, but got %v", chain.NUMANodes)
+	}
+	if cores := chain.NUMANodes[0].Cores; len(cores) != 1 || len(cores[0].LogicalProcessors) != 2 {
+		t.Fatalf("Expected NUMA node 1 to have 1 core with 2 logical processors, but got %v", cores)
+	}
+
+	if _, err = ResolvePath("/data/tmp", option.WithChroot(root), option.WithNullAlerter()); err == nil {
+		t.Fatalf("Expected an error resolving a path on a tmpfs")
+	}
+	if _, err = ResolvePath("/nonexistent", option.WithChroot(root), option.WithNullAlerter()); err == nil {
+		t.Fatalf("Expected an error resolving a nonexistent path")
+	}
+}
@@ -0,0 +1,62 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+)
+
+// SCSIAddress describes the address of a SCSI device, as shown by lsscsi
+type SCSIAddress struct {
+	Host    int    `json:"host"`
+	Channel int    `json:"channel"`
+	Target  int    `json:"target"`
+	LUN     uint64 `json:"lun"`
+}
+
+func (a *SCSIAddress) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d", a.Host, a.Channel, a.Target, a.LUN)
+}
+
+// SCSIHost describes a SCSI host adapter, e.g. a SAS HBA, a RAID controller
+// or an AHCI SATA controller
+type SCSIHost struct {
+	// Name is the kernel name of the host, e.g. "host0"
+	Name string `json:"name"`
+	// ProcName is the name the driver registered the host with in the SCSI
+	// layer, e.g. "mpt3sas" or "ahci"
+	ProcName string `json:"proc_name"`
+	// Driver is the name of the kernel driver bound to the PCI device
+	// backing the host, e.g. "mpt3sas" or "ahci"
+	Driver string `json:"driver"`
+	// PCIAddress is the PCI address of the device backing the host, or nil
+	// for hosts not backed by a PCI device (e.g. iSCSI hosts). For USB
+	// storage, it is the address of the USB controller.
+	PCIAddress *string `json:"pci_address,omitempty"`
+	// TODO(jaypipes): Convert this to a TopologyNode struct pointer and then
+	// add to serialized output as "numa_node,omitempty"
+	NUMANodeID int `json:"-"`
+}
+
+func (h *SCSIHost) String() string {
+	pciAddr := ""
+	if h.PCIAddress != nil {
+		pciAddr = " [@" + *h.PCIAddress + "]"
+	}
+	atNode := ""
+	if h.NUMANodeID >= 0 {
+		atNode = fmt.Sprintf(" (node #%d)", h.NUMANodeID)
+	}
+	return fmt.Sprintf(
+		"%s proc_name=%s driver=%s%s%s",
+		h.Name,
+		h.ProcName,
+		h.Driver,
+		pciAddr,
+		atNode,
+	)
+}
@@ -0,0 +1,189 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+	pciaddr "github.com/jaypipes/ghw/pkg/pci/address"
+)
+
+var (
+	scsiAddressRegex = regexp.MustCompile(`^(\d+):(\d+):(\d+):(\d+)$`)
+	scsiHostRegex    = regexp.MustCompile(`^host\d+$`)
+	sasPortRegex     = regexp.MustCompile(`^port-\d+(:\d+)+$`)
+	sasEndDevRegex   = regexp.MustCompile(`^end_device-\d+(:\d+)+$`)
+)
+
+// diskDevicePath returns the sysfs path of the device directory of the
+// supplied disk, with all symlinks resolved, e.g.
+// "/sys/devices/pci0000:00/0000:00:1f.2/ata1/host0/target0:0:0/0:0:0:0/block/sda"
+func diskDevicePath(paths *linuxpath.Paths, disk string) string {
+	devPath, err := filepath.EvalSymlinks(filepath.Join(paths.SysBlock, disk))
+	if err != nil {
+		return ""
+	}
+	return devPath
+}
+
+// deviceAncestors returns the sysfs directories the supplied device path is
+// nested in, nearest first, stopping before the /sys/devices root
+func deviceAncestors(paths *linuxpath.Paths, devPath string) []string {
+	out := make([]string, 0)
+	if devPath == "" {
+		return out
+	}
+	root := filepath.Join(filepath.Dir(paths.SysBlock), "devices")
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	for dir := filepath.Dir(devPath); strings.HasPrefix(dir, root+string(os.PathSeparator)); dir = filepath.Dir(dir) {
+		out = append(out, dir)
+	}
+	return out
+}
+
+// diskAncestors returns the sysfs directories each block device found in
+// /sys/block is nested in, keyed by device name, see deviceAncestors. They
+// are computed once and shared by the functions looking up the transport,
+// the PCI device, the SCSI host, the ATA port and the iSCSI session of disks.
+func diskAncestors(paths *linuxpath.Paths) map[string][]string {
+	out := make(map[string][]string)
+	entries, err := ioutil.ReadDir(paths.SysBlock)
+	if err != nil {
+		return out
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		out[name] = deviceAncestors(paths, diskDevicePath(paths, name))
+	}
+	return out
+}
+
+// diskPCIAddress returns the address of the PCI device the supplied disk
+// hangs off: its HBA, NVMe controller or virtio device. It returns nil for
+// disks without a PCI device, like NVMe over Fabrics namespaces.
+func diskPCIAddress(ancestors []string) *string {
+	for _, dir := range ancestors {
+		if addr := pciaddr.FromString(filepath.Base(dir)); addr != nil {
+			pciAddr := filepath.Base(dir)
+			return &pciAddr
+		}
+	}
+	return nil
+}
+
+// diskSCSI fills in the SCSI address and SAS information of the supplied
+// disk, if it is a SCSI device, and returns the SCSIHost serving it, or nil.
+// Hosts are looked up in, and added to, the supplied map keyed by host name.
+func diskSCSI(ancestors []string, d *Disk, hosts map[string]*SCSIHost) *SCSIHost {
+	// The sysfs path of a SCSI disk looks like
+	// /sys/devices/pci0000:00/0000:00:01.0/0000:01:00.0/host2/port-2:0/end_device-2:0/target2:0:0/2:0:0:0/block/sdb
+	// with the port-* and end_device-* components only present for SAS
+	// end devices.
+	for x, dir := range ancestors {
+		base := filepath.Base(dir)
+		switch {
+		case d.SCSIAddress == nil && scsiAddressRegex.MatchString(base):
+			d.SCSIAddress = scsiAddress(base)
+			d.SASAddress = sysfsAttr(dir, "sas_address")
+		case d.SASAddress == "" && sasEndDevRegex.MatchString(base):
+			d.SASAddress = sysfsAttr(filepath.Join(dir, "sas_device", base), "sas_address")
+		case d.SASPort == "" && sasPortRegex.MatchString(base):
+			d.SASPort = base
+		case scsiHostRegex.MatchString(base):
+			if h, ok := hosts[base]; ok {
+				return h
+			}
+			h := scsiHost(dir, ancestors[x+1:])
+			hosts[base] = h
+			return h
+		}
+	}
+	return nil
+}
+
+// scsiHost returns a pointer to a SCSIHost struct describing the host found
+// in the supplied sysfs directory. The host is backed by the nearest PCI
+// device among the directories it is nested in.
+func scsiHost(hostPath string, ancestors []string) *SCSIHost {
+	name := filepath.Base(hostPath)
+	h := &SCSIHost{
+		Name:       name,
+		ProcName:   sysfsAttr(filepath.Join(hostPath, "scsi_host", name), "proc_name"),
+		NUMANodeID: deviceNUMANodeID(ancestors),
+	}
+	for _, dir := range ancestors {
+		if addr := pciaddr.FromString(filepath.Base(dir)); addr == nil {
+			continue
+		}
+		pciAddr := filepath.Base(dir)
+		h.PCIAddress = &pciAddr
+		if dest, err := os.Readlink(filepath.Join(dir, "driver")); err == nil {
+			h.Driver = filepath.Base(dest)
+		}
+		break
+	}
+	return h
+}
+
+// scsiHosts returns a slice of pointers to SCSIHost structs, one for each
+// SCSI host serving at least one of the supplied Disks, whose ancestors are
+// supplied keyed by name. The SCSI address, host and SAS information of each
+// Disk are filled in along the way.
+func scsiHosts(disks []*Disk, ancestors map[string][]string) []*SCSIHost {
+	out := make([]*SCSIHost, 0)
+	hosts := make(map[string]*SCSIHost)
+	for _, d := range disks {
+		known := len(hosts)
+		d.SCSIHost = diskSCSI(ancestors[d.Name], d, hosts)
+		if len(hosts) > known {
+			out = append(out, d.SCSIHost)
+		}
+	}
+	return out
+}
+
+// scsiAddress parses a H:C:T:L SCSI address
+func scsiAddress(addr string) *SCSIAddress {
+	m := scsiAddressRegex.FindStringSubmatch(addr)
+	if m == nil {
+		return nil
+	}
+	host, _ := strconv.Atoi(m[1])
+	channel, _ := strconv.Atoi(m[2])
+	target, _ := strconv.Atoi(m[3])
+	lun, _ := strconv.ParseUint(m[4], 10, 64)
+	return &SCSIAddress{
+		Host:    host,
+		Channel: channel,
+		Target:  target,
+		LUN:     lun,
+	}
+}
+
+// deviceNUMANodeID returns the NUMA node of the nearest of the supplied
+// device directories having a numa_node attribute, or -1
+func deviceNUMANodeID(ancestors []string) int {
+	for _, dir := range ancestors {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, "numa_node"))
+		if err != nil {
+			continue
+		}
+		nodeID, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+		if err != nil {
+			return -1
+		}
+		return nodeID
+	}
+	return -1
+}
@@ -0,0 +1,131 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestDiskSCSI(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-scsi-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// sda is a SATA disk behind an AHCI controller, sdb and sdc are SAS end
+	// devices behind the same HBA and nvme0n1 is a NVMe namespace. vda is
+	// not backed by any PCI device we know of.
+	ahci := "pci0000:00/0000:00:1f.2"
+	hba := "pci0000:80/0000:80:01.0/0000:81:00.0"
+	disks := map[string]string{
+		"sda":     ahci + "/ata1/host0/target0:0:0/0:0:0:0/block/sda",
+		"sdb":     hba + "/host2/port-2:0/end_device-2:0/target2:0:0/2:0:0:0/block/sdb",
+		"sdc":     hba + "/host2/port-2:1/end_device-2:1/target2:0:1/2:0:1:3/block/sdc",
+		"nvme0n1": "pci0000:00/0000:00:1d.0/0000:03:00.0/nvme/nvme0/nvme0n1",
+		"vda":     "virtual/block/vda",
+	}
+	files := map[string]string{
+		ahci + "/numa_node":                            "-1\n",
+		ahci + "/ata1/host0/scsi_host/host0/proc_name": "ahci\n",
+		hba + "/numa_node":                             "1\n",
+		hba + "/host2/scsi_host/host2/proc_name":       "mpt3sas\n",
+		hba + "/host2/port-2:0/end_device-2:0/sas_device/end_device-2:0/sas_address": "0x5000c500a1b2c3d4\n",
+		hba + "/host2/port-2:1/end_device-2:1/target2:0:1/2:0:1:3/sas_address":       "0x5000c500a1b2c3d5\n",
+		"pci0000:00/0000:00:1d.0/0000:03:00.0/numa_node":                             "0\n",
+	}
+	for _, path := range disks {
+		files[filepath.Join(path, "size")] = "2048\n"
+	}
+	writeFiles(t, filepath.Join(root, "sys", "devices"), files)
+	if err = os.Symlink("../../../../bus/pci/drivers/mpt3sas", filepath.Join(root, "sys", "devices", hba, "driver")); err != nil {
+		t.Fatalf("Unable to create driver link: %v", err)
+	}
+	if err = os.MkdirAll(filepath.Join(root, "sys", "block"), os.ModePerm); err != nil {
+		t.Fatalf("Unable to create /sys/block: %v", err)
+	}
+	for name, path := range disks {
+		if err = os.Symlink(filepath.Join("..", "devices", path), filepath.Join(root, "sys", "block", name)); err != nil {
+			t.Fatalf("Unable to create block device link: %v", err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 5 {
+		t.Fatalf("Expected 5 disks, but got %d", len(info.Disks))
+	}
+	byName := make(map[string]*Disk, len(info.Disks))
+	for _, d := range info.Disks {
+		byName[d.Name] = d
+	}
+
+	sda := byName["sda"]
+	if sda.SCSIAddress == nil || sda.SCSIAddress.String() != "0:0:0:0" {
+		t.Fatalf("Expected sda to be at SCSI address 0:0:0:0, but got %v", sda.SCSIAddress)
+	}
+	if sda.SCSIHost == nil || sda.SCSIHost.Name != "host0" || sda.SCSIHost.ProcName != "ahci" {
+		t.Fatalf("Expected sda to be served by the ahci host0, but got %v", sda.SCSIHost)
+	}
+	if sda.PCIAddress == nil || *sda.PCIAddress != "0000:00:1f.2" {
+		t.Fatalf("Expected sda to hang off 0000:00:1f.2, but got %v", sda.PCIAddress)
+	}
+	if sda.NUMANodeID != -1 || sda.SASAddress != "" {
+		t.Fatalf("Expected sda to have no NUMA node and no SAS address, but got %d and %q", sda.NUMANodeID, sda.SASAddress)
+	}
+
+	sdb := byName["sdb"]
+	if sdb.SCSIAddress == nil || sdb.SCSIAddress.String() != "2:0:0:0" {
+		t.Fatalf("Expected sdb to be at SCSI address 2:0:0:0, but got %v", sdb.SCSIAddress)
+	}
+	if sdb.SASAddress != "0x5000c500a1b2c3d4" || sdb.SASPort != "port-2:0" {
+		t.Fatalf("Expected sdb to be SAS end device 0x5000c500a1b2c3d4 on port-2:0, but got %q on %q", sdb.SASAddress, sdb.SASPort)
+	}
+	if sdb.NUMANodeID != 1 {
+		t.Fatalf("Expected sdb to be on NUMA node 1, but got %d", sdb.NUMANodeID)
+	}
+	h := sdb.SCSIHost
+	if h == nil || h.ProcName != "mpt3sas" || h.Driver != "mpt3sas" || h.PCIAddress == nil || *h.PCIAddress != "0000:81:00.0" {
+		t.Fatalf("Expected sdb to be served by the mpt3sas HBA at 0000:81:00.0, but got %v", h)
+	}
+
+	sdc := byName["sdc"]
+	if sdc.SCSIHost != h {
+		t.Fatalf("Expected sdc to share its SCSI host with sdb, but got %v", sdc.SCSIHost)
+	}
+	if sdc.SCSIAddress.LUN != 3 || sdc.SASAddress != "0x5000c500a1b2c3d5" || sdc.SASPort != "port-2:1" {
+		t.Fatalf("Expected sdc to be LUN 3 of SAS end device 0x5000c500a1b2c3d5 on port-2:1, but got %v, %q and %q", sdc.SCSIAddress, sdc.SASAddress, sdc.SASPort)
+	}
+
+	nvme := byName["nvme0n1"]
+	if nvme.SCSIAddress != nil || nvme.SCSIHost != nil {
+		t.Fatalf("Expected nvme0n1 not to be a SCSI disk, but got %v", nvme.SCSIAddress)
+	}
+	if nvme.PCIAddress == nil || *nvme.PCIAddress != "0000:03:00.0" || nvme.NUMANodeID != 0 {
+		t.Fatalf("Expected nvme0n1 to hang off 0000:03:00.0 on NUMA node 0, but got %v on %d", nvme.PCIAddress, nvme.NUMANodeID)
+	}
+	if vda := byName["vda"]; vda.PCIAddress != nil || vda.NUMANodeID != -1 {
+		t.Fatalf("Expected vda to have no PCI address nor NUMA node, but got %v on %d", vda.PCIAddress, vda.NUMANodeID)
+	}
+
+	if len(info.SCSIHosts) != 2 {
+		t.Fatalf("Expected 2 SCSI hosts, but got %d", len(info.SCSIHosts))
+	}
+}
@@ -0,0 +1,200 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"time"
+)
+
+// IOStats contains the I/O counters of a disk or partition since it was
+// brought up, as reported by the kernel. Sectors are always 512 bytes long,
+// whatever the block size of the device, and times are in milliseconds.
+type IOStats struct {
+	ReadIOs        uint64 `json:"read_ios"`
+	ReadMerges     uint64 `json:"read_merges"`
+	ReadSectors    uint64 `json:"read_sectors"`
+	ReadTicks      uint64 `json:"read_ticks"`
+	WriteIOs       uint64 `json:"write_ios"`
+	WriteMerges    uint64 `json:"write_merges"`
+	WriteSectors   uint64 `json:"write_sectors"`
+	WriteTicks     uint64 `json:"write_ticks"`
+	InFlight       uint64 `json:"in_flight"`
+	IOTicks        uint64 `json:"io_ticks"`
+	TimeInQueue    uint64 `json:"time_in_queue"`
+	DiscardIOs     uint64 `json:"discard_ios"`
+	DiscardMerges  uint64 `json:"discard_merges"`
+	DiscardSectors uint64 `json:"discard_sectors"`
+	DiscardTicks   uint64 `json:"discard_ticks"`
+	FlushIOs       uint64 `json:"flush_ios"`
+	FlushTicks     uint64 `json:"flush_ticks"`
+}
+
+// IOStatsSample contains the I/O counters of all the disks and partitions
+// of an Info, read at the same time
+type IOStatsSample struct {
+	Time time.Time `json:"time"`
+	// Disks and Partitions map the name of each disk and partition to its
+	// counters
+	Disks      map[string]*IOStats `json:"disks"`
+	Partitions map[string]*IOStats `json:"partitions"`
+}
+
+// Disk returns the I/O counters of the supplied Disk, or nil
+func (s *IOStatsSample) Disk(d *Disk) *IOStats {
+	return s.Disks[d.Name]
+}
+
+// Partition returns the I/O counters of the supplied Partition, or nil
+func (s *IOStatsSample) Partition(p *Partition) *IOStats {
+	return s.Partitions[p.Name]
+}
+
+// IORates describes the activity of a disk or partition between two
+// IOStats samples, like iostat does
+type IORates struct {
+	ReadIOPS            float64 `json:"read_iops"`
+	WriteIOPS           float64 `json:"write_iops"`
+	DiscardIOPS         float64 `json:"discard_iops"`
+	FlushIOPS           float64 `json:"flush_iops"`
+	ReadBytesPerSecond  float64 `json:"read_bytes_per_second"`
+	WriteBytesPerSecond float64 `json:"write_bytes_per_second"`
+	// Utilization is the percentage of time the device had I/O requests in
+	// flight
+	Utilization float64 `json:"utilization"`
+	// ReadLatencyMs and WriteLatencyMs are the average time, in
+	// milliseconds, read and write requests took to be served, including
+	// the time spent in queue
+	ReadLatencyMs  float64 `json:"read_latency_ms"`
+	WriteLatencyMs float64 `json:"write_latency_ms"`
+	// AverageQueueSize is the average number of requests in flight
+	AverageQueueSize float64 `json:"average_queue_size"`
+}
+
+func (r *IORates) String() string {
+	return fmt.Sprintf(
+		"r/s=%.2f w/s=%.2f rB/s=%.0f wB/s=%.0f r_await=%.2fms w_await=%.2fms util=%.2f%%",
+		r.ReadIOPS,
+		r.WriteIOPS,
+		r.ReadBytesPerSecond,
+		r.WriteBytesPerSecond,
+		r.ReadLatencyMs,
+		r.WriteLatencyMs,
+		r.Utilization,
+	)
+}
+
+// IORatesSample contains the I/O rates of all the disks and partitions of an
+// Info over an interval
+type IORatesSample struct {
+	Interval   time.Duration       `json:"interval"`
+	Disks      map[string]*IORates `json:"disks"`
+	Partitions map[string]*IORates `json:"partitions"`
+}
+
+// Disk returns the I/O rates of the supplied Disk, or nil
+func (s *IORatesSample) Disk(d *Disk) *IORates {
+	return s.Disks[d.Name]
+}
+
+// Partition returns the I/O rates of the supplied Partition, or nil
+func (s *IORatesSample) Partition(p *Partition) *IORates {
+	return s.Partitions[p.Name]
+}
+
+// IOStats reads the current I/O counters of the disks and partitions of the
+// Info. Devices removed since the Info was built are left out of the sample.
+func (i *Info) IOStats() (*IOStatsSample, error) {
+	var sample *IOStatsSample
+	err := i.ctx.Do(func() error {
+		var err error
+		sample, err = i.ioStats()
+		return err
+	})
+	return sample, err
+}
+
+// IORates samples the I/O counters of the disks and partitions of the Info
+// twice, the supplied interval apart, and returns the resulting I/O rates
+func (i *Info) IORates(interval time.Duration) (*IORatesSample, error) {
+	prev, err := i.IOStats()
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(interval)
+	cur, err := i.IOStats()
+	if err != nil {
+		return nil, err
+	}
+	return cur.Rates(prev), nil
+}
+
+// Rates returns the I/O rates of the disks and partitions between the
+// supplied, earlier sample and this one
+func (s *IOStatsSample) Rates(prev *IOStatsSample) *IORatesSample {
+	interval := s.Time.Sub(prev.Time)
+	out := &IORatesSample{
+		Interval:   interval,
+		Disks:      make(map[string]*IORates, len(s.Disks)),
+		Partitions: make(map[string]*IORates, len(s.Partitions)),
+	}
+	for name, cur := range s.Disks {
+		if old, ok := prev.Disks[name]; ok {
+			out.Disks[name] = cur.Rates(old, interval)
+		}
+	}
+	for name, cur := range s.Partitions {
+		if old, ok := prev.Partitions[name]; ok {
+			out.Partitions[name] = cur.Rates(old, interval)
+		}
+	}
+	return out
+}
+
+// Rates returns the I/O rates between the supplied counters, read the
+// supplied interval earlier, and these ones
+func (s *IOStats) Rates(prev *IOStats, interval time.Duration) *IORates {
+	r := &IORates{}
+	seconds := interval.Seconds()
+	if seconds <= 0 {
+		return r
+	}
+	readIOs := counterDelta(s.ReadIOs, prev.ReadIOs)
+	writeIOs := counterDelta(s.WriteIOs, prev.WriteIOs)
+	r.ReadIOPS = float64(readIOs) / seconds
+	r.WriteIOPS = float64(writeIOs) / seconds
+	r.DiscardIOPS = float64(counterDelta(s.DiscardIOs, prev.DiscardIOs)) / seconds
+	r.FlushIOPS = float64(counterDelta(s.FlushIOs, prev.FlushIOs)) / seconds
+	r.ReadBytesPerSecond = float64(counterDelta(s.ReadSectors, prev.ReadSectors)*ioStatsSectorSize) / seconds
+	r.WriteBytesPerSecond = float64(counterDelta(s.WriteSectors, prev.WriteSectors)*ioStatsSectorSize) / seconds
+	if readIOs > 0 {
+		r.ReadLatencyMs = float64(counterDelta(s.ReadTicks, prev.ReadTicks)) / float64(readIOs)
+	}
+	if writeIOs > 0 {
+		r.WriteLatencyMs = float64(counterDelta(s.WriteTicks, prev.WriteTicks)) / float64(writeIOs)
+	}
+	ms := seconds * 1000
+	r.Utilization = float64(counterDelta(s.IOTicks, prev.IOTicks)) * 100 / ms
+	if r.Utilization > 100 {
+		r.Utilization = 100
+	}
+	r.AverageQueueSize = float64(counterDelta(s.TimeInQueue, prev.TimeInQueue)) / ms
+	return r
+}
+
+// The kernel always counts I/O in 512-byte sectors
+const ioStatsSectorSize = 512
+
+// counterDelta returns the increase of a counter between two samples. A
+// counter going backwards means the device was reset (or the counter
+// wrapped), in which case there is no meaningful delta.
+func counterDelta(cur uint64, prev uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
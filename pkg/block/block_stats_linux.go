@@ -0,0 +1,86 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// ioStats reads the counters of the disks and partitions of the Info. The
+// ones whose counters cannot be read, like devices removed since the Info was
+// built, are left out of the sample with a warning.
+func (i *Info) ioStats() (*IOStatsSample, error) {
+	paths := linuxpath.New(i.ctx)
+	sample := &IOStatsSample{
+		Time:       time.Now(),
+		Disks:      make(map[string]*IOStats, len(i.Disks)),
+		Partitions: make(map[string]*IOStats),
+	}
+	for _, d := range i.Disks {
+		stats, err := readIOStats(filepath.Join(paths.SysBlock, d.Name, "stat"))
+		if err != nil {
+			i.ctx.Warn("failed to read I/O statistics of %s: %s\n", d.Name, err)
+			continue
+		}
+		sample.Disks[d.Name] = stats
+		for _, p := range d.Partitions {
+			stats, err := readIOStats(filepath.Join(paths.SysBlock, d.Name, p.Name, "stat"))
+			if err != nil {
+				i.ctx.Warn("failed to read I/O statistics of %s: %s\n", p.Name, err)
+				continue
+			}
+			sample.Partitions[p.Name] = stats
+		}
+	}
+	return sample, nil
+}
+
+// readIOStats parses a /sys/block/$DEVICE/stat file. It has 11 fields, 15
+// since Linux 4.18 added the discard counters and 17 since Linux 5.5 added
+// the flush counters. See Documentation/block/stat.rst in the kernel tree.
+func readIOStats(path string) (*IOStats, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(contents))
+	if len(fields) < 11 {
+		return nil, fmt.Errorf("%s: expected at least 11 fields, but got %d", path, len(fields))
+	}
+	vals := make([]uint64, 17)
+	for x := 0; x < len(fields) && x < len(vals); x++ {
+		vals[x], err = strconv.ParseUint(fields[x], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return &IOStats{
+		ReadIOs:        vals[0],
+		ReadMerges:     vals[1],
+		ReadSectors:    vals[2],
+		ReadTicks:      vals[3],
+		WriteIOs:       vals[4],
+		WriteMerges:    vals[5],
+		WriteSectors:   vals[6],
+		WriteTicks:     vals[7],
+		InFlight:       vals[8],
+		IOTicks:        vals[9],
+		TimeInQueue:    vals[10],
+		DiscardIOs:     vals[11],
+		DiscardMerges:  vals[12],
+		DiscardSectors: vals[13],
+		DiscardTicks:   vals[14],
+		FlushIOs:       vals[15],
+		FlushTicks:     vals[16],
+	}, nil
+}
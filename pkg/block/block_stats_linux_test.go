@@ -0,0 +1,112 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestIOStats(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-stats-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// sda reports the 17 fields of Linux 5.5+, vda the 11 fields of older
+	// kernels
+	sysBlock := filepath.Join(root, "sys", "block")
+	writeFiles(t, sysBlock, map[string]string{
+		"sda/size":           "2048\n",
+		"sda/stat":           "  100  10  8000  50  200  20  16000  400  1  300  450  5  0  1024  2  7  3\n",
+		"sda/sda1/partition": "1\n",
+		"sda/sda1/size":      "1024\n",
+		"sda/sda1/stat":      "  40  0  3200  20  80  0  6400  160  0  120  180  0  0  0  0  0  0\n",
+		"vda/size":           "2048\n",
+		"vda/stat":           "  10  0  80  5  0  0  0  0  0  5  5\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	prev, err := info.IOStats()
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	sda := prev.Disk(info.Disks[0])
+	if sda == nil || sda.ReadIOs != 100 || sda.WriteSectors != 16000 || sda.DiscardSectors != 1024 || sda.FlushIOs != 7 {
+		t.Fatalf("Unexpected sda counters: %+v", sda)
+	}
+	if vda := prev.Disk(info.Disks[1]); vda == nil || vda.TimeInQueue != 5 || vda.DiscardIOs != 0 {
+		t.Fatalf("Unexpected vda counters: %+v", vda)
+	}
+	if sda1 := prev.Partition(info.Disks[0].Partitions[0]); sda1 == nil || sda1.WriteIOs != 80 {
+		t.Fatalf("Unexpected sda1 counters: %+v", sda1)
+	}
+
+	// Two seconds later, sda served 100 more reads of 4KiB taking 2ms each
+	// and 50 more writes of 8KiB taking 10ms each, and was busy for half of
+	// the time
+	writeFiles(t, sysBlock, map[string]string{
+		"sda/stat": "  200  10  8800  250  250  20  16800  900  0  1300  1150  5  0  1024  2  9  4\n",
+	})
+	cur, err := info.IOStats()
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	cur.Time = prev.Time.Add(2 * time.Second)
+	rates := cur.Rates(prev)
+	r := rates.Disk(info.Disks[0])
+	if r == nil {
+		t.Fatalf("Expected rates for sda, but got nil")
+	}
+	expected := IORates{
+		ReadIOPS:            50,
+		WriteIOPS:           25,
+		FlushIOPS:           1,
+		ReadBytesPerSecond:  204800,
+		WriteBytesPerSecond: 204800,
+		Utilization:         50,
+		ReadLatencyMs:       2,
+		WriteLatencyMs:      10,
+		AverageQueueSize:    0.35,
+	}
+	if *r != expected {
+		t.Fatalf("Expected sda rates %+v, but got %+v", expected, *r)
+	}
+	if r := rates.Partition(info.Disks[0].Partitions[0]); r == nil || r.ReadIOPS != 0 || r.Utilization != 0 {
+		t.Fatalf("Expected idle sda1, but got %v", r)
+	}
+
+	// vda is hot-removed, and left out of the samples taken afterwards
+	if err = os.RemoveAll(filepath.Join(sysBlock, "vda")); err != nil {
+		t.Fatalf("Unable to remove vda: %v", err)
+	}
+	cur, err = info.IOStats()
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if cur.Disk(info.Disks[1]) != nil || cur.Disk(info.Disks[0]) == nil {
+		t.Fatalf("Expected counters for sda only, but got %v", cur.Disks)
+	}
+	if r := cur.Rates(prev).Disk(info.Disks[1]); r != nil {
+		t.Fatalf("Expected no rates for vda, but got %+v", r)
+	}
+}
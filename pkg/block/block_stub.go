@@ -1,4 +1,5 @@
 // +build !linux,!darwin,!windows
+
 // Use and distribution licensed under the Apache license version 2.
 //
 // See the COPYING file in the root project directory for full text.
@@ -15,3 +16,15 @@ import (
 func (i *Info) load() error {
 	return errors.New("blockFillInfo not implemented on " + runtime.GOOS)
 }
+
+func (i *Info) ioStats() (*IOStatsSample, error) {
+	return nil, errors.New("ioStats not implemented on " + runtime.GOOS)
+}
+
+func (i *Info) loadStack() error {
+	return errors.New("loadStack not implemented on " + runtime.GOOS)
+}
+
+func (i *Info) resolvePath(chain *PathChain, path string) error {
+	return errors.New("resolvePath not implemented on " + runtime.GOOS)
+}
@@ -0,0 +1,61 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"strings"
+)
+
+// Transport describes the bus or fabric a disk is reached through
+type Transport int
+
+const (
+	TRANSPORT_UNKNOWN   Transport = iota
+	TRANSPORT_ATA                 // (S)ATA disk on an AHCI or IDE controller
+	TRANSPORT_SAS                 // Serial Attached SCSI
+	TRANSPORT_USB                 // USB mass storage
+	TRANSPORT_FC                  // Fibre Channel LUN or NVMe over Fibre Channel
+	TRANSPORT_ISCSI               // iSCSI LUN
+	TRANSPORT_VIRTIO              // virtio-blk or virtio-scsi device
+	TRANSPORT_NVME_PCIE           // Locally attached NVMe namespace
+	TRANSPORT_NVME_TCP            // NVMe over TCP namespace
+	TRANSPORT_MMC                 // MMC or SD card
+	TRANSPORT_XEN                 // Xen virtual block device
+)
+
+var (
+	transportString = map[Transport]string{
+		TRANSPORT_UNKNOWN:   "Unknown",
+		TRANSPORT_ATA:       "ATA",
+		TRANSPORT_SAS:       "SAS",
+		TRANSPORT_USB:       "USB",
+		TRANSPORT_FC:        "FC",
+		TRANSPORT_ISCSI:     "iSCSI",
+		TRANSPORT_VIRTIO:    "virtio",
+		TRANSPORT_NVME_PCIE: "NVMe-PCIe",
+		TRANSPORT_NVME_TCP:  "NVMe-TCP",
+		TRANSPORT_MMC:       "MMC",
+		TRANSPORT_XEN:       "Xen",
+	}
+
+	// transportByNVMeTransport maps the transport of the NVMe controller
+	// serving a namespace to the transport of the namespace disk
+	transportByNVMeTransport = map[NVMeTransport]Transport{
+		NVME_TRANSPORT_PCIE: TRANSPORT_NVME_PCIE,
+		NVME_TRANSPORT_TCP:  TRANSPORT_NVME_TCP,
+		NVME_TRANSPORT_FC:   TRANSPORT_FC,
+	}
+)
+
+func (t Transport) String() string {
+	return transportString[t]
+}
+
+// MarshalJSON encodes the transport as its lowercased name
+func (t Transport) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + strings.ToLower(t.String()) + "\""), nil
+}
@@ -0,0 +1,97 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var (
+	// transportBySubsystem maps the subsystem of a device a disk is nested
+	// in to the transport of the disk
+	transportBySubsystem = map[string]Transport{
+		"usb":    TRANSPORT_USB,
+		"mmc":    TRANSPORT_MMC,
+		"virtio": TRANSPORT_VIRTIO,
+		"xen":    TRANSPORT_XEN,
+	}
+
+	// The subsystem links are not part of snapshots, so the transport is
+	// also recognized from the names of the directories a disk is nested in
+	transportRegexes = []struct {
+		regex     *regexp.Regexp
+		transport Transport
+	}{
+		{regexp.MustCompile(`^(ata|ide)\d+$`), TRANSPORT_ATA},
+		{sasPortRegex, TRANSPORT_SAS},
+		{sasEndDevRegex, TRANSPORT_SAS},
+		{regexp.MustCompile(`^usb\d+$`), TRANSPORT_USB},
+		{regexp.MustCompile(`^rport-\d+:\d+-\d+$`), TRANSPORT_FC},
+		{regexp.MustCompile(`^session\d+$`), TRANSPORT_ISCSI},
+		{regexp.MustCompile(`^virtio\d+$`), TRANSPORT_VIRTIO},
+		{regexp.MustCompile(`^mmc\d+(:[0-9a-f]+)?$`), TRANSPORT_MMC},
+		{regexp.MustCompile(`^vbd-\d+$`), TRANSPORT_XEN},
+	}
+)
+
+// diskTransport returns the transport of a disk from the sysfs directories
+// it is nested in, nearest first, e.g.
+// /sys/devices/pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/host6/target6:0:0/6:0:0:0/block/sdb
+// for a USB stick. The transport of NVMe namespaces is that of their
+// controller, and is filled in once the controllers are known.
+func diskTransport(ancestors []string) Transport {
+	for _, dir := range ancestors {
+		if t, ok := transportBySubsystem[deviceSubsystem(dir)]; ok {
+			return t
+		}
+		base := filepath.Base(dir)
+		for _, tr := range transportRegexes {
+			if tr.regex.MatchString(base) {
+				return tr.transport
+			}
+		}
+	}
+	return TRANSPORT_UNKNOWN
+}
+
+// deviceSubsystem returns the name of the subsystem of the supplied sysfs
+// device directory, e.g. "usb", or an empty string
+func deviceSubsystem(dir string) string {
+	dest, err := os.Readlink(filepath.Join(dir, "subsystem"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(dest)
+}
+
+// transportTypes refines the drive type and storage controller guessed from
+// the name of a disk with its transport. virtio-scsi disks are named like
+// any other SCSI disk, and MMC or NVMe disks are always solid-state.
+func transportTypes(t Transport, dt DriveType, sc StorageController) (
+	DriveType,
+	StorageController,
+) {
+	switch t {
+	case TRANSPORT_VIRTIO:
+		sc = STORAGE_CONTROLLER_VIRTIO
+	case TRANSPORT_MMC:
+		dt = DRIVE_TYPE_SSD
+		sc = STORAGE_CONTROLLER_MMC
+	case TRANSPORT_NVME_PCIE, TRANSPORT_NVME_TCP:
+		dt = DRIVE_TYPE_SSD
+		sc = STORAGE_CONTROLLER_NVME
+	case TRANSPORT_ATA, TRANSPORT_SAS, TRANSPORT_USB, TRANSPORT_FC, TRANSPORT_ISCSI:
+		if sc == STORAGE_CONTROLLER_UNKNOWN {
+			sc = STORAGE_CONTROLLER_SCSI
+		}
+	}
+	if dt == DRIVE_TYPE_UNKNOWN && t != TRANSPORT_UNKNOWN {
+		dt = DRIVE_TYPE_HDD
+	}
+	return dt, sc
+}
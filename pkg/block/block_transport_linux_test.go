@@ -0,0 +1,125 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestDiskTransport(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-transport-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		name       string
+		path       string
+		transport  Transport
+		driveType  DriveType
+		controller StorageController
+	}{
+		{
+			"sda",
+			"pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda",
+			TRANSPORT_ATA, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_SCSI,
+		},
+		{
+			"sdb",
+			"pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/host6/target6:0:0/6:0:0:0/block/sdb",
+			TRANSPORT_USB, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_SCSI,
+		},
+		{
+			"sdc",
+			"pci0000:00/0000:00:01.0/0000:01:00.0/host2/port-2:0/end_device-2:0/target2:0:0/2:0:0:0/block/sdc",
+			TRANSPORT_SAS, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_SCSI,
+		},
+		{
+			"sdd",
+			"platform/host3/session1/target3:0:0/3:0:0:1/block/sdd",
+			TRANSPORT_ISCSI, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_SCSI,
+		},
+		{
+			"sde",
+			"pci0000:00/0000:00:02.0/0000:02:00.0/host5/rport-5:0-2/target5:0:0/5:0:0:1/block/sde",
+			TRANSPORT_FC, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_SCSI,
+		},
+		{
+			"sdf",
+			"pci0000:00/0000:00:04.0/virtio1/host0/target0:0:0/0:0:0:0/block/sdf",
+			TRANSPORT_VIRTIO, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_VIRTIO,
+		},
+		{
+			"vda",
+			"pci0000:00/0000:00:05.0/virtio2/block/vda",
+			TRANSPORT_VIRTIO, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_VIRTIO,
+		},
+		{
+			"mmcblk0",
+			"platform/fe340000.mmc/mmc_host/mmc0/mmc0:0001/block/mmcblk0",
+			TRANSPORT_MMC, DRIVE_TYPE_SSD, STORAGE_CONTROLLER_MMC,
+		},
+		{
+			"xvda",
+			"vbd-51712/block/xvda",
+			TRANSPORT_XEN, DRIVE_TYPE_HDD, STORAGE_CONTROLLER_SCSI,
+		},
+		{
+			"zram0",
+			"virtual/block/zram0",
+			TRANSPORT_UNKNOWN, DRIVE_TYPE_UNKNOWN, STORAGE_CONTROLLER_UNKNOWN,
+		},
+	}
+	files := make(map[string]string, len(tests)*2)
+	for _, test := range tests {
+		files[filepath.Join(test.path, "size")] = "2048\n"
+		files[filepath.Join(test.path, "queue", "rotational")] = "1\n"
+	}
+	writeFiles(t, filepath.Join(root, "sys", "devices"), files)
+	if err = os.MkdirAll(filepath.Join(root, "sys", "block"), os.ModePerm); err != nil {
+		t.Fatalf("Unable to create /sys/block: %v", err)
+	}
+	for _, test := range tests {
+		if err = os.Symlink(filepath.Join("..", "devices", test.path), filepath.Join(root, "sys", "block", test.name)); err != nil {
+			t.Fatalf("Unable to create block device link: %v", err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter(), option.WithVirtualBlockDevices())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	byName := make(map[string]*Disk, len(info.Disks))
+	for _, d := range info.Disks {
+		byName[d.Name] = d
+	}
+	for _, test := range tests {
+		d, ok := byName[test.name]
+		if !ok {
+			t.Fatalf("Expected to find disk %s", test.name)
+		}
+		if d.Transport != test.transport || d.DriveType != test.driveType || d.StorageController != test.controller {
+			t.Fatalf(
+				"Expected %s to be a %s %s on %s, but got a %s %s on %s",
+				test.name, test.driveType, test.controller, test.transport,
+				d.DriveType, d.StorageController, d.Transport,
+			)
+		}
+	}
+}
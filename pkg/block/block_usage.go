@@ -0,0 +1,54 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+	"math"
+)
+
+// MountUsage describes how full a mounted filesystem is, as reported by
+// statfs(2) on its mount point
+type MountUsage struct {
+	TotalBytes uint64 `json:"total_bytes"`
+	FreeBytes  uint64 `json:"free_bytes"`
+	// AvailableBytes is the amount of free space available to unprivileged
+	// users, excluding the blocks reserved for root
+	AvailableBytes uint64 `json:"available_bytes"`
+	// UsedPercent is the percentage of the space available to unprivileged
+	// users already in use, rounded up like df does
+	UsedPercent uint64 `json:"used_percent"`
+	TotalInodes uint64 `json:"total_inodes"`
+	FreeInodes  uint64 `json:"free_inodes"`
+	// InodesUsedPercent is 0 for filesystems without a fixed number of
+	// inodes, like btrfs
+	InodesUsedPercent uint64 `json:"inodes_used_percent"`
+}
+
+func newMountUsage(blockSize uint64, blocks uint64, free uint64, avail uint64, inodes uint64, freeInodes uint64) *MountUsage {
+	u := &MountUsage{
+		TotalBytes:     blocks * blockSize,
+		FreeBytes:      free * blockSize,
+		AvailableBytes: avail * blockSize,
+		TotalInodes:    inodes,
+		FreeInodes:     freeInodes,
+	}
+	if free <= blocks {
+		used := blocks - free
+		if used+avail > 0 {
+			u.UsedPercent = uint64(math.Ceil(float64(used) * 100 / float64(used+avail)))
+		}
+	}
+	if inodes > 0 && freeInodes <= inodes {
+		u.InodesUsedPercent = uint64(math.Ceil(float64(inodes-freeInodes) * 100 / float64(inodes)))
+	}
+	return u
+}
+
+func (u *MountUsage) String() string {
+	return fmt.Sprintf("%d%% used", u.UsedPercent)
+}
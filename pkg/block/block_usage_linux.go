@@ -0,0 +1,83 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+	"github.com/jaypipes/ghw/pkg/util"
+)
+
+// mountUsage returns a pointer to a MountUsage struct describing how full the
+// filesystem mounted on the supplied mount point is, or nil if it cannot be
+// found out.
+//
+// statfs(2) is called on the mount point resolved through the context's
+// chroot. Snapshots record the usage of the mounted filesystems at snapshot
+// time instead, since calling statfs(2) on an unpacked snapshot would only
+// describe the filesystem it was unpacked to.
+func mountUsage(ctx *context.Context, paths *linuxpath.Paths, mountPoint string) *MountUsage {
+	if usage, ok := recordedMountUsage(paths, mountPoint); ok {
+		return usage
+	}
+	if ctx.SnapshotPath != "" {
+		return nil
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(filepath.Join(ctx.Chroot, mountPoint), &st); err != nil {
+		ctx.Warn("failed to statfs %s: %s\n", mountPoint, err)
+		return nil
+	}
+	return newMountUsage(
+		uint64(st.Bsize),
+		st.Blocks,
+		st.Bfree,
+		st.Bavail,
+		st.Files,
+		st.Ffree,
+	)
+}
+
+// recordedMountUsage looks up the supplied mount point in the mount usage
+// file recorded by snapshots. The second return value is false when there is
+// no such file, and the mount usage must be found out some other way.
+//
+// Each line of the file describes a mount point, escaped like in
+// /proc/self/mounts, followed by the block size, the total, free and
+// available blocks, and the total and free inodes:
+//
+// /boot 4096 249830 190475 177726 65536 65180
+func recordedMountUsage(paths *linuxpath.Paths, mountPoint string) (*MountUsage, bool) {
+	f, err := os.Open(paths.RunGHWMountUsage)
+	if err != nil {
+		return nil, false
+	}
+	defer util.SafeClose(f)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 7 || unescapeMountPoint(fields[0]) != mountPoint {
+			continue
+		}
+		vals := make([]uint64, 6)
+		for x := range vals {
+			vals[x], err = strconv.ParseUint(fields[x+1], 10, 64)
+			if err != nil {
+				return nil, true
+			}
+		}
+		return newMountUsage(vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]), true
+	}
+	return nil, true
+}
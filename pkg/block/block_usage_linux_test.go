@@ -0,0 +1,87 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestMountUsage(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-usage-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"sys/block/sda/size":           "2048\n",
+		"sys/block/sda/sda1/partition": "1\n",
+		"sys/block/sda/sda1/size":      "1024\n",
+		"sys/block/sda/sda2/partition": "2\n",
+		"sys/block/sda/sda2/size":      "1024\n",
+		"proc/self/mounts": "/dev/sda1 / ext4 rw,relatime 0 0\n" +
+			"/dev/sda2 /srv/my\\040data xfs rw,relatime 0 0\n" +
+			"//server/share /mnt/share cifs rw,relatime 0 0\n",
+	})
+
+	// Without a recorded mount usage, statfs(2) is called on the mount point
+	// within the chroot, but only for the mounts of block devices
+	var warnings bytes.Buffer
+	info, err := New(option.WithChroot(root), option.WithAlerter(log.New(&warnings, "", 0)))
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if strings.Contains(warnings.String(), "/mnt/share") {
+		t.Fatalf("Expected the usage of the CIFS mount not to be looked up, but got %q", warnings.String())
+	}
+	parts := info.Disks[0].Partitions
+	if len(parts) != 2 || parts[0].MountInfo == nil {
+		t.Fatalf("Expected sda1 to be mounted, but got %v", parts)
+	}
+	if u := parts[0].MountInfo.Usage; u == nil || u.TotalBytes == 0 || u.TotalBytes < u.FreeBytes {
+		t.Fatalf("Expected the usage of the filesystem holding %s, but got %+v", root, u)
+	}
+
+	// Snapshots record the mount usage at snapshot time
+	writeFiles(t, root, map[string]string{
+		"run/ghw/mount-usage": "/ 4096 1000 300 250 65536 60000\n" +
+			"/srv/my\\040data 4096 2000 2000 2000 0 0\n",
+	})
+	info, err = New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	parts = info.Disks[0].Partitions
+	expected := MountUsage{
+		TotalBytes:        4096000,
+		FreeBytes:         1228800,
+		AvailableBytes:    1024000,
+		UsedPercent:       74,
+		TotalInodes:       65536,
+		FreeInodes:        60000,
+		InodesUsedPercent: 9,
+	}
+	if u := parts[0].MountInfo.Usage; u == nil || *u != expected {
+		t.Fatalf("Expected sda1 usage %+v, but got %+v", expected, u)
+	}
+	if u := parts[1].MountInfo.Usage; u == nil || u.UsedPercent != 0 || u.InodesUsedPercent != 0 || u.AvailableBytes != 8192000 {
+		t.Fatalf("Expected an empty filesystem on sda2, but got %+v", u)
+	}
+}
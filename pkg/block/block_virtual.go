@@ -0,0 +1,72 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"fmt"
+)
+
+// LoopDevice describes the file backing a loop device
+type LoopDevice struct {
+	BackingFile string `json:"backing_file"`
+	// OffsetBytes is the offset of the device data in the backing file, and
+	// SizeLimitBytes the maximum size of the device, or 0 for the whole file
+	OffsetBytes    uint64 `json:"offset_bytes"`
+	SizeLimitBytes uint64 `json:"size_limit_bytes"`
+	// AutoClear is true when the device is detached from its backing file
+	// once closed for the last time
+	AutoClear bool `json:"autoclear"`
+	// PartScan is true when the kernel scans the device for partitions
+	PartScan bool `json:"partscan"`
+	// DirectIO is true when the backing file is accessed with direct I/O
+	DirectIO bool `json:"dio"`
+}
+
+func (l *LoopDevice) String() string {
+	return fmt.Sprintf("loop backing_file=%s offset=%d", l.BackingFile, l.OffsetBytes)
+}
+
+// ZramDevice describes a compressed RAM block device
+type ZramDevice struct {
+	// CompAlgorithm is the compression algorithm in use, e.g. "zstd", and
+	// AvailableCompAlgorithms lists the algorithms the kernel supports
+	CompAlgorithm           string   `json:"comp_algorithm"`
+	AvailableCompAlgorithms []string `json:"available_comp_algorithms"`
+	// DiskSizeBytes is the uncompressed size of the device
+	DiskSizeBytes uint64 `json:"disk_size_bytes"`
+	// The fields below come from the mm_stat attribute of the device.
+	// OrigDataSizeBytes is the uncompressed size of the data stored on the
+	// device, ComprDataSizeBytes its compressed size and MemUsedTotalBytes
+	// the memory used to store it, including allocator overhead.
+	OrigDataSizeBytes  uint64 `json:"orig_data_size_bytes"`
+	ComprDataSizeBytes uint64 `json:"compr_data_size_bytes"`
+	MemUsedTotalBytes  uint64 `json:"mem_used_total_bytes"`
+	// MemLimitBytes is the maximum memory the device may use, or 0 for no
+	// limit, and MemUsedMaxBytes the maximum memory it ever used
+	MemLimitBytes   uint64 `json:"mem_limit_bytes"`
+	MemUsedMaxBytes uint64 `json:"mem_used_max_bytes"`
+	SamePages       uint64 `json:"same_pages"`
+	PagesCompacted  uint64 `json:"pages_compacted"`
+	HugePages       uint64 `json:"huge_pages"`
+	// IsSwap is true when the device is used as swap space, with the
+	// SwapPriority priority
+	IsSwap       bool `json:"swap"`
+	SwapPriority int  `json:"swap_priority,omitempty"`
+}
+
+func (z *ZramDevice) String() string {
+	swap := ""
+	if z.IsSwap {
+		swap = " swap"
+	}
+	return fmt.Sprintf(
+		"zram comp_algorithm=%s disksize=%d%s",
+		z.CompAlgorithm,
+		z.DiskSizeBytes,
+		swap,
+	)
+}
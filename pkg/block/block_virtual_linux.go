@@ -0,0 +1,122 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package block
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+	"github.com/jaypipes/ghw/pkg/util"
+)
+
+// diskIsVirtual returns whether the supplied block device has no physical
+// backing, like loop, zram, device-mapper or md devices, which sysfs places
+// under /sys/devices/virtual/block
+func diskIsVirtual(paths *linuxpath.Paths, disk string) bool {
+	dest, err := os.Readlink(filepath.Join(paths.SysBlock, disk))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(dest, "devices/virtual/block")
+}
+
+// isVirtualBlockDevice returns whether the supplied block device is a loop,
+// zram or ram device, which are only reported with the
+// WithVirtualBlockDevices option
+func isVirtualBlockDevice(disk string) bool {
+	for _, prefix := range []string{"loop", "zram", "ram"} {
+		if strings.HasPrefix(disk, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// diskLoop returns a pointer to a LoopDevice struct describing the file
+// backing the supplied loop device, or nil if the device is not attached to
+// any file
+func diskLoop(paths *linuxpath.Paths, disk string) *LoopDevice {
+	lpath := filepath.Join(paths.SysBlock, disk, "loop")
+	backingFile := sysfsAttr(lpath, "backing_file")
+	if backingFile == "" {
+		return nil
+	}
+	return &LoopDevice{
+		BackingFile:    backingFile,
+		OffsetBytes:    sysfsUintAttr(lpath, "offset"),
+		SizeLimitBytes: sysfsUintAttr(lpath, "sizelimit"),
+		AutoClear:      sysfsAttr(lpath, "autoclear") == "1",
+		PartScan:       sysfsAttr(lpath, "partscan") == "1",
+		DirectIO:       sysfsAttr(lpath, "dio") == "1",
+	}
+}
+
+// diskZram returns a pointer to a ZramDevice struct describing the supplied
+// zram device. The supplied map holds the swap priority of the devices used
+// as swap space, keyed by device name.
+func diskZram(paths *linuxpath.Paths, disk string, swaps map[string]int) *ZramDevice {
+	zpath := filepath.Join(paths.SysBlock, disk)
+	z := &ZramDevice{
+		DiskSizeBytes: sysfsUintAttr(zpath, "disksize"),
+	}
+	z.CompAlgorithm, z.AvailableCompAlgorithms = parseChoices(sysfsAttr(zpath, "comp_algorithm"))
+	// mm_stat holds, in this order: orig_data_size, compr_data_size,
+	// mem_used_total, mem_limit, mem_used_max, same_pages, pages_compacted
+	// and, since Linux 5.0, huge_pages
+	stats := []*uint64{
+		&z.OrigDataSizeBytes,
+		&z.ComprDataSizeBytes,
+		&z.MemUsedTotalBytes,
+		&z.MemLimitBytes,
+		&z.MemUsedMaxBytes,
+		&z.SamePages,
+		&z.PagesCompacted,
+		&z.HugePages,
+	}
+	for x, field := range strings.Fields(sysfsAttr(zpath, "mm_stat")) {
+		if x >= len(stats) {
+			break
+		}
+		*stats[x], _ = strconv.ParseUint(field, 10, 64)
+	}
+	if prio, ok := swaps[disk]; ok {
+		z.IsSwap = true
+		z.SwapPriority = prio
+	}
+	return z
+}
+
+// swapDevices returns the priority of the block devices used as swap space,
+// keyed by device name, as found in /proc/swaps:
+//
+// Filename                                Type            Size    Used    Priority
+// /dev/zram0                              partition       8388604 0       100
+func swapDevices(paths *linuxpath.Paths) map[string]int {
+	out := make(map[string]int)
+	f, err := os.Open(paths.ProcSwaps)
+	if err != nil {
+		return out
+	}
+	defer util.SafeClose(f)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[1] != "partition" || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		prio, err := strconv.Atoi(fields[4])
+		if err != nil {
+			continue
+		}
+		out[filepath.Base(unescapeMountPoint(fields[0]))] = prio
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestVirtualBlockDevices(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_BLOCK"); ok {
+		t.Skip("Skipping block tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-block-virtual-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// loop1 is not attached to any backing file
+	disks := map[string]string{
+		"loop0": "virtual/block/loop0",
+		"loop1": "virtual/block/loop1",
+		"ram0":  "virtual/block/ram0",
+		"sda":   "pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda",
+		"zram0": "virtual/block/zram0",
+	}
+	files := map[string]string{
+		"virtual/block/loop0/loop/backing_file": "/var/lib/snapd/snaps/core18_2409.snap\n",
+		"virtual/block/loop0/loop/offset":       "4096\n",
+		"virtual/block/loop0/loop/sizelimit":    "0\n",
+		"virtual/block/loop0/loop/autoclear":    "1\n",
+		"virtual/block/loop0/loop/partscan":     "0\n",
+		"virtual/block/loop0/loop/dio":          "1\n",
+		"virtual/block/zram0/comp_algorithm":    "lzo lzo-rle lz4 [zstd]\n",
+		"virtual/block/zram0/disksize":          "8589930496\n",
+		"virtual/block/zram0/mm_stat":           "  217305088 49405813 53993472 0 56971264 6135 0 13\n",
+	}
+	for _, path := range disks {
+		files[filepath.Join(path, "size")] = "2048\n"
+	}
+	writeFiles(t, filepath.Join(root, "sys", "devices"), files)
+	writeFiles(t, root, map[string]string{
+		"proc/swaps": "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n" +
+			"/dev/zram0                              partition\t8388604\t\t212224\t\t100\n",
+	})
+	if err = os.MkdirAll(filepath.Join(root, "sys", "block"), os.ModePerm); err != nil {
+		t.Fatalf("Unable to create /sys/block: %v", err)
+	}
+	for name, path := range disks {
+		if err = os.Symlink(filepath.Join("..", "devices", path), filepath.Join(root, "sys", "block", name)); err != nil {
+			t.Fatalf("Unable to create block device link: %v", err)
+		}
+	}
+
+	// Loop, ram and zram devices are skipped by default
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 1 {
+		t.Fatalf("Expected 1 disk, but got %d", len(info.Disks))
+	}
+	if sda := info.Disks[0]; sda.Name != "sda" || sda.IsVirtual || sda.Loop != nil || sda.Zram != nil {
+		t.Fatalf("Expected sda to be a physical disk, but got %+v", sda)
+	}
+
+	info, err = New(option.WithChroot(root), option.WithNullAlerter(), option.WithVirtualBlockDevices())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Disks) != 4 {
+		t.Fatalf("Expected 4 disks, but got %d", len(info.Disks))
+	}
+	loop := info.Disks[0]
+	if loop.Name != "loop0" || !loop.IsVirtual {
+		t.Fatalf("Expected loop0 to be virtual, but got %+v", loop)
+	}
+	expectedLoop := LoopDevice{
+		BackingFile: "/var/lib/snapd/snaps/core18_2409.snap",
+		OffsetBytes: 4096,
+		AutoClear:   true,
+		DirectIO:    true,
+	}
+	if loop.Loop == nil || *loop.Loop != expectedLoop {
+		t.Fatalf("Expected loop0 %+v, but got %+v", expectedLoop, loop.Loop)
+	}
+	if ram := info.Disks[1]; ram.Name != "ram0" || !ram.IsVirtual || ram.SizeBytes != 2048*sectorSize {
+		t.Fatalf("Expected ram0 to be a virtual disk of 1MiB, but got %+v", ram)
+	}
+
+	zram := info.Disks[3]
+	if zram.Name != "zram0" || !zram.IsVirtual {
+		t.Fatalf("Expected zram0 to be virtual, but got %+v", zram)
+	}
+	expected := &ZramDevice{
+		CompAlgorithm:           "zstd",
+		AvailableCompAlgorithms: []string{"lzo", "lzo-rle", "lz4", "zstd"},
+		DiskSizeBytes:           8589930496,
+		OrigDataSizeBytes:       217305088,
+		ComprDataSizeBytes:      49405813,
+		MemUsedTotalBytes:       53993472,
+		MemUsedMaxBytes:         56971264,
+		SamePages:               6135,
+		HugePages:               13,
+		IsSwap:                  true,
+		SwapPriority:            100,
+	}
+	if !reflect.DeepEqual(zram.Zram, expected) {
+		t.Fatalf("Expected zram0 %+v, but got %+v", expected, zram.Zram)
+	}
+}
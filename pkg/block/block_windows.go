@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/StackExchange/wmi"
+	"github.com/pkg/errors"
 
 	"github.com/jaypipes/ghw/pkg/util"
 )
@@ -218,3 +219,15 @@ func toReadOnly(access uint16) bool {
 	// See Access property from: https://docs.microsoft.com/en-us/windows/win32/cimwin32prov/win32-diskpartition
 	return access == 0x1
 }
+
+func (i *Info) ioStats() (*IOStatsSample, error) {
+	return nil, errors.New("ioStats not implemented on windows")
+}
+
+func (i *Info) loadStack() error {
+	return errors.New("loadStack not implemented on windows")
+}
+
+func (i *Info) resolvePath(chain *PathChain, path string) error {
+	return errors.New("resolvePath not implemented on windows")
+}
@@ -0,0 +1,43 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	btrfsSuperblockOffset = 0x10000
+	btrfsMagic            = "_BHRfS_M"
+	// btrfsDevItemOffset is the offset of the btrfs_dev_item describing the
+	// device the superblock was read from
+	btrfsDevItemOffset = 0xc9
+)
+
+// probeBtrfs looks for the primary btrfs superblock, at 64KiB. The sizes it
+// records are the ones of the whole filesystem, which may span several
+// devices.
+func probeBtrfs(r io.ReaderAt) *Filesystem {
+	sb := readAt(r, btrfsSuperblockOffset, 4096)
+	if sb == nil || string(sb[0x40:0x48]) != btrfsMagic {
+		return nil
+	}
+	sectorSize := uint64(binary.LittleEndian.Uint32(sb[0x90:0x94]))
+	fs := &Filesystem{
+		Type:           "btrfs",
+		UUID:           formatUUID(sb[0x20:0x30]),
+		DeviceUUID:     formatUUID(sb[btrfsDevItemOffset+66 : btrfsDevItemOffset+82]),
+		Label:          cString(sb[0x12b : 0x12b+256]),
+		BlockSizeBytes: sectorSize,
+	}
+	if sectorSize > 0 {
+		fs.TotalBlocks = binary.LittleEndian.Uint64(sb[0x70:0x78]) / sectorSize
+		fs.UsedBlocks = binary.LittleEndian.Uint64(sb[0x78:0x80]) / sectorSize
+	}
+	return fs
+}
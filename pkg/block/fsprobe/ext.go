@@ -0,0 +1,70 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	extSuperblockOffset = 1024
+	extMagic            = 0xef53
+
+	extCompatHasJournal = 0x0004
+
+	extIncompatJournalDev = 0x0008
+	extIncompatExtents    = 0x0040
+	extIncompat64Bit      = 0x0080
+	extIncompatFlexBG     = 0x0200
+
+	extROCompatHugeFile     = 0x0008
+	extROCompatGDTCsum      = 0x0010
+	extROCompatDirNlink     = 0x0020
+	extROCompatExtraIsize   = 0x0040
+	extROCompatMetadataCsum = 0x0400
+)
+
+// probeExt looks for an ext2, ext3 or ext4 superblock. The three are told
+// apart by their feature flags, the same way blkid does: ext4 features win
+// over the ext3 journal, and anything else is ext2.
+func probeExt(r io.ReaderAt) *Filesystem {
+	sb := readAt(r, extSuperblockOffset, 1024)
+	if sb == nil || binary.LittleEndian.Uint16(sb[56:58]) != extMagic {
+		return nil
+	}
+	compat := binary.LittleEndian.Uint32(sb[92:96])
+	incompat := binary.LittleEndian.Uint32(sb[96:100])
+	roCompat := binary.LittleEndian.Uint32(sb[100:104])
+	if incompat&extIncompatJournalDev != 0 {
+		// An external journal, not a filesystem
+		return nil
+	}
+
+	fsType := "ext2"
+	if incompat&(extIncompatExtents|extIncompat64Bit|extIncompatFlexBG) != 0 ||
+		roCompat&(extROCompatHugeFile|extROCompatGDTCsum|extROCompatDirNlink|extROCompatExtraIsize|extROCompatMetadataCsum) != 0 {
+		fsType = "ext4"
+	} else if compat&extCompatHasJournal != 0 {
+		fsType = "ext3"
+	}
+
+	blocks := uint64(binary.LittleEndian.Uint32(sb[4:8]))
+	free := uint64(binary.LittleEndian.Uint32(sb[12:16]))
+	if incompat&extIncompat64Bit != 0 {
+		blocks |= uint64(binary.LittleEndian.Uint32(sb[336:340])) << 32
+		free |= uint64(binary.LittleEndian.Uint32(sb[344:348])) << 32
+	}
+	return &Filesystem{
+		Type:           fsType,
+		UUID:           formatUUID(sb[104:120]),
+		Label:          cString(sb[120:136]),
+		BlockSizeBytes: 1024 << binary.LittleEndian.Uint32(sb[24:28]),
+		TotalBlocks:    blocks,
+		UsedBlocks:     blocks - free,
+	}
+}
@@ -0,0 +1,60 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// probeFAT looks for a FAT12, FAT16 or FAT32 boot sector. The volume serial
+// number is reported as the UUID, formatted as "XXXX-XXXX" like blkid does.
+// The number of used clusters is not recorded in the boot sector.
+func probeFAT(r io.ReaderAt) *Filesystem {
+	bs := readAt(r, 0, 512)
+	if bs == nil || bs[510] != 0x55 || bs[511] != 0xaa {
+		return nil
+	}
+	if bs[0] != 0xeb && bs[0] != 0xe9 {
+		return nil
+	}
+	// The extended BIOS parameter block sits at a different offset on FAT32
+	var ebpb []byte
+	if string(bs[82:87]) == "FAT32" {
+		ebpb = bs[64:90]
+	} else if string(bs[54:57]) == "FAT" {
+		ebpb = bs[36:62]
+	} else {
+		return nil
+	}
+	bytesPerSector := uint64(binary.LittleEndian.Uint16(bs[11:13]))
+	sectorsPerCluster := uint64(bs[13])
+	if bytesPerSector == 0 || sectorsPerCluster == 0 {
+		return nil
+	}
+
+	fs := &Filesystem{
+		Type:           "vfat",
+		BlockSizeBytes: bytesPerSector * sectorsPerCluster,
+	}
+	// ebpb[2] is the extended boot signature. The serial number and label
+	// are only valid when it is 0x29.
+	if ebpb[2] == 0x29 {
+		serial := binary.LittleEndian.Uint32(ebpb[3:7])
+		fs.UUID = fmt.Sprintf("%04X-%04X", serial>>16, serial&0xffff)
+		if label := cString(ebpb[7:18]); label != "NO NAME" {
+			fs.Label = label
+		}
+	}
+	sectors := uint64(binary.LittleEndian.Uint16(bs[19:21]))
+	if sectors == 0 {
+		sectors = uint64(binary.LittleEndian.Uint32(bs[32:36]))
+	}
+	fs.TotalBlocks = sectors / sectorsPerCluster
+	return fs
+}
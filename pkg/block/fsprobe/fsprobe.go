@@ -0,0 +1,136 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package fsprobe identifies the filesystem (or other well-known on-disk
+// format, like LUKS or LVM2 physical volumes) found on a block device or disk
+// image, by reading its superblock. It does not rely on any external binary.
+package fsprobe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	// ErrUnknownFilesystem is returned when none of the supported signatures
+	// could be found
+	ErrUnknownFilesystem = errors.New("unknown filesystem")
+)
+
+// Filesystem describes the filesystem, or other on-disk format, found on a
+// block device
+type Filesystem struct {
+	// Type is the type of the filesystem, using the names blkid uses: "ext2",
+	// "ext3", "ext4", "xfs", "btrfs", "vfat", "ntfs", "swap", "crypto_LUKS",
+	// "LVM2_member", "zfs_member" or "iso9660"
+	Type string `json:"type"`
+	// UUID is the UUID of the filesystem, formatted the same way blkid does.
+	// Note that FAT and NTFS volumes have short serial numbers instead, and
+	// ISO9660 volumes use their creation date.
+	UUID string `json:"uuid"`
+	// DeviceUUID is the UUID of the device within a filesystem spanning
+	// multiple devices (btrfs), or the GUID of the vdev of a ZFS pool member.
+	// It is empty for other filesystems.
+	DeviceUUID string `json:"device_uuid,omitempty"`
+	Label      string `json:"label"`
+	// VdevGUID and VdevType are the GUID and the type of the top-level vdev
+	// holding a ZFS pool member: "mirror", "raidz1", "raidz2", "raidz3" or
+	// "draid", or "disk" or "file" when the member is a top-level vdev of
+	// its own. They are empty for other filesystems.
+	VdevGUID string `json:"vdev_guid,omitempty"`
+	VdevType string `json:"vdev_type,omitempty"`
+	// BlockSizeBytes is the size of the allocation unit of the filesystem, in
+	// bytes. TotalBlocks and UsedBlocks are expressed in this unit. All three
+	// are zero when the on-disk format does not record them.
+	BlockSizeBytes uint64 `json:"block_size_bytes,omitempty"`
+	TotalBlocks    uint64 `json:"total_blocks,omitempty"`
+	UsedBlocks     uint64 `json:"used_blocks,omitempty"`
+}
+
+func (fs *Filesystem) String() string {
+	label := ""
+	if fs.Label != "" {
+		label = fmt.Sprintf(" label=%q", fs.Label)
+	}
+	return fmt.Sprintf("%s uuid=%s%s", fs.Type, fs.UUID, label)
+}
+
+// prober returns a pointer to a Filesystem struct if the supplied reader
+// contains the format it knows about, or nil
+type prober func(r io.ReaderAt) *Filesystem
+
+// NOTE: the order matters. Containers like LUKS and LVM2 come first, since
+// their payload may contain stale filesystem signatures. Filesystems with a
+// signature at offset 0 (xfs) come before the ones with a signature further
+// away, which old filesystems may have left behind.
+var probers = []prober{
+	probeLUKS,
+	probeLVM2,
+	probeXFS,
+	probeExt,
+	probeBtrfs,
+	probeZFS,
+	probeISO9660,
+	probeNTFS,
+	probeFAT,
+	probeSwap,
+}
+
+// Probe returns a pointer to a Filesystem struct describing the filesystem
+// found in r, or ErrUnknownFilesystem
+func Probe(r io.ReaderAt) (*Filesystem, error) {
+	for _, p := range probers {
+		if fs := p(r); fs != nil {
+			return fs, nil
+		}
+	}
+	return nil, ErrUnknownFilesystem
+}
+
+// ProbeFile returns a pointer to a Filesystem struct describing the filesystem
+// found in the supplied block device node or disk image file
+func ProbeFile(path string) (*Filesystem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Probe(f)
+}
+
+// readAt returns the n bytes found at offset off, or nil if they cannot be
+// read, e.g. because the device is too small
+func readAt(r io.ReaderAt, off int64, n int) []byte {
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, off); err != nil {
+		return nil
+	}
+	return buf
+}
+
+// formatUUID returns the canonical, lowercase string representation of a
+// UUID stored as 16 big-endian bytes
+func formatUUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// cString returns the string stored in a NUL-padded fixed-size field, with
+// trailing spaces removed
+func cString(b []byte) string {
+	for x, c := range b {
+		if c == 0 {
+			b = b[:x]
+			break
+		}
+	}
+	end := len(b)
+	for end > 0 && b[end-1] == ' ' {
+		end--
+	}
+	return string(b[:end])
+}
@@ -0,0 +1,341 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/block/fsprobe"
+)
+
+const (
+	testImageSize = 1 << 20
+	testUUID      = "3f1c2a9e-5b7d-4e08-9a61-c2d4e6f8a0b1"
+)
+
+func testUUIDBytes() []byte {
+	b, _ := hex.DecodeString("3f1c2a9e5b7d4e089a61c2d4e6f8a0b1")
+	return b
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(img []byte)
+		expected fsprobe.Filesystem
+	}{
+		{
+			name: "ext4",
+			build: func(img []byte) {
+				sb := img[1024:]
+				binary.LittleEndian.PutUint32(sb[4:], 8192)
+				binary.LittleEndian.PutUint32(sb[12:], 6574)
+				binary.LittleEndian.PutUint32(sb[24:], 2)
+				binary.LittleEndian.PutUint16(sb[56:], 0xef53)
+				binary.LittleEndian.PutUint32(sb[92:], 0x4)
+				binary.LittleEndian.PutUint32(sb[96:], 0x2c2)
+				copy(sb[104:], testUUIDBytes())
+				copy(sb[120:], "rootfs")
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "ext4",
+				UUID:           testUUID,
+				Label:          "rootfs",
+				BlockSizeBytes: 4096,
+				TotalBlocks:    8192,
+				UsedBlocks:     1618,
+			},
+		},
+		{
+			name: "ext3",
+			build: func(img []byte) {
+				sb := img[1024:]
+				binary.LittleEndian.PutUint16(sb[56:], 0xef53)
+				binary.LittleEndian.PutUint32(sb[92:], 0x4)
+				copy(sb[104:], testUUIDBytes())
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "ext3",
+				UUID:           testUUID,
+				BlockSizeBytes: 1024,
+			},
+		},
+		{
+			name: "xfs",
+			build: func(img []byte) {
+				copy(img[0:], "XFSB")
+				binary.BigEndian.PutUint32(img[4:], 4096)
+				binary.BigEndian.PutUint64(img[8:], 262144)
+				copy(img[32:], testUUIDBytes())
+				copy(img[108:], "data")
+				binary.BigEndian.PutUint64(img[144:], 262000)
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "xfs",
+				UUID:           testUUID,
+				Label:          "data",
+				BlockSizeBytes: 4096,
+				TotalBlocks:    262144,
+				UsedBlocks:     144,
+			},
+		},
+		{
+			name: "btrfs",
+			build: func(img []byte) {
+				sb := img[0x10000:]
+				copy(sb[0x20:], testUUIDBytes())
+				copy(sb[0x40:], "_BHRfS_M")
+				binary.LittleEndian.PutUint64(sb[0x70:], 1<<30)
+				binary.LittleEndian.PutUint64(sb[0x78:], 1<<20)
+				binary.LittleEndian.PutUint32(sb[0x90:], 4096)
+				devUUID := testUUIDBytes()
+				devUUID[0] = 0xaa
+				copy(sb[0xc9+66:], devUUID)
+				copy(sb[0x12b:], "pool")
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "btrfs",
+				UUID:           testUUID,
+				DeviceUUID:     "aa1c2a9e-5b7d-4e08-9a61-c2d4e6f8a0b1",
+				Label:          "pool",
+				BlockSizeBytes: 4096,
+				TotalBlocks:    262144,
+				UsedBlocks:     256,
+			},
+		},
+		{
+			name: "zfs_member",
+			build: func(img []byte) {
+				nvl := img[16<<10:]
+				nvl[0] = 1 // XDR encoding
+				pairs := [][]byte{
+					xdrPair("version", 8, xdrUint64(5000)),
+					xdrPair("name", 9, xdrString("tank")),
+					xdrPair("pool_guid", 8, xdrUint64(9507823112334467211)),
+					xdrPair("vdev_tree", 19, xdrNVList(
+						xdrPair("type", 9, xdrString("raidz")),
+						xdrPair("guid", 8, xdrUint64(2214916324137531262)),
+						xdrPair("nparity", 8, xdrUint64(2)),
+						xdrPair("children", 20, make([]byte, 64)),
+					)),
+					xdrPair("guid", 8, xdrUint64(15425631925519313409)),
+				}
+				off := 4 + 8
+				for _, pair := range pairs {
+					off += copy(nvl[off:], pair)
+				}
+			},
+			expected: fsprobe.Filesystem{
+				Type:       "zfs_member",
+				UUID:       "9507823112334467211",
+				DeviceUUID: "15425631925519313409",
+				Label:      "tank",
+				VdevGUID:   "2214916324137531262",
+				VdevType:   "raidz2",
+			},
+		},
+		{
+			name: "vfat",
+			build: func(img []byte) {
+				img[0] = 0xeb
+				binary.LittleEndian.PutUint16(img[11:], 512)
+				img[13] = 8
+				binary.LittleEndian.PutUint32(img[32:], 1048576)
+				img[66] = 0x29
+				binary.LittleEndian.PutUint32(img[67:], 0x1a2b3c4d)
+				copy(img[71:], "EFI        ")
+				copy(img[82:], "FAT32   ")
+				img[510], img[511] = 0x55, 0xaa
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "vfat",
+				UUID:           "1A2B-3C4D",
+				Label:          "EFI",
+				BlockSizeBytes: 4096,
+				TotalBlocks:    131072,
+			},
+		},
+		{
+			name: "ntfs",
+			build: func(img []byte) {
+				copy(img[3:], "NTFS    ")
+				binary.LittleEndian.PutUint16(img[11:], 512)
+				img[13] = 8
+				binary.LittleEndian.PutUint64(img[40:], 2097151)
+				binary.LittleEndian.PutUint64(img[72:], 0x5e2a1c3b4d6f7081)
+				img[510], img[511] = 0x55, 0xaa
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "ntfs",
+				UUID:           "5E2A1C3B4D6F7081",
+				BlockSizeBytes: 4096,
+				TotalBlocks:    262143,
+			},
+		},
+		{
+			name: "swap",
+			build: func(img []byte) {
+				binary.LittleEndian.PutUint32(img[1024+4:], 255)
+				copy(img[1024+12:], testUUIDBytes())
+				copy(img[1024+28:], "swap0")
+				copy(img[4096-10:], "SWAPSPACE2")
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "swap",
+				UUID:           testUUID,
+				Label:          "swap0",
+				BlockSizeBytes: 4096,
+				TotalBlocks:    256,
+			},
+		},
+		{
+			name: "luks2",
+			build: func(img []byte) {
+				copy(img[0:], "LUKS\xba\xbe\x00\x02")
+				copy(img[24:], "secret")
+				copy(img[168:], testUUID)
+			},
+			expected: fsprobe.Filesystem{
+				Type:  "crypto_LUKS",
+				UUID:  testUUID,
+				Label: "secret",
+			},
+		},
+		{
+			name: "lvm2",
+			build: func(img []byte) {
+				label := img[512:]
+				copy(label[0:], "LABELONE")
+				binary.LittleEndian.PutUint64(label[8:], 1)
+				binary.LittleEndian.PutUint32(label[20:], 32)
+				copy(label[24:], "LVM2 001")
+				copy(label[32:], "Wb5Hs3yTqLa7AEc0mJ2yR9kfXz1NpVd4")
+			},
+			expected: fsprobe.Filesystem{
+				Type: "LVM2_member",
+				UUID: "Wb5Hs3-yTqL-a7AE-c0mJ-2yR9-kfXz-1NpVd4",
+			},
+		},
+		{
+			name: "iso9660",
+			build: func(img []byte) {
+				pvd := img[0x8000:]
+				pvd[0] = 1
+				copy(pvd[1:], "CD001")
+				copy(pvd[40:], "INSTALL_MEDIA                   ")
+				binary.LittleEndian.PutUint32(pvd[80:], 300)
+				binary.LittleEndian.PutUint16(pvd[128:], 2048)
+				copy(pvd[813:], "2020111213141500")
+			},
+			expected: fsprobe.Filesystem{
+				Type:           "iso9660",
+				UUID:           "2020-11-12-13-14-15-00",
+				Label:          "INSTALL_MEDIA",
+				BlockSizeBytes: 2048,
+				TotalBlocks:    300,
+				UsedBlocks:     300,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		img := make([]byte, testImageSize)
+		test.build(img)
+		fs, err := fsprobe.Probe(bytes.NewReader(img))
+		if err != nil {
+			t.Fatalf("For %s, expected nil error, but got %v", test.name, err)
+		}
+		if *fs != test.expected {
+			t.Fatalf("For %s, expected %+v, but got %+v", test.name, test.expected, *fs)
+		}
+	}
+}
+
+func TestProbeUnknown(t *testing.T) {
+	img := make([]byte, testImageSize)
+	if _, err := fsprobe.Probe(bytes.NewReader(img)); err != fsprobe.ErrUnknownFilesystem {
+		t.Fatalf("Expected ErrUnknownFilesystem, but got %v", err)
+	}
+	// Too small to hold any superblock
+	if _, err := fsprobe.Probe(bytes.NewReader(img[:100])); err != fsprobe.ErrUnknownFilesystem {
+		t.Fatalf("Expected ErrUnknownFilesystem, but got %v", err)
+	}
+}
+
+func TestProbeZFSCorrupt(t *testing.T) {
+	// A pair of 0xffffffff bytes, and a string of 0xffffffff bytes, must not
+	// be read past the end of the label, even on 32-bit platforms
+	hugePair := xdrPair("guid", 8, xdrUint64(15425631925519313409))
+	binary.BigEndian.PutUint32(hugePair, 0xffffffff)
+	hugeString := xdrPair("name", 9, xdrString("tank"))
+	binary.BigEndian.PutUint32(hugeString[8+8+8:], 0xffffffff)
+	for _, corrupt := range [][]byte{hugePair, hugeString} {
+		img := make([]byte, testImageSize)
+		nvl := img[16<<10:]
+		nvl[0] = 1 // XDR encoding
+		off := 4 + 8
+		off += copy(nvl[off:], xdrPair("version", 8, xdrUint64(5000)))
+		copy(nvl[off:], corrupt)
+		if _, err := fsprobe.Probe(bytes.NewReader(img)); err != fsprobe.ErrUnknownFilesystem {
+			t.Fatalf("Expected ErrUnknownFilesystem, but got %v", err)
+		}
+	}
+}
+
+func TestProbeLVM2Corrupt(t *testing.T) {
+	// A physical volume header offset of 0xffffffff must not be read past the
+	// end of the label, even on 32-bit platforms
+	img := make([]byte, testImageSize)
+	label := img[512:]
+	copy(label[0:], "LABELONE")
+	binary.LittleEndian.PutUint64(label[8:], 1)
+	binary.LittleEndian.PutUint32(label[20:], 0xffffffff)
+	copy(label[24:], "LVM2 001")
+	if _, err := fsprobe.Probe(bytes.NewReader(img)); err != fsprobe.ErrUnknownFilesystem {
+		t.Fatalf("Expected ErrUnknownFilesystem, but got %v", err)
+	}
+}
+
+// xdrPair encodes a name/value pair of a ZFS nvlist: its encoded and decoded
+// sizes, name, type, number of elements and value
+func xdrPair(name string, dataType uint32, value []byte) []byte {
+	body := xdrString(name)
+	body = append(body, make([]byte, 8)...)
+	binary.BigEndian.PutUint32(body[len(body)-8:], dataType)
+	binary.BigEndian.PutUint32(body[len(body)-4:], 1)
+	body = append(body, value...)
+	pair := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(pair[0:], uint32(8+len(body)))
+	binary.BigEndian.PutUint32(pair[4:], uint32(8+len(body)))
+	return append(pair, body...)
+}
+
+// xdrNVList encodes an nvlist nested in a pair: its version and flags, its
+// pairs and the empty pair ending it
+func xdrNVList(pairs ...[]byte) []byte {
+	nvl := make([]byte, 8)
+	for _, pair := range pairs {
+		nvl = append(nvl, pair...)
+	}
+	return append(nvl, make([]byte, 8)...)
+}
+
+func xdrString(s string) []byte {
+	b := make([]byte, 4+(len(s)+3)&^3)
+	binary.BigEndian.PutUint32(b, uint32(len(s)))
+	copy(b[4:], s)
+	return b
+}
+
+func xdrUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
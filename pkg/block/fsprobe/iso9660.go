@@ -0,0 +1,51 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	isoPrimaryVolumeDescriptorOffset = 0x8000
+	isoStandardID                    = "CD001"
+)
+
+// probeISO9660 looks for the primary volume descriptor of an ISO9660 image.
+// ISO9660 has no UUID. Like blkid, the volume creation date is reported
+// instead, formatted as "YYYY-MM-DD-HH-MM-SS-CC".
+func probeISO9660(r io.ReaderAt) *Filesystem {
+	pvd := readAt(r, isoPrimaryVolumeDescriptorOffset, 2048)
+	if pvd == nil || pvd[0] != 1 || string(pvd[1:6]) != isoStandardID {
+		return nil
+	}
+	fs := &Filesystem{
+		Type:  "iso9660",
+		Label: cString(pvd[40:72]),
+		// Both-endian fields, only the little-endian half is used
+		BlockSizeBytes: uint64(binary.LittleEndian.Uint16(pvd[128:130])),
+		TotalBlocks:    uint64(binary.LittleEndian.Uint32(pvd[80:84])),
+	}
+	// A read-only filesystem is always full
+	fs.UsedBlocks = fs.TotalBlocks
+	date := pvd[813:829]
+	if isDigits(date) && string(date) != "0000000000000000" {
+		d := string(date)
+		fs.UUID = d[0:4] + "-" + d[4:6] + "-" + d[6:8] + "-" + d[8:10] + "-" + d[10:12] + "-" + d[12:14] + "-" + d[14:16]
+	}
+	return fs
+}
+
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
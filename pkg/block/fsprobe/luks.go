@@ -0,0 +1,32 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"io"
+)
+
+const (
+	luksMagic = "LUKS\xba\xbe"
+)
+
+// probeLUKS looks for a LUKS1 or LUKS2 header. Both versions store the UUID
+// as a string at the same offset. Only LUKS2 headers have a label.
+func probeLUKS(r io.ReaderAt) *Filesystem {
+	hdr := readAt(r, 0, 512)
+	if hdr == nil || string(hdr[0:6]) != luksMagic {
+		return nil
+	}
+	fs := &Filesystem{
+		Type: "crypto_LUKS",
+		UUID: cString(hdr[168:208]),
+	}
+	if hdr[6] == 0 && hdr[7] == 2 {
+		fs.Label = cString(hdr[24:72])
+	}
+	return fs
+}
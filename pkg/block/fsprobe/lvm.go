@@ -0,0 +1,46 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	lvm2LabelID   = "LABELONE"
+	lvm2LabelType = "LVM2 001"
+	// lvm2LabelScanSectors is the number of 512-byte sectors at the start of
+	// the device the LVM2 label may be written to
+	lvm2LabelScanSectors = 4
+)
+
+// probeLVM2 looks for an LVM2 physical volume label. The UUID of the physical
+// volume is stored as 32 characters, which are reported with dashes in the
+// same 6-4-4-4-4-4-6 layout the LVM tools and blkid use.
+func probeLVM2(r io.ReaderAt) *Filesystem {
+	for sector := int64(0); sector < lvm2LabelScanSectors; sector++ {
+		label := readAt(r, sector*512, 512)
+		if label == nil || string(label[0:8]) != lvm2LabelID || string(label[24:32]) != lvm2LabelType {
+			continue
+		}
+		// The physical volume header follows the label header, at the offset
+		// recorded in the label header. The offset is compared before its
+		// conversion, which would turn the largest ones negative on 32-bit
+		// platforms.
+		offset := binary.LittleEndian.Uint32(label[20:24])
+		if offset > uint32(len(label)-32) {
+			return nil
+		}
+		raw := string(label[offset : offset+32])
+		return &Filesystem{
+			Type: "LVM2_member",
+			UUID: raw[0:6] + "-" + raw[6:10] + "-" + raw[10:14] + "-" + raw[14:18] + "-" + raw[18:22] + "-" + raw[22:26] + "-" + raw[26:32],
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	ntfsOEMID = "NTFS    "
+)
+
+// probeNTFS looks for an NTFS boot sector. The 64-bit volume serial number is
+// reported as the UUID. The volume label is stored in the $Volume file of the
+// MFT, which is not parsed, so Label is always empty.
+func probeNTFS(r io.ReaderAt) *Filesystem {
+	bs := readAt(r, 0, 512)
+	if bs == nil || string(bs[3:11]) != ntfsOEMID {
+		return nil
+	}
+	bytesPerSector := uint64(binary.LittleEndian.Uint16(bs[11:13]))
+	sectorsPerCluster := uint64(bs[13])
+	if sectorsPerCluster > 0x80 {
+		// Values above 0x80 encode a negative power of two
+		sectorsPerCluster = 1 << (256 - sectorsPerCluster)
+	}
+	if bytesPerSector == 0 || sectorsPerCluster == 0 {
+		return nil
+	}
+	return &Filesystem{
+		Type:           "ntfs",
+		UUID:           fmt.Sprintf("%016X", binary.LittleEndian.Uint64(bs[72:80])),
+		BlockSizeBytes: bytesPerSector * sectorsPerCluster,
+		TotalBlocks:    binary.LittleEndian.Uint64(bs[40:48]) / sectorsPerCluster,
+	}
+}
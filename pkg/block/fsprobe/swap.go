@@ -0,0 +1,40 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// swapPageSizes are the page sizes a swap area may have been created with.
+// The swap signature is found in the last 10 bytes of the first page.
+var swapPageSizes = []int64{4096, 8192, 16384, 65536}
+
+// probeSwap looks for a Linux swap area. The UUID and label are only
+// available in the version 1 header written by mkswap since util-linux 2.15.
+func probeSwap(r io.ReaderAt) *Filesystem {
+	for _, pageSize := range swapPageSizes {
+		sig := readAt(r, pageSize-10, 10)
+		if sig == nil || (string(sig) != "SWAPSPACE2" && string(sig) != "SWAP-SPACE") {
+			continue
+		}
+		fs := &Filesystem{
+			Type:           "swap",
+			BlockSizeBytes: uint64(pageSize),
+		}
+		hdr := readAt(r, 1024, 44)
+		if string(sig) == "SWAPSPACE2" && hdr != nil {
+			// last_page is the index of the last usable page
+			fs.TotalBlocks = uint64(binary.LittleEndian.Uint32(hdr[4:8])) + 1
+			fs.UUID = formatUUID(hdr[12:28])
+			fs.Label = cString(hdr[28:44])
+		}
+		return fs
+	}
+	return nil
+}
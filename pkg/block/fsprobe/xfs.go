@@ -0,0 +1,35 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	xfsMagic = "XFSB"
+)
+
+// probeXFS looks for an XFS superblock, which is stored big-endian at the
+// very beginning of the device
+func probeXFS(r io.ReaderAt) *Filesystem {
+	sb := readAt(r, 0, 512)
+	if sb == nil || string(sb[0:4]) != xfsMagic {
+		return nil
+	}
+	blocks := binary.BigEndian.Uint64(sb[8:16])
+	free := binary.BigEndian.Uint64(sb[144:152])
+	return &Filesystem{
+		Type:           "xfs",
+		UUID:           formatUUID(sb[32:48]),
+		Label:          cString(sb[108:120]),
+		BlockSizeBytes: uint64(binary.BigEndian.Uint32(sb[4:8])),
+		TotalBlocks:    blocks,
+		UsedBlocks:     blocks - free,
+	}
+}
@@ -0,0 +1,120 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package fsprobe
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+)
+
+const (
+	// zfsNVListOffset is the offset of the name/value pair list in the first
+	// vdev label, found at the start of each device of a pool
+	zfsNVListOffset = 16 << 10
+	zfsNVListSize   = 112 << 10
+	zfsEncodingXDR  = 1
+	zfsTypeUint64   = 8
+	zfsTypeString   = 9
+	zfsTypeNVList   = 19
+)
+
+// probeZFS looks for the name/value pair list of the first vdev label of a
+// ZFS pool member. Like blkid, it reports the pool name as the label, the
+// pool GUID as the UUID and the vdev GUID as the device UUID. The top-level
+// vdev holding the device is described by the nested vdev_tree list.
+func probeZFS(r io.ReaderAt) *Filesystem {
+	nvl := readAt(r, zfsNVListOffset, zfsNVListSize)
+	if nvl == nil || nvl[0] != zfsEncodingXDR {
+		return nil
+	}
+	strs, uints, lists := zfsNVPairs(nvl[4:])
+	guid, ok := uints["guid"]
+	if !ok {
+		return nil
+	}
+	if _, ok := uints["version"]; !ok {
+		return nil
+	}
+	fs := &Filesystem{
+		Type:       "zfs_member",
+		DeviceUUID: strconv.FormatUint(guid, 10),
+		Label:      strs["name"],
+	}
+	if poolGUID, ok := uints["pool_guid"]; ok {
+		fs.UUID = strconv.FormatUint(poolGUID, 10)
+	}
+	if tree, ok := lists["vdev_tree"]; ok {
+		vdevStrs, vdevUints, _ := zfsNVPairs(tree)
+		if vdevGUID, ok := vdevUints["guid"]; ok {
+			fs.VdevGUID = strconv.FormatUint(vdevGUID, 10)
+		}
+		// zpool names raidz vdevs after their parity, e.g. raidz2
+		fs.VdevType = vdevStrs["type"]
+		if nparity, ok := vdevUints["nparity"]; ok && fs.VdevType == "raidz" {
+			fs.VdevType += strconv.FormatUint(nparity, 10)
+		}
+	}
+	return fs
+}
+
+// zfsNVPairs decodes the string and uint64 pairs of an XDR-encoded nvlist,
+// and returns the encoded nvlists nested in it, e.g. vdev_tree, to be decoded
+// the same way. Pairs of any other type are skipped. The list starts with its
+// version and flags, followed by the pairs, each one starting with its
+// encoded size, which includes the nested lists, and ends with an empty pair.
+func zfsNVPairs(b []byte) (map[string]string, map[string]uint64, map[string][]byte) {
+	strs := make(map[string]string)
+	uints := make(map[string]uint64)
+	lists := make(map[string][]byte)
+	off := 8
+	for off+8 <= len(b) {
+		// The sizes are compared before their conversion, which would turn
+		// the largest ones negative on 32-bit platforms
+		size := binary.BigEndian.Uint32(b[off:])
+		if size < 8 || uint32(len(b)-off) < size {
+			break
+		}
+		pair := b[off : off+int(size)]
+		off += int(size)
+
+		name, p, ok := xdrString(pair, 8)
+		if !ok || p+8 > len(pair) {
+			continue
+		}
+		dataType := binary.BigEndian.Uint32(pair[p:])
+		p += 8 // type and number of elements
+		switch dataType {
+		case zfsTypeUint64:
+			if p+8 <= len(pair) {
+				uints[name] = binary.BigEndian.Uint64(pair[p:])
+			}
+		case zfsTypeString:
+			if s, _, ok := xdrString(pair, p); ok {
+				strs[name] = s
+			}
+		case zfsTypeNVList:
+			lists[name] = pair[p:]
+		}
+	}
+	return strs, uints, lists
+}
+
+// xdrString decodes the XDR string found at offset off of b: its length,
+// followed by its bytes padded to a multiple of 4. It returns the string and
+// the offset following it.
+func xdrString(b []byte, off int) (string, int, bool) {
+	if off+4 > len(b) {
+		return "", 0, false
+	}
+	n := binary.BigEndian.Uint32(b[off:])
+	off += 4
+	if uint32(len(b)-off) < n {
+		return "", 0, false
+	}
+	return string(b[off : off+int(n)]), off + (int(n)+3)&^3, true
+}
@@ -0,0 +1,188 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package parttable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	gptSignature         = "EFI PART"
+	gptMinHeaderSize     = 92
+	gptMinEntrySize      = 128
+	gptMaxEntries        = 1024
+	gptPartitionNameSize = 72
+)
+
+// gptHeader holds the fields of a GPT header we care about. See the UEFI
+// specification, section 5.3.2 "GPT Header".
+type gptHeader struct {
+	currentLBA     uint64
+	alternateLBA   uint64
+	firstUsableLBA uint64
+	lastUsableLBA  uint64
+	diskGUID       string
+	entriesLBA     uint64
+	numEntries     uint32
+	entrySize      uint32
+	entriesCRC     uint32
+}
+
+func hasGPTSignature(r io.ReaderAt, sectorSize int) bool {
+	sig := make([]byte, len(gptSignature))
+	if _, err := r.ReadAt(sig, int64(sectorSize)); err != nil {
+		return false
+	}
+	return string(sig) == gptSignature
+}
+
+// readGPT reads the primary GPT header from LBA 1 and the backup GPT header
+// from the last LBA of the disk. The partition entries are taken from the
+// primary copy when it is valid, and from the backup copy otherwise.
+func readGPT(r io.ReaderAt, sectorSize int) (*Table, error) {
+	primary, primaryEntries, primaryErr := readGPTCopy(r, sectorSize, 1)
+
+	var backupLBA uint64
+	if primaryErr == nil {
+		backupLBA = primary.alternateLBA
+	} else if size := readerSize(r); size > 0 {
+		backupLBA = uint64(size/int64(sectorSize)) - 1
+	}
+	var backup *gptHeader
+	var backupEntries []byte
+	backupErr := ErrInvalidGPT
+	if backupLBA > 1 {
+		backup, backupEntries, backupErr = readGPTCopy(r, sectorSize, backupLBA)
+	}
+
+	hdr, entries := primary, primaryEntries
+	if primaryErr != nil {
+		if backupErr != nil {
+			return nil, ErrInvalidGPT
+		}
+		hdr, entries = backup, backupEntries
+	}
+
+	t := &Table{
+		Type:               TABLE_TYPE_GPT,
+		SectorSizeBytes:    sectorSize,
+		DiskID:             hdr.diskGUID,
+		PrimaryHeaderValid: primaryErr == nil,
+		BackupHeaderValid:  backupErr == nil,
+		FirstUsableLBA:     hdr.firstUsableLBA,
+		LastUsableLBA:      hdr.lastUsableLBA,
+		Partitions:         make([]*Partition, 0),
+	}
+	for x := 0; x < int(hdr.numEntries); x++ {
+		entry := entries[x*int(hdr.entrySize) : (x+1)*int(hdr.entrySize)]
+		typeGUID := formatGUID(entry[0:16])
+		if typeGUID == gptUnusedTypeGUID {
+			continue
+		}
+		t.Partitions = append(t.Partitions, &Partition{
+			Number:     x + 1,
+			TypeID:     typeGUID,
+			TypeName:   gptTypeName(typeGUID),
+			UUID:       formatGUID(entry[16:32]),
+			FirstLBA:   binary.LittleEndian.Uint64(entry[32:40]),
+			LastLBA:    binary.LittleEndian.Uint64(entry[40:48]),
+			Attributes: binary.LittleEndian.Uint64(entry[48:56]),
+			Name:       decodeUTF16(entry[56 : 56+gptPartitionNameSize]),
+		})
+	}
+	return t, nil
+}
+
+// readGPTCopy reads and validates the GPT header found at the supplied LBA,
+// as well as the partition entry array it points to
+func readGPTCopy(r io.ReaderAt, sectorSize int, lba uint64) (*gptHeader, []byte, error) {
+	buf := make([]byte, sectorSize)
+	if _, err := r.ReadAt(buf, int64(lba)*int64(sectorSize)); err != nil {
+		return nil, nil, err
+	}
+	if string(buf[0:8]) != gptSignature {
+		return nil, nil, fmt.Errorf("missing GPT signature at LBA %d", lba)
+	}
+	hdrSize := binary.LittleEndian.Uint32(buf[12:16])
+	if hdrSize < gptMinHeaderSize || hdrSize > uint32(sectorSize) {
+		return nil, nil, fmt.Errorf("invalid GPT header size %d at LBA %d", hdrSize, lba)
+	}
+	hdrCRC := binary.LittleEndian.Uint32(buf[16:20])
+	// The header CRC is computed with the CRC field itself zeroed
+	hdrBytes := make([]byte, hdrSize)
+	copy(hdrBytes, buf[:hdrSize])
+	copy(hdrBytes[16:20], []byte{0, 0, 0, 0})
+	if crc32.ChecksumIEEE(hdrBytes) != hdrCRC {
+		return nil, nil, fmt.Errorf("GPT header CRC mismatch at LBA %d", lba)
+	}
+
+	hdr := &gptHeader{
+		currentLBA:     binary.LittleEndian.Uint64(buf[24:32]),
+		alternateLBA:   binary.LittleEndian.Uint64(buf[32:40]),
+		firstUsableLBA: binary.LittleEndian.Uint64(buf[40:48]),
+		lastUsableLBA:  binary.LittleEndian.Uint64(buf[48:56]),
+		diskGUID:       formatGUID(buf[56:72]),
+		entriesLBA:     binary.LittleEndian.Uint64(buf[72:80]),
+		numEntries:     binary.LittleEndian.Uint32(buf[80:84]),
+		entrySize:      binary.LittleEndian.Uint32(buf[84:88]),
+		entriesCRC:     binary.LittleEndian.Uint32(buf[88:92]),
+	}
+	if hdr.currentLBA != lba {
+		return nil, nil, fmt.Errorf("GPT header at LBA %d claims to be at LBA %d", lba, hdr.currentLBA)
+	}
+	// The size of the entries is bounded by the sector size, so that a
+	// crafted header cannot make us allocate gigabytes for the entry array.
+	// The sizes are compared before their conversion, which would turn the
+	// largest ones negative on 32-bit platforms.
+	if hdr.entrySize < gptMinEntrySize || hdr.entrySize%8 != 0 || hdr.entrySize > uint32(sectorSize) || hdr.numEntries > gptMaxEntries {
+		return nil, nil, fmt.Errorf(
+			"invalid GPT partition entry array (%d entries of %d bytes) at LBA %d",
+			hdr.numEntries, hdr.entrySize, lba,
+		)
+	}
+
+	entries := make([]byte, int(hdr.numEntries)*int(hdr.entrySize))
+	if _, err := r.ReadAt(entries, int64(hdr.entriesLBA)*int64(sectorSize)); err != nil {
+		return nil, nil, err
+	}
+	if crc32.ChecksumIEEE(entries) != hdr.entriesCRC {
+		return nil, nil, fmt.Errorf("GPT partition entry array CRC mismatch for header at LBA %d", lba)
+	}
+	return hdr, entries, nil
+}
+
+// formatGUID returns the canonical, lowercase string representation of a GUID
+// stored in the mixed-endian on-disk format used by GPT: the first three
+// fields are little-endian, the last two are stored as is.
+func formatGUID(b []byte) string {
+	return fmt.Sprintf(
+		"%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(b[0:4]),
+		binary.LittleEndian.Uint16(b[4:6]),
+		binary.LittleEndian.Uint16(b[6:8]),
+		b[8:10],
+		b[10:16],
+	)
+}
+
+// decodeUTF16 decodes a NUL-terminated UTF-16LE string
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for x := 0; x+1 < len(b); x += 2 {
+		c := binary.LittleEndian.Uint16(b[x : x+2])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+	return strings.TrimSpace(string(utf16.Decode(u)))
+}
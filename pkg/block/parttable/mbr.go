@@ -0,0 +1,144 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package parttable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	mbrSize              = 512
+	mbrSignatureOffset   = 510
+	mbrDiskIDOffset      = 440
+	mbrEntriesOffset     = 446
+	mbrEntrySize         = 16
+	mbrNumPrimaryEntries = 4
+	mbrBootableFlag      = 0x80
+	mbrProtectiveType    = 0xee
+	// mbrMaxLogical bounds the number of extended boot records we are willing
+	// to follow, in order to protect against loops in corrupted EBR chains
+	mbrMaxLogical = 128
+)
+
+// mbrEntry is an entry of the partition table of an MBR or of an extended
+// boot record (EBR)
+type mbrEntry struct {
+	status   byte
+	ptype    byte
+	startLBA uint32
+	sectors  uint32
+}
+
+func parseMBREntries(sector []byte) []mbrEntry {
+	out := make([]mbrEntry, mbrNumPrimaryEntries)
+	for x := 0; x < mbrNumPrimaryEntries; x++ {
+		e := sector[mbrEntriesOffset+x*mbrEntrySize : mbrEntriesOffset+(x+1)*mbrEntrySize]
+		out[x] = mbrEntry{
+			status:   e[0],
+			ptype:    e[4],
+			startLBA: binary.LittleEndian.Uint32(e[8:12]),
+			sectors:  binary.LittleEndian.Uint32(e[12:16]),
+		}
+	}
+	return out
+}
+
+func hasMBRSignature(sector []byte) bool {
+	return sector[mbrSignatureOffset] == 0x55 && sector[mbrSignatureOffset+1] == 0xaa
+}
+
+func isExtendedMBRType(ptype byte) bool {
+	return ptype == 0x05 || ptype == 0x0f || ptype == 0x85
+}
+
+// readMBR reads the MBR found in LBA 0, including the logical partitions
+// described by the chain of extended boot records
+func readMBR(r io.ReaderAt, sectorSize int) (*Table, error) {
+	sector := make([]byte, mbrSize)
+	if _, err := r.ReadAt(sector, 0); err != nil {
+		return nil, err
+	}
+	if !hasMBRSignature(sector) {
+		return nil, ErrNoPartitionTable
+	}
+	diskID := fmt.Sprintf("%08x", binary.LittleEndian.Uint32(sector[mbrDiskIDOffset:mbrDiskIDOffset+4]))
+	t := &Table{
+		Type:            TABLE_TYPE_MBR,
+		SectorSizeBytes: sectorSize,
+		DiskID:          diskID,
+		Partitions:      make([]*Partition, 0),
+	}
+
+	entries := parseMBREntries(sector)
+	for _, e := range entries {
+		if e.ptype == mbrProtectiveType {
+			// A protective MBR whose GPT primary header could not be found.
+			// The backup header may still be usable.
+			return readGPT(r, sectorSize)
+		}
+	}
+	for x, e := range entries {
+		if e.ptype == 0 || e.sectors == 0 {
+			continue
+		}
+		t.Partitions = append(t.Partitions, newMBRPartition(diskID, x+1, e, uint64(e.startLBA)))
+		if isExtendedMBRType(e.ptype) {
+			logical, err := readEBRChain(r, sectorSize, diskID, uint64(e.startLBA))
+			if err != nil {
+				return nil, err
+			}
+			t.Partitions = append(t.Partitions, logical...)
+		}
+	}
+	return t, nil
+}
+
+// readEBRChain follows the linked list of extended boot records starting at
+// the first sector of the extended partition. The first entry of each EBR
+// describes a logical partition, relative to the EBR itself; the second one
+// points to the next EBR, relative to the start of the extended partition.
+func readEBRChain(r io.ReaderAt, sectorSize int, diskID string, extStart uint64) ([]*Partition, error) {
+	out := make([]*Partition, 0)
+	sector := make([]byte, mbrSize)
+	ebrLBA := extStart
+	for number := 5; number < 5+mbrMaxLogical; number++ {
+		if _, err := r.ReadAt(sector, int64(ebrLBA)*int64(sectorSize)); err != nil {
+			return nil, err
+		}
+		if !hasMBRSignature(sector) {
+			break
+		}
+		entries := parseMBREntries(sector)
+		if entries[0].ptype != 0 && entries[0].sectors != 0 {
+			out = append(out, newMBRPartition(diskID, number, entries[0], ebrLBA+uint64(entries[0].startLBA)))
+		}
+		if !isExtendedMBRType(entries[1].ptype) || entries[1].startLBA == 0 {
+			break
+		}
+		ebrLBA = extStart + uint64(entries[1].startLBA)
+	}
+	return out, nil
+}
+
+func newMBRPartition(diskID string, number int, e mbrEntry, firstLBA uint64) *Partition {
+	var attrs uint64
+	if e.status&mbrBootableFlag != 0 {
+		attrs |= ATTRIBUTE_LEGACY_BIOS_BOOTABLE
+	}
+	typeID := fmt.Sprintf("0x%02x", e.ptype)
+	return &Partition{
+		Number:     number,
+		TypeID:     typeID,
+		TypeName:   mbrTypeName(e.ptype),
+		UUID:       fmt.Sprintf("%s-%02x", diskID, number),
+		Attributes: attrs,
+		FirstLBA:   firstLBA,
+		LastLBA:    firstLBA + uint64(e.sectors) - 1,
+	}
+}
@@ -0,0 +1,208 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package parttable reads MBR and GPT partition tables from any io.ReaderAt,
+// which may be a block device node as well as a raw disk image file.
+package parttable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrNoPartitionTable is returned when neither a GPT nor an MBR partition
+	// table could be found
+	ErrNoPartitionTable = errors.New("no partition table found")
+	// ErrInvalidGPT is returned when a GPT was found but neither its primary
+	// nor its backup header (and partition entries) passed the CRC checks
+	ErrInvalidGPT = errors.New("no valid GPT header found")
+)
+
+// TableType describes the kind of partition table found on a disk
+type TableType int
+
+const (
+	TABLE_TYPE_UNKNOWN TableType = iota
+	TABLE_TYPE_MBR               // DOS/MBR partition table
+	TABLE_TYPE_GPT               // GUID partition table
+)
+
+var (
+	tableTypeString = map[TableType]string{
+		TABLE_TYPE_UNKNOWN: "Unknown",
+		TABLE_TYPE_MBR:     "MBR",
+		TABLE_TYPE_GPT:     "GPT",
+	}
+)
+
+func (t TableType) String() string {
+	return tableTypeString[t]
+}
+
+// MarshalJSON encodes the table type as its lowercased name
+func (t TableType) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + strings.ToLower(t.String()) + "\""), nil
+}
+
+// Table describes the partition table of a disk
+type Table struct {
+	Type TableType `json:"type"`
+	// SectorSizeBytes is the size of the logical blocks the LBAs in the table
+	// are expressed in
+	SectorSizeBytes int `json:"sector_size_bytes"`
+	// DiskID is the disk GUID for GPT, or the 32-bit disk signature, as 8
+	// hexadecimal digits, for MBR
+	DiskID string `json:"disk_id"`
+	// PrimaryHeaderValid and BackupHeaderValid tell whether the two copies of
+	// a GPT (header and partition entries) passed their CRC checks. Both are
+	// false for MBR tables.
+	PrimaryHeaderValid bool `json:"primary_header_valid"`
+	BackupHeaderValid  bool `json:"backup_header_valid"`
+	// FirstUsableLBA and LastUsableLBA delimit the area of a GPT disk
+	// partitions may be allocated in. Both are zero for MBR tables.
+	FirstUsableLBA uint64       `json:"first_usable_lba"`
+	LastUsableLBA  uint64       `json:"last_usable_lba"`
+	Partitions     []*Partition `json:"partitions"`
+}
+
+// Partition describes an entry of a partition table
+type Partition struct {
+	// Number is the number of the partition, as used by the kernel to name
+	// partition devices: the 1-based index of a GPT entry, 1-4 for MBR
+	// primary partitions and 5 onwards for MBR logical partitions
+	Number int `json:"number"`
+	// TypeID is the partition type GUID for GPT, or the partition type byte
+	// formatted as "0x83" for MBR
+	TypeID string `json:"type_id"`
+	// TypeName is a human-readable name for TypeID, or "unknown"
+	TypeName string `json:"type_name"`
+	// UUID is the unique partition GUID for GPT. For MBR it is built from the
+	// disk signature and the partition number, the same way the kernel and
+	// blkid build the PARTUUID of MBR partitions.
+	UUID string `json:"uuid"`
+	// Name is the GPT partition name. MBR partitions have no name.
+	Name string `json:"name"`
+	// Attributes contains the GPT partition attribute flags. For MBR, bit 7
+	// of the status byte (the bootable flag) is mapped to
+	// ATTRIBUTE_LEGACY_BIOS_BOOTABLE.
+	Attributes uint64 `json:"attributes"`
+	FirstLBA   uint64 `json:"first_lba"`
+	LastLBA    uint64 `json:"last_lba"`
+}
+
+const (
+	// ATTRIBUTE_REQUIRED marks partitions required for the platform to function
+	ATTRIBUTE_REQUIRED uint64 = 1 << 0
+	// ATTRIBUTE_NO_BLOCK_IO_PROTOCOL hides the partition from EFI firmware
+	ATTRIBUTE_NO_BLOCK_IO_PROTOCOL uint64 = 1 << 1
+	// ATTRIBUTE_LEGACY_BIOS_BOOTABLE marks the partition legacy BIOS bootable
+	ATTRIBUTE_LEGACY_BIOS_BOOTABLE uint64 = 1 << 2
+	// ATTRIBUTE_READ_ONLY marks Microsoft basic data partitions read-only
+	ATTRIBUTE_READ_ONLY uint64 = 1 << 60
+	// ATTRIBUTE_HIDDEN marks Microsoft basic data partitions hidden
+	ATTRIBUTE_HIDDEN uint64 = 1 << 62
+	// ATTRIBUTE_NO_AUTOMOUNT prevents Microsoft basic data partitions from
+	// being assigned a drive letter
+	ATTRIBUTE_NO_AUTOMOUNT uint64 = 1 << 63
+)
+
+// SizeSectors returns the number of sectors the partition spans
+func (p *Partition) SizeSectors() uint64 {
+	return p.LastLBA - p.FirstLBA + 1
+}
+
+func (p *Partition) String() string {
+	name := ""
+	if p.Name != "" {
+		name = fmt.Sprintf(" %q", p.Name)
+	}
+	return fmt.Sprintf(
+		"partition #%d%s (%s) [%d-%d]",
+		p.Number,
+		name,
+		p.TypeName,
+		p.FirstLBA,
+		p.LastLBA,
+	)
+}
+
+func (t *Table) String() string {
+	return fmt.Sprintf(
+		"%s partition table %s (%d partitions)",
+		t.Type.String(),
+		t.DiskID,
+		len(t.Partitions),
+	)
+}
+
+// Partition returns the Partition with the supplied number, or nil
+func (t *Table) Partition(number int) *Partition {
+	for _, p := range t.Partitions {
+		if p.Number == number {
+			return p
+		}
+	}
+	return nil
+}
+
+// Read returns a pointer to a Table struct describing the partition table
+// found in r. GPT headers are looked for assuming 512-byte and then 4096-byte
+// logical sectors; MBR tables are assumed to use 512-byte sectors. Use
+// ReadWithSectorSize when the logical sector size of the disk is known.
+func Read(r io.ReaderAt) (*Table, error) {
+	for _, sectorSize := range []int{512, 4096} {
+		if hasGPTSignature(r, sectorSize) {
+			return readGPT(r, sectorSize)
+		}
+	}
+	return readMBR(r, 512)
+}
+
+// ReadWithSectorSize returns a pointer to a Table struct describing the
+// partition table found in r, a disk using the supplied logical sector size.
+func ReadWithSectorSize(r io.ReaderAt, sectorSize int) (*Table, error) {
+	if sectorSize < mbrSize {
+		return nil, fmt.Errorf("invalid sector size %d", sectorSize)
+	}
+	if hasGPTSignature(r, sectorSize) {
+		return readGPT(r, sectorSize)
+	}
+	return readMBR(r, sectorSize)
+}
+
+// ReadFile returns a pointer to a Table struct describing the partition table
+// found in the supplied disk image file or block device node.
+func ReadFile(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// readerSize returns the size in bytes of the supplied io.ReaderAt, or -1 if
+// it cannot be determined
+func readerSize(r io.ReaderAt) int64 {
+	switch v := r.(type) {
+	case interface{ Size() int64 }:
+		// bytes.Reader, strings.Reader, io.SectionReader
+		return v.Size()
+	case io.Seeker:
+		// os.File. Stat() reports a zero size for block device nodes, but
+		// seeking to their end works.
+		size, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		return size
+	}
+	return -1
+}
@@ -0,0 +1,313 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package parttable_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/jaypipes/ghw/pkg/block/parttable"
+)
+
+const (
+	testSectorSize = 512
+	// testDiskSectors is the size of the test disk images, i.e. 1MiB
+	testDiskSectors = 2048
+	testDiskGUID    = "8d3c1a4e-2b6f-4d9e-9c2a-61e0a6f1d5b7"
+)
+
+type testGPTEntry struct {
+	typeGUID string
+	uuid     string
+	name     string
+	attrs    uint64
+	first    uint64
+	last     uint64
+}
+
+var testGPTEntries = []testGPTEntry{
+	{
+		typeGUID: "C12A7328-F81F-11D2-BA4B-00A0C93EC93B",
+		uuid:     "0a9f1f6e-6d3c-4a5e-8f43-0e4f7a2d9c11",
+		name:     "EFI system partition",
+		attrs:    parttable.ATTRIBUTE_REQUIRED,
+		first:    34,
+		last:     1057,
+	},
+	// An unused entry is left between the two partitions, to check partition
+	// numbers follow the index of the entries in the array
+	{},
+	{
+		typeGUID: "0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+		uuid:     "5c1e8b2a-3f7d-4e61-b0a9-7d2c4e8f1a36",
+		name:     "root",
+		attrs:    parttable.ATTRIBUTE_LEGACY_BIOS_BOOTABLE,
+		first:    1058,
+		last:     2014,
+	},
+}
+
+// guidBytes encodes a GUID in the mixed-endian format used by GPT
+func guidBytes(t *testing.T, guid string) []byte {
+	raw, err := hex.DecodeString(strings.Replace(guid, "-", "", -1))
+	if err != nil || len(raw) != 16 {
+		t.Fatalf("invalid GUID %q", guid)
+	}
+	out := make([]byte, 16)
+	for x := 0; x < 4; x++ {
+		out[x] = raw[3-x]
+	}
+	out[4], out[5] = raw[5], raw[4]
+	out[6], out[7] = raw[7], raw[6]
+	copy(out[8:], raw[8:])
+	return out
+}
+
+func writeGPTHeader(t *testing.T, img []byte, lba, altLBA, entriesLBA uint64, entriesCRC uint32) {
+	hdr := img[lba*testSectorSize : lba*testSectorSize+92]
+	copy(hdr[0:8], "EFI PART")
+	binary.LittleEndian.PutUint32(hdr[8:12], 0x00010000)
+	binary.LittleEndian.PutUint32(hdr[12:16], 92)
+	binary.LittleEndian.PutUint64(hdr[24:32], lba)
+	binary.LittleEndian.PutUint64(hdr[32:40], altLBA)
+	binary.LittleEndian.PutUint64(hdr[40:48], 34)
+	binary.LittleEndian.PutUint64(hdr[48:56], testDiskSectors-34)
+	copy(hdr[56:72], guidBytes(t, testDiskGUID))
+	binary.LittleEndian.PutUint64(hdr[72:80], entriesLBA)
+	binary.LittleEndian.PutUint32(hdr[80:84], 128)
+	binary.LittleEndian.PutUint32(hdr[84:88], 128)
+	binary.LittleEndian.PutUint32(hdr[88:92], entriesCRC)
+	binary.LittleEndian.PutUint32(hdr[16:20], crc32.ChecksumIEEE(hdr))
+}
+
+// gptImage returns a disk image with a protective MBR, and primary and backup
+// GPT headers describing testGPTEntries
+func gptImage(t *testing.T) []byte {
+	img := make([]byte, testDiskSectors*testSectorSize)
+
+	mbr := img[0:testSectorSize]
+	mbr[446+4] = 0xee
+	binary.LittleEndian.PutUint32(mbr[446+8:], 1)
+	binary.LittleEndian.PutUint32(mbr[446+12:], testDiskSectors-1)
+	mbr[510], mbr[511] = 0x55, 0xaa
+
+	entries := make([]byte, 128*128)
+	for x, e := range testGPTEntries {
+		if e.typeGUID == "" {
+			continue
+		}
+		entry := entries[x*128 : (x+1)*128]
+		copy(entry[0:16], guidBytes(t, e.typeGUID))
+		copy(entry[16:32], guidBytes(t, e.uuid))
+		binary.LittleEndian.PutUint64(entry[32:40], e.first)
+		binary.LittleEndian.PutUint64(entry[40:48], e.last)
+		binary.LittleEndian.PutUint64(entry[48:56], e.attrs)
+		for y, c := range utf16.Encode([]rune(e.name)) {
+			binary.LittleEndian.PutUint16(entry[56+2*y:], c)
+		}
+	}
+	entriesCRC := crc32.ChecksumIEEE(entries)
+	copy(img[2*testSectorSize:], entries)
+	copy(img[(testDiskSectors-33)*testSectorSize:], entries)
+
+	writeGPTHeader(t, img, 1, testDiskSectors-1, 2, entriesCRC)
+	writeGPTHeader(t, img, testDiskSectors-1, 1, testDiskSectors-33, entriesCRC)
+	return img
+}
+
+func checkGPTPartitions(t *testing.T, table *parttable.Table) {
+	if table.Type != parttable.TABLE_TYPE_GPT {
+		t.Fatalf("Expected a GPT partition table, but got %s", table.Type)
+	}
+	if table.DiskID != testDiskGUID {
+		t.Fatalf("Expected disk GUID %s, but got %s", testDiskGUID, table.DiskID)
+	}
+	if len(table.Partitions) != 2 {
+		t.Fatalf("Expected 2 partitions, but got %d", len(table.Partitions))
+	}
+
+	esp := table.Partition(1)
+	if esp == nil {
+		t.Fatalf("Expected partition #1 to be found")
+	}
+	if esp.TypeName != "EFI System" {
+		t.Fatalf("Expected partition #1 to be an EFI System partition, but got %q", esp.TypeName)
+	}
+	if esp.TypeID != strings.ToLower(testGPTEntries[0].typeGUID) {
+		t.Fatalf("Expected partition #1 type %s, but got %s", testGPTEntries[0].typeGUID, esp.TypeID)
+	}
+	if esp.UUID != testGPTEntries[0].uuid {
+		t.Fatalf("Expected partition #1 UUID %s, but got %s", testGPTEntries[0].uuid, esp.UUID)
+	}
+	if esp.Name != testGPTEntries[0].name {
+		t.Fatalf("Expected partition #1 name %q, but got %q", testGPTEntries[0].name, esp.Name)
+	}
+	if esp.Attributes != parttable.ATTRIBUTE_REQUIRED {
+		t.Fatalf("Expected partition #1 attributes %x, but got %x", parttable.ATTRIBUTE_REQUIRED, esp.Attributes)
+	}
+	if esp.FirstLBA != 34 || esp.LastLBA != 1057 || esp.SizeSectors() != 1024 {
+		t.Fatalf("Expected partition #1 to span LBAs 34-1057, but got %d-%d", esp.FirstLBA, esp.LastLBA)
+	}
+
+	if table.Partition(2) != nil {
+		t.Fatalf("Expected unused entry #2 to be skipped")
+	}
+	root := table.Partition(3)
+	if root == nil {
+		t.Fatalf("Expected partition #3 to be found")
+	}
+	if root.TypeName != "Linux filesystem" || root.Name != "root" {
+		t.Fatalf("Expected partition #3 to be the root Linux filesystem, but got %s", root)
+	}
+}
+
+func TestReadGPT(t *testing.T) {
+	img := gptImage(t)
+	table, err := parttable.Read(bytes.NewReader(img))
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if !table.PrimaryHeaderValid || !table.BackupHeaderValid {
+		t.Fatalf("Expected both GPT headers to be valid")
+	}
+	if table.SectorSizeBytes != testSectorSize {
+		t.Fatalf("Expected sector size %d, but got %d", testSectorSize, table.SectorSizeBytes)
+	}
+	checkGPTPartitions(t, table)
+}
+
+func TestReadGPTCorruptPrimary(t *testing.T) {
+	img := gptImage(t)
+	// Corrupt the primary partition entry array, which the primary header
+	// CRC does not cover but the entries CRC does
+	img[2*testSectorSize+60] ^= 0xff
+
+	table, err := parttable.Read(bytes.NewReader(img))
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if table.PrimaryHeaderValid {
+		t.Fatalf("Expected primary GPT header to be invalid")
+	}
+	if !table.BackupHeaderValid {
+		t.Fatalf("Expected backup GPT header to be valid")
+	}
+	checkGPTPartitions(t, table)
+
+	// With both copies corrupted, the table is unusable
+	img[(testDiskSectors-1)*testSectorSize+40] ^= 0xff
+	if _, err := parttable.Read(bytes.NewReader(img)); err != parttable.ErrInvalidGPT {
+		t.Fatalf("Expected ErrInvalidGPT, but got %v", err)
+	}
+}
+
+func TestReadGPTOversizedEntries(t *testing.T) {
+	img := gptImage(t)
+	// Headers with valid CRCs claiming 1024 entries of almost 4GiB each must
+	// be rejected rather than have the entry array allocated
+	for _, lba := range []uint64{1, testDiskSectors - 1} {
+		hdr := img[lba*testSectorSize : lba*testSectorSize+92]
+		binary.LittleEndian.PutUint32(hdr[80:84], 1024)
+		binary.LittleEndian.PutUint32(hdr[84:88], 0xfffffff8)
+		binary.LittleEndian.PutUint32(hdr[16:20], 0)
+		binary.LittleEndian.PutUint32(hdr[16:20], crc32.ChecksumIEEE(hdr))
+	}
+	if _, err := parttable.Read(bytes.NewReader(img)); err != parttable.ErrInvalidGPT {
+		t.Fatalf("Expected ErrInvalidGPT, but got %v", err)
+	}
+}
+
+func TestReadGPTOversizedHeader(t *testing.T) {
+	img := gptImage(t)
+	// Headers claiming a size of 2GiB must be rejected rather than have a
+	// buffer of that size allocated to check their CRC
+	for _, lba := range []uint64{1, testDiskSectors - 1} {
+		hdr := img[lba*testSectorSize : lba*testSectorSize+92]
+		binary.LittleEndian.PutUint32(hdr[12:16], 0x80000000)
+	}
+	if _, err := parttable.Read(bytes.NewReader(img)); err != parttable.ErrInvalidGPT {
+		t.Fatalf("Expected ErrInvalidGPT, but got %v", err)
+	}
+}
+
+func TestReadMBR(t *testing.T) {
+	img := make([]byte, testDiskSectors*testSectorSize)
+	putEntry := func(sector uint64, idx int, status byte, ptype byte, start uint32, size uint32) {
+		e := img[sector*testSectorSize+446+uint64(idx)*16:]
+		e[0] = status
+		e[4] = ptype
+		binary.LittleEndian.PutUint32(e[8:], start)
+		binary.LittleEndian.PutUint32(e[12:], size)
+		img[sector*testSectorSize+510], img[sector*testSectorSize+511] = 0x55, 0xaa
+	}
+	binary.LittleEndian.PutUint32(img[440:], 0x4ad1e3f2)
+	putEntry(0, 0, 0x80, 0x83, 2, 1000)
+	putEntry(0, 1, 0x00, 0x05, 1002, 1000)
+	// First EBR: a logical partition, plus a link to the next EBR
+	putEntry(1002, 0, 0x00, 0x82, 2, 400)
+	putEntry(1002, 1, 0x00, 0x05, 500, 500)
+	// Second EBR, at 1002+500, with the last logical partition
+	putEntry(1502, 0, 0x00, 0x8e, 2, 498)
+
+	table, err := parttable.Read(bytes.NewReader(img))
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if table.Type != parttable.TABLE_TYPE_MBR {
+		t.Fatalf("Expected an MBR partition table, but got %s", table.Type)
+	}
+	if table.DiskID != "4ad1e3f2" {
+		t.Fatalf("Expected disk ID 4ad1e3f2, but got %s", table.DiskID)
+	}
+
+	tests := []struct {
+		number   int
+		typeID   string
+		typeName string
+		first    uint64
+		last     uint64
+		attrs    uint64
+	}{
+		{1, "0x83", "Linux", 2, 1001, parttable.ATTRIBUTE_LEGACY_BIOS_BOOTABLE},
+		{2, "0x05", "Extended", 1002, 2001, 0},
+		{5, "0x82", "Linux swap / Solaris", 1004, 1403, 0},
+		{6, "0x8e", "Linux LVM", 1504, 2001, 0},
+	}
+	if len(table.Partitions) != len(tests) {
+		t.Fatalf("Expected %d partitions, but got %d", len(tests), len(table.Partitions))
+	}
+	for x, test := range tests {
+		p := table.Partitions[x]
+		if p.Number != test.number {
+			t.Fatalf("Expected partition number %d, but got %d", test.number, p.Number)
+		}
+		if p.TypeID != test.typeID || p.TypeName != test.typeName {
+			t.Fatalf("Expected partition #%d of type %s (%s), but got %s (%s)", p.Number, test.typeID, test.typeName, p.TypeID, p.TypeName)
+		}
+		if p.FirstLBA != test.first || p.LastLBA != test.last {
+			t.Fatalf("Expected partition #%d to span LBAs %d-%d, but got %d-%d", p.Number, test.first, test.last, p.FirstLBA, p.LastLBA)
+		}
+		if p.Attributes != test.attrs {
+			t.Fatalf("Expected partition #%d attributes %x, but got %x", p.Number, test.attrs, p.Attributes)
+		}
+	}
+	if uuid := table.Partition(5).UUID; uuid != "4ad1e3f2-05" {
+		t.Fatalf("Expected partition #5 UUID 4ad1e3f2-05, but got %s", uuid)
+	}
+}
+
+func TestReadNoPartitionTable(t *testing.T) {
+	img := make([]byte, testDiskSectors*testSectorSize)
+	if _, err := parttable.Read(bytes.NewReader(img)); err != parttable.ErrNoPartitionTable {
+		t.Fatalf("Expected ErrNoPartitionTable, but got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package smartctl runs smartctl on block devices, both when reading the
+// health of disks and when recording it into snapshots.
+package smartctl
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Timeout is the time smartctl is given to report on devices before being
+// killed, so that an unresponsive device does not hang the caller
+const Timeout = 30 * time.Second
+
+// HasSMART returns whether smartctl should be asked for the SMART data of the
+// block device with the supplied kernel name, e.g. "sda". Only SCSI, ATA and
+// NVMe disks are: optical (sr*) and floppy (fd*) drives, virtual disks,
+// device-mapper devices and software RAID arrays are not.
+func HasSMART(name string) bool {
+	for _, prefix := range []string{"sd", "hd", "nvme"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run returns the `smartctl --json -a` output for the supplied device node,
+// using the supplied smartctl binary, which is killed if still running at
+// the supplied deadline.
+//
+// Disks in standby are not woken up: smartctl then reports nothing about
+// their SMART status. The exit status of smartctl is a bit mask which is not
+// zero as soon as the disk reports any problem, so the output is returned
+// whatever the exit status, along with the error of the run if any.
+func Run(smartctl string, devPath string, deadline time.Time) ([]byte, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, smartctl, "--json", "-a", "-n", "standby,0", devPath)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out.Bytes(), err
+}
@@ -0,0 +1,35 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package smartctl_test
+
+import (
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/block/smartctl"
+)
+
+func TestHasSMART(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "sda", expected: true},
+		{name: "hdb", expected: true},
+		{name: "nvme0n1", expected: true},
+		{name: "sr0", expected: false},
+		{name: "fd0", expected: false},
+		{name: "vda", expected: false},
+		{name: "dm-0", expected: false},
+		{name: "md127", expected: false},
+		{name: "loop0", expected: false},
+	}
+	for _, test := range tests {
+		if got := smartctl.HasSMART(test.name); got != test.expected {
+			t.Fatalf("Expected HasSMART(%q) to be %v, but got %v", test.name, test.expected, got)
+		}
+	}
+}
@@ -14,14 +14,16 @@ import (
 // Concrete merged set of configuration switches that act as an execution
 // context when calling internal discovery methods
 type Context struct {
-	Chroot               string
-	EnableTools          bool
-	SnapshotPath         string
-	SnapshotRoot         string
-	SnapshotExclusive    bool
-	PathOverrides        option.PathOverrides
-	snapshotUnpackedPath string
-	alert                option.Alerter
+	Chroot                    string
+	EnableTools               bool
+	EnableVirtualBlockDevices bool
+	EnableDiskHealth          bool
+	SnapshotPath              string
+	SnapshotRoot              string
+	SnapshotExclusive         bool
+	PathOverrides             option.PathOverrides
+	snapshotUnpackedPath      string
+	alert                     option.Alerter
 }
 
 // New returns a Context struct pointer that has had various options set on it
@@ -49,6 +51,14 @@ func New(opts ...*option.Option) *Context {
 		ctx.EnableTools = *merged.EnableTools
 	}
 
+	if merged.EnableVirtualBlockDevices != nil {
+		ctx.EnableVirtualBlockDevices = *merged.EnableVirtualBlockDevices
+	}
+
+	if merged.EnableDiskHealth != nil {
+		ctx.EnableDiskHealth = *merged.EnableDiskHealth
+	}
+
 	if merged.PathOverrides != nil {
 		ctx.PathOverrides = merged.PathOverrides
 	}
@@ -8,6 +8,7 @@ package cpu
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jaypipes/ghw/pkg/context"
 	"github.com/jaypipes/ghw/pkg/marshal"
@@ -26,6 +27,18 @@ type ProcessorCore struct {
 	// Index is the zero-based index of the core on the physical processor
 	// package
 	Index int `json:"index"`
+	// DieID and ClusterID identify the die and the cluster of cores sharing
+	// a cache the core belongs to, within the physical package. They are -1
+	// when the kernel does not report them.
+	DieID     int `json:"die_id"`
+	ClusterID int `json:"cluster_id"`
+	// Type is the kind of the core on hybrid processors, and
+	// CORE_TYPE_UNKNOWN when the cores of the host are all alike
+	Type CoreType `json:"type"`
+	// Capacity is the relative performance of the core, as computed by the
+	// scheduler on Arm systems, the fastest cores having a capacity of 1024.
+	// It is zero when unknown.
+	Capacity uint64 `json:"capacity,omitempty"`
 	// NumThreads is the number of hardware threads associated with the core
 	NumThreads uint32 `json:"total_threads"`
 	// LogicalProcessors is a slice of ints representing the logical processor
@@ -36,14 +49,51 @@ type ProcessorCore struct {
 // String returns a short string indicating important information about the
 // processor core
 func (c *ProcessorCore) String() string {
+	kind := "processor"
+	if c.Type != CORE_TYPE_UNKNOWN {
+		kind = strings.ToLower(c.Type.String())
+	}
 	return fmt.Sprintf(
-		"processor core #%d (%d threads), logical processors %v",
+		"%s core #%d (%d threads), logical processors %v",
+		kind,
 		c.Index,
 		c.NumThreads,
 		c.LogicalProcessors,
 	)
 }
 
+// LogicalProcessor describes a hardware thread, as scheduled by the operating
+// system
+type LogicalProcessor struct {
+	// ID is the identifier the operating system gave the logical processor,
+	// e.g. 3 for "cpu3"
+	ID int `json:"id"`
+	// Online is true when the kernel schedules tasks on the logical
+	// processor. The kernel removes the topology of offline logical
+	// processors, so those are only found in Info.LogicalProcessors.
+	Online bool `json:"online"`
+	// Frequency describes the frequency scaling of the logical processor.
+	// It is nil when the kernel does not control it, e.g. in most virtual
+	// machines.
+	Frequency *Frequency `json:"frequency,omitempty"`
+	// IdleStates contains the idle states the logical processor may enter,
+	// shallowest first
+	IdleStates []*IdleState `json:"idle_states,omitempty"`
+}
+
+// String returns a short string describing the LogicalProcessor
+func (lp *LogicalProcessor) String() string {
+	freq := ""
+	if lp.Frequency != nil {
+		freq = " " + lp.Frequency.String()
+	}
+	return fmt.Sprintf(
+		"logical processor #%d%s",
+		lp.ID,
+		freq,
+	)
+}
+
 // Processor describes a physical host central processing unit (CPU).
 type Processor struct {
 	// ID is the physical processor `uint32` ID according to the system
@@ -52,6 +102,10 @@ type Processor struct {
 	NumCores uint32 `json:"total_cores"`
 	// NumThreads is the number of hardware threads in the processor package
 	NumThreads uint32 `json:"total_threads"`
+	// NumPerformanceCores and NumEfficiencyCores are the number of cores of
+	// each type on hybrid processors, and zero otherwise
+	NumPerformanceCores uint32 `json:"total_performance_cores"`
+	NumEfficiencyCores  uint32 `json:"total_efficiency_cores"`
 	// Vendor is a string containing the vendor name
 	Vendor string `json:"vendor"`
 	// Model` is a string containing the vendor's model name
@@ -59,9 +113,27 @@ type Processor struct {
 	// Capabilities is a slice of strings indicating the features the processor
 	// has enabled
 	Capabilities []string `json:"capabilities"`
+	// capabilitySet indexes the capabilities for HasCapability
+	capabilitySet map[string]bool
+	// Features groups the capabilities, e.g. into the x86-64
+	// microarchitecture level or the AVX-512 subsets. It is nil when the
+	// capabilities are unknown.
+	Features *Features `json:"features,omitempty"`
+	// X86 identifies the processor on x86 systems, and is nil otherwise
+	X86 *X86Identification `json:"x86,omitempty"`
+	// Arm identifies the processor on Arm systems, and is nil otherwise
+	Arm *ArmIdentification `json:"arm,omitempty"`
 	// Cores is a slice of ProcessorCore` struct pointers that are packed onto
 	// this physical processor
 	Cores []*ProcessorCore `json:"cores"`
+	// LogicalProcessors is a slice of LogicalProcessor struct pointers, one
+	// for each online hardware thread of the processor, sorted by ID. They
+	// are the ones found in Info.LogicalProcessors, so they are left out of
+	// the JSON and YAML output, where the IDs of the cores describe them.
+	LogicalProcessors []*LogicalProcessor `json:"-"`
+	// Frequency summarises the frequency scaling and the idle states of the
+	// logical processors, or is nil when the kernel controls neither
+	Frequency *FrequencySummary `json:"frequency,omitempty"`
 }
 
 // HasCapability returns true if the Processor has the supplied cpuid
@@ -70,7 +142,12 @@ type Processor struct {
 // CPUID feature bits in the following article:
 //
 // https://en.wikipedia.org/wiki/CPUID
+//
+// The lookup takes constant time on the processors returned by ghw.CPU().
 func (p *Processor) HasCapability(find string) bool {
+	if p.capabilitySet != nil {
+		return p.capabilitySet[find]
+	}
 	for _, c := range p.Capabilities {
 		if c == find {
 			return true
@@ -112,6 +189,17 @@ type Info struct {
 	// Processors is a slice of Processor struct pointers, one for each
 	// physical processor package contained in the host
 	Processors []*Processor `json:"processors"`
+	// LogicalProcessors is a slice of LogicalProcessor struct pointers, one
+	// for each logical processor present in the host, offline ones included,
+	// sorted by ID. It is empty on systems other than Linux.
+	LogicalProcessors []*LogicalProcessor `json:"logical_processors,omitempty"`
+	// Sets describes the sets of logical processors the kernel manages, e.g.
+	// the online or the isolated ones. It is nil on systems other than
+	// Linux.
+	Sets *Sets `json:"sets,omitempty"`
+	// Vulnerabilities is a slice of Vulnerability struct pointers, one for
+	// each hardware vulnerability the kernel knows of, sorted by name
+	Vulnerabilities []*Vulnerability `json:"vulnerabilities,omitempty"`
 }
 
 // New returns a pointer to an Info struct that contains information about the
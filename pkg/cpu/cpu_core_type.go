@@ -0,0 +1,56 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"strings"
+)
+
+// CoreType describes the kind of a core on hybrid processors, which mix
+// cores of different microarchitectures, like the P-cores and E-cores of
+// Intel hybrid parts or the big and LITTLE cores of Arm systems
+type CoreType int
+
+const (
+	// CORE_TYPE_UNKNOWN is the type of the cores of processors whose cores
+	// are all alike, or whose kind could not be determined
+	CORE_TYPE_UNKNOWN CoreType = iota
+	CORE_TYPE_PERFORMANCE
+	CORE_TYPE_EFFICIENCY
+)
+
+var (
+	coreTypeString = map[CoreType]string{
+		CORE_TYPE_UNKNOWN:     "Unknown",
+		CORE_TYPE_PERFORMANCE: "Performance",
+		CORE_TYPE_EFFICIENCY:  "Efficiency",
+	}
+)
+
+func (t CoreType) String() string {
+	return coreTypeString[t]
+}
+
+// MarshalJSON encodes the core type as its lowercased name
+func (t CoreType) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + strings.ToLower(t.String()) + "\""), nil
+}
+
+// CoreTypeCounts returns the number of performance cores and the number of
+// efficiency cores among the supplied cores
+func CoreTypeCounts(cores []*ProcessorCore) (uint32, uint32) {
+	var perf, eff uint32
+	for _, c := range cores {
+		switch c.Type {
+		case CORE_TYPE_PERFORMANCE:
+			perf++
+		case CORE_TYPE_EFFICIENCY:
+			eff++
+		}
+	}
+	return perf, eff
+}
@@ -0,0 +1,178 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/cpulist"
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+const (
+	// minCapacityGap is the ratio by which the capacities of two groups of
+	// cores must differ for them to be told apart. It ignores the small
+	// differences the CPPC highest performance values of the cores of Arm
+	// servers show, e.g. 1020 and 1024.
+	minCapacityGap = 1.2
+	// minFrequencyGap is the ratio by which the maximum frequencies of two
+	// groups of cores must differ for them to be told apart, when those are
+	// all that tells them apart. It ignores the favored cores of Intel Turbo
+	// Boost Max 3.0, and the high priority cores of Intel SST-TF, whose
+	// frequencies are up to about 30% higher than the ones of the others.
+	minFrequencyGap = 1.4
+)
+
+// setCoreTypes sets the type and the capacity of the supplied cores to those
+// of their first logical processor
+func setCoreTypes(paths *linuxpath.Paths, cores []*ProcessorCore, types map[int]CoreType) {
+	for _, c := range cores {
+		if len(c.LogicalProcessors) == 0 {
+			continue
+		}
+		lp := c.LogicalProcessors[0]
+		c.Type = types[lp]
+		c.Capacity = cpuUintAttr(
+			filepath.Join(paths.SysDevicesSystemCPU, fmt.Sprintf("cpu%d", lp)),
+			"cpu_capacity",
+		)
+	}
+}
+
+// logicalProcessorTypes returns the type of the core of each logical
+// processor, keyed by logical processor ID. The lists of the kernel are
+// used on Intel hybrid processors. Elsewhere, the cores are grouped by
+// capacity, as computed by the scheduler on Arm systems, or else by maximum
+// frequency, see classifyCoreTypes. The returned map is empty when the cores
+// cannot be told apart.
+func logicalProcessorTypes(paths *linuxpath.Paths) map[int]CoreType {
+	if types := hybridCoreTypes(paths); len(types) > 0 {
+		return types
+	}
+	capacities := make(map[int]uint64)
+	maxFreqs := make(map[int]uint64)
+	entries, err := ioutil.ReadDir(paths.SysDevicesSystemCPU)
+	if err != nil {
+		return make(map[int]CoreType)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "cpu") {
+			continue
+		}
+		id, err := strconv.Atoi(name[3:])
+		if err != nil {
+			continue
+		}
+		lpPath := filepath.Join(paths.SysDevicesSystemCPU, name)
+		if capacity := cpuUintAttr(lpPath, "cpu_capacity"); capacity > 0 {
+			capacities[id] = capacity
+		}
+		if maxFreq := cpuUintAttr(filepath.Join(lpPath, "cpufreq"), "cpuinfo_max_freq"); maxFreq > 0 {
+			maxFreqs[id] = maxFreq
+		}
+	}
+	if types := classifyCoreTypes(capacities, minCapacityGap); len(types) > 0 {
+		return types
+	}
+	return classifyCoreTypes(maxFreqs, minFrequencyGap)
+}
+
+// hybridCoreTypes returns the type of the core of each logical processor of
+// an Intel hybrid processor. The kernel lists the logical processors of each
+// type in /sys/devices/system/cpu/types/$TYPE/cpulist, and the perf
+// subsystem in /sys/devices/cpu_core/cpus and /sys/devices/cpu_atom/cpus.
+func hybridCoreTypes(paths *linuxpath.Paths) map[int]CoreType {
+	lists := map[string]CoreType{
+		filepath.Join(paths.SysDevices, "cpu_core", "cpus"): CORE_TYPE_PERFORMANCE,
+		filepath.Join(paths.SysDevices, "cpu_atom", "cpus"): CORE_TYPE_EFFICIENCY,
+	}
+	typesPath := filepath.Join(paths.SysDevicesSystemCPU, "types")
+	if entries, err := ioutil.ReadDir(typesPath); err == nil {
+		for _, entry := range entries {
+			name := entry.Name()
+			path := filepath.Join(typesPath, name, "cpulist")
+			switch {
+			case strings.Contains(name, "atom"):
+				lists[path] = CORE_TYPE_EFFICIENCY
+			case strings.Contains(name, "core"):
+				lists[path] = CORE_TYPE_PERFORMANCE
+			}
+		}
+	}
+
+	types := make(map[int]CoreType)
+	hybrid := false
+	for path, t := range lists {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		ids, err := cpulist.Parse(string(contents))
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			types[id] = t
+			hybrid = hybrid || t == CORE_TYPE_EFFICIENCY
+		}
+	}
+	if !hybrid {
+		return make(map[int]CoreType)
+	}
+	return types
+}
+
+// classifyCoreTypes groups the logical processors by the supplied values,
+// e.g. their capacity: sorted by value, a new group starts wherever a value
+// exceeds the previous one by the supplied gap ratio. The logical processors
+// of the group with the highest values are CORE_TYPE_PERFORMANCE ones, and
+// those of the group with the lowest values CORE_TYPE_EFFICIENCY ones. The
+// ones of any group in between, like the middle cores of Arm DynamIQ
+// tri-cluster processors, are CORE_TYPE_UNKNOWN ones. It returns an empty map
+// when the values are not clearly separated into several groups.
+func classifyCoreTypes(values map[int]uint64, gap float64) map[int]CoreType {
+	distinct := make([]uint64, 0, len(values))
+	seen := make(map[uint64]bool, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			distinct = append(distinct, v)
+		}
+	}
+	sort.Slice(distinct, func(x, y int) bool { return distinct[x] < distinct[y] })
+
+	// group holds the index of the group of each distinct value, the first
+	// group having the lowest values
+	group := make(map[uint64]int, len(distinct))
+	last := 0
+	for x, v := range distinct {
+		if x > 0 && float64(v) > gap*float64(distinct[x-1]) {
+			last++
+		}
+		group[v] = last
+	}
+	types := make(map[int]CoreType)
+	if last == 0 {
+		return types
+	}
+	for id, v := range values {
+		switch group[v] {
+		case 0:
+			types[id] = CORE_TYPE_EFFICIENCY
+		case last:
+			types[id] = CORE_TYPE_PERFORMANCE
+		default:
+			types[id] = CORE_TYPE_UNKNOWN
+		}
+	}
+	return types
+}
@@ -0,0 +1,156 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Features describes the features of a processor, grouped from its
+// capabilities
+type Features struct {
+	// X86Level is the x86-64 microarchitecture level defined by the x86-64
+	// psABI, from 1 for the baseline x86-64 to 4 for x86-64-v4. It is 0 on
+	// other architectures.
+	X86Level int `json:"x86_level,omitempty"`
+	// AVX512 contains the AVX-512 subsets supported on x86, e.g. "avx512f"
+	// or "avx512_vnni"
+	AVX512 []string `json:"avx512,omitempty"`
+	// AMX contains the Advanced Matrix Extensions supported on x86, e.g.
+	// "amx_tile" or "amx_bf16"
+	AMX []string `json:"amx,omitempty"`
+	// Virtualization is "vmx" for Intel VT-x or "svm" for AMD-V, and is empty
+	// when neither is exposed, e.g. in most virtual machines
+	Virtualization string `json:"virtualization,omitempty"`
+	// Crypto contains the cryptographic extensions supported, e.g. "aes" or
+	// "sha_ni" on x86, and "aes" or "sha2" on arm64
+	Crypto []string `json:"crypto,omitempty"`
+	// SVE contains the Scalable Vector Extensions supported on arm64, e.g.
+	// "sve" or "sve2"
+	SVE []string `json:"sve,omitempty"`
+	// LSE is true when the Large System Extensions atomic instructions are
+	// supported on arm64
+	LSE bool `json:"lse,omitempty"`
+}
+
+// X86LevelString returns the name of the x86-64 microarchitecture level, e.g.
+// "x86-64-v3", as accepted by the -march option of compilers, or an empty
+// string on other architectures
+func (f *Features) X86LevelString() string {
+	switch f.X86Level {
+	case 0:
+		return ""
+	case 1:
+		return "x86-64"
+	default:
+		return fmt.Sprintf("x86-64-v%d", f.X86Level)
+	}
+}
+
+func (f *Features) String() string {
+	groups := make([]string, 0)
+	if level := f.X86LevelString(); level != "" {
+		groups = append(groups, level)
+	}
+	for _, group := range [][]string{f.AVX512, f.AMX, f.Crypto, f.SVE} {
+		if len(group) > 0 {
+			groups = append(groups, strings.Join(group, ","))
+		}
+	}
+	if f.Virtualization != "" {
+		groups = append(groups, f.Virtualization)
+	}
+	if f.LSE {
+		groups = append(groups, "lse")
+	}
+	return strings.Join(groups, " ")
+}
+
+var (
+	// x86Levels contains the capabilities, as named in /proc/cpuinfo,
+	// required by each x86-64 microarchitecture level on top of the previous
+	// one. "lm" denotes the 64-bit long mode, "pni" SSE3 and "abm" LZCNT.
+	x86Levels = [][]string{
+		{"lm", "cmov", "cx8", "fpu", "fxsr", "mmx", "syscall", "sse", "sse2"},
+		{"cx16", "lahf_lm", "popcnt", "pni", "sse4_1", "sse4_2", "ssse3"},
+		{"avx", "avx2", "bmi1", "bmi2", "f16c", "fma", "abm", "movbe", "xsave"},
+		{"avx512f", "avx512bw", "avx512cd", "avx512dq", "avx512vl"},
+	}
+
+	// cryptoCapabilities contains the cryptographic extensions of x86 and
+	// arm64, as named in /proc/cpuinfo
+	cryptoCapabilities = map[string]bool{
+		"aes":        true,
+		"vaes":       true,
+		"pclmulqdq":  true,
+		"vpclmulqdq": true,
+		"sha_ni":     true,
+		"gfni":       true,
+		"pmull":      true,
+		"sha1":       true,
+		"sha2":       true,
+		"sha3":       true,
+		"sha512":     true,
+		"sm3":        true,
+		"sm4":        true,
+	}
+)
+
+// setCapabilities sets the capabilities of the processor, indexes them for
+// HasCapability and derives the Features of the processor from them
+func (p *Processor) setCapabilities(caps []string) {
+	p.Capabilities = caps
+	p.capabilitySet = make(map[string]bool, len(caps))
+	for _, c := range caps {
+		p.capabilitySet[c] = true
+	}
+	p.Features = newFeatures(caps, p.capabilitySet)
+}
+
+// newFeatures returns a pointer to a Features struct grouping the supplied
+// capabilities, listed in their original order and indexed in the supplied
+// set
+func newFeatures(caps []string, set map[string]bool) *Features {
+	f := &Features{
+		X86Level: x86Level(set),
+		AVX512:   make([]string, 0),
+		AMX:      make([]string, 0),
+		Crypto:   make([]string, 0),
+		SVE:      make([]string, 0),
+	}
+	for _, c := range caps {
+		switch {
+		case strings.HasPrefix(c, "avx512"):
+			f.AVX512 = append(f.AVX512, c)
+		case strings.HasPrefix(c, "amx_"):
+			f.AMX = append(f.AMX, c)
+		case strings.HasPrefix(c, "sve"):
+			f.SVE = append(f.SVE, c)
+		case c == "vmx" || c == "svm":
+			f.Virtualization = c
+		case c == "atomics":
+			f.LSE = true
+		case cryptoCapabilities[c]:
+			f.Crypto = append(f.Crypto, c)
+		}
+	}
+	return f
+}
+
+// x86Level returns the highest x86-64 microarchitecture level whose
+// capabilities, and those of the levels below, are all in the supplied set
+func x86Level(set map[string]bool) int {
+	for level, caps := range x86Levels {
+		for _, c := range caps {
+			if !set[c] {
+				return level
+			}
+		}
+	}
+	return len(x86Levels)
+}
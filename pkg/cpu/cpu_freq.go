@@ -0,0 +1,209 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BoostState describes whether a logical processor may run above its base
+// frequency, e.g. with Intel Turbo Boost or AMD Core Performance Boost
+type BoostState int
+
+const (
+	BOOST_STATE_UNKNOWN BoostState = iota
+	BOOST_STATE_ENABLED
+	BOOST_STATE_DISABLED
+)
+
+var (
+	boostStateString = map[BoostState]string{
+		BOOST_STATE_UNKNOWN:  "Unknown",
+		BOOST_STATE_ENABLED:  "Enabled",
+		BOOST_STATE_DISABLED: "Disabled",
+	}
+)
+
+func (s BoostState) String() string {
+	return boostStateString[s]
+}
+
+// MarshalJSON encodes the boost state as its lowercased name
+func (s BoostState) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + strings.ToLower(s.String()) + "\""), nil
+}
+
+// Frequency describes the frequency scaling of a logical processor. All
+// frequencies are in kHz, and are zero when unknown.
+type Frequency struct {
+	// MinKHz and MaxKHz are the lowest and highest frequencies the hardware
+	// supports, boost frequencies included
+	MinKHz uint64 `json:"min_khz"`
+	MaxKHz uint64 `json:"max_khz"`
+	// BaseKHz is the frequency the processor is guaranteed to sustain. Only
+	// some drivers, like intel_pstate and amd-pstate, report it.
+	BaseKHz uint64 `json:"base_khz,omitempty"`
+	// CurrentKHz is the frequency the kernel last requested or observed
+	CurrentKHz uint64 `json:"current_khz"`
+	// ScalingMinKHz and ScalingMaxKHz are the limits the governor is
+	// allowed to pick frequencies within
+	ScalingMinKHz uint64 `json:"scaling_min_khz"`
+	ScalingMaxKHz uint64 `json:"scaling_max_khz"`
+	// Driver is the scaling driver, e.g. "intel_pstate" or "acpi-cpufreq"
+	Driver string `json:"driver"`
+	// Governor is the scaling governor in use, e.g. "performance" or
+	// "schedutil", and AvailableGovernors the ones the driver supports
+	Governor           string     `json:"governor"`
+	AvailableGovernors []string   `json:"available_governors"`
+	Boost              BoostState `json:"boost"`
+	// EnergyPerformancePreference is the hint given to hardware-managed
+	// P-states, e.g. "performance" or "balance_power". It is empty with
+	// drivers not supporting it.
+	EnergyPerformancePreference string `json:"energy_performance_preference,omitempty"`
+}
+
+func (f *Frequency) String() string {
+	return fmt.Sprintf(
+		"%s/%s %d-%d kHz (current %d kHz, boost %s)",
+		f.Driver,
+		f.Governor,
+		f.MinKHz,
+		f.MaxKHz,
+		f.CurrentKHz,
+		f.Boost.String(),
+	)
+}
+
+// IdleState describes one of the idle states (C-states) a logical processor
+// may enter when it has nothing to run
+type IdleState struct {
+	// Index is the index of the state, 0 being the shallowest
+	Index int `json:"index"`
+	// Name and Description identify the state, e.g. "C1E" and "MWAIT 0x01"
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// LatencyUsec is the time it takes to exit the state, and
+	// ResidencyUsec the minimum time worth spending in it, in microseconds
+	LatencyUsec   uint64 `json:"latency_usec"`
+	ResidencyUsec uint64 `json:"residency_usec"`
+	// Disabled is true when the state was disabled, e.g. to lower wake-up
+	// latencies
+	Disabled bool `json:"disabled"`
+}
+
+func (s *IdleState) String() string {
+	disabled := ""
+	if s.Disabled {
+		disabled = " (disabled)"
+	}
+	return fmt.Sprintf(
+		"%s latency=%dus residency=%dus%s",
+		s.Name,
+		s.LatencyUsec,
+		s.ResidencyUsec,
+		disabled,
+	)
+}
+
+// FrequencySummary summarises the frequency scaling and the idle states of
+// the logical processors of a Processor
+type FrequencySummary struct {
+	// MinKHz and MaxKHz are the lowest and highest frequencies any of the
+	// logical processors supports, and BaseKHz the highest base frequency
+	MinKHz  uint64 `json:"min_khz"`
+	MaxKHz  uint64 `json:"max_khz"`
+	BaseKHz uint64 `json:"base_khz,omitempty"`
+	// Drivers, Governors and EnergyPerformancePreferences contain the
+	// distinct values found on the logical processors. A single governor
+	// means all the logical processors use it.
+	Drivers                      []string `json:"drivers"`
+	Governors                    []string `json:"governors"`
+	EnergyPerformancePreferences []string `json:"energy_performance_preferences,omitempty"`
+	// Boost is BOOST_STATE_ENABLED or BOOST_STATE_DISABLED when all the
+	// logical processors agree, and BOOST_STATE_UNKNOWN otherwise
+	Boost BoostState `json:"boost"`
+	// IdleStates contains the names of the idle states, and
+	// DisabledIdleStates the ones disabled on at least one logical processor
+	IdleStates         []string `json:"idle_states"`
+	DisabledIdleStates []string `json:"disabled_idle_states,omitempty"`
+}
+
+func (s *FrequencySummary) String() string {
+	return fmt.Sprintf(
+		"%s/%s %d-%d kHz (boost %s, idle states %s)",
+		strings.Join(s.Drivers, ","),
+		strings.Join(s.Governors, ","),
+		s.MinKHz,
+		s.MaxKHz,
+		s.Boost.String(),
+		strings.Join(s.IdleStates, ","),
+	)
+}
+
+// summarizeFrequency returns a FrequencySummary describing the supplied
+// logical processors, or nil if none of them reported any frequency scaling
+// or idle state
+func summarizeFrequency(lps []*LogicalProcessor) *FrequencySummary {
+	s := &FrequencySummary{
+		Drivers:                      make([]string, 0),
+		Governors:                    make([]string, 0),
+		EnergyPerformancePreferences: make([]string, 0),
+		IdleStates:                   make([]string, 0),
+		DisabledIdleStates:           make([]string, 0),
+	}
+	found := false
+	boostSeen := false
+	for _, lp := range lps {
+		for _, state := range lp.IdleStates {
+			found = true
+			s.IdleStates = appendUnique(s.IdleStates, state.Name)
+			if state.Disabled {
+				s.DisabledIdleStates = appendUnique(s.DisabledIdleStates, state.Name)
+			}
+		}
+		f := lp.Frequency
+		if f == nil {
+			continue
+		}
+		found = true
+		if f.MinKHz > 0 && (s.MinKHz == 0 || f.MinKHz < s.MinKHz) {
+			s.MinKHz = f.MinKHz
+		}
+		if f.MaxKHz > s.MaxKHz {
+			s.MaxKHz = f.MaxKHz
+		}
+		if f.BaseKHz > s.BaseKHz {
+			s.BaseKHz = f.BaseKHz
+		}
+		s.Drivers = appendUnique(s.Drivers, f.Driver)
+		s.Governors = appendUnique(s.Governors, f.Governor)
+		s.EnergyPerformancePreferences = appendUnique(s.EnergyPerformancePreferences, f.EnergyPerformancePreference)
+		if !boostSeen {
+			s.Boost = f.Boost
+			boostSeen = true
+		} else if s.Boost != f.Boost {
+			s.Boost = BOOST_STATE_UNKNOWN
+		}
+	}
+	if !found {
+		return nil
+	}
+	return s
+}
+
+func appendUnique(items []string, item string) []string {
+	if item == "" {
+		return items
+	}
+	for _, i := range items {
+		if i == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
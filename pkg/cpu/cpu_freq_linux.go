@@ -0,0 +1,181 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// logicalProcessors returns a slice of pointers to LogicalProcessor structs,
+// sorted by ID, for all the logical processors present in the system. The
+// kernel removes the topology of offline logical processors, which are thus
+// missing from the cores of the supplied processors, so the logical
+// processors are taken from the present set (or from the possible set when
+// the kernel reports no present set), as well as from the topology.
+func logicalProcessors(paths *linuxpath.Paths, procs []*Processor, sets *Sets) []*LogicalProcessor {
+	ids := sets.Present
+	if len(ids) == 0 {
+		ids = sets.Possible
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	ids = append([]int{}, ids...)
+	for _, p := range procs {
+		for _, c := range p.Cores {
+			for _, id := range c.LogicalProcessors {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	sort.Ints(ids)
+	out := make([]*LogicalProcessor, 0, len(ids))
+	for _, id := range ids {
+		lpPath := filepath.Join(paths.SysDevicesSystemCPU, fmt.Sprintf("cpu%d", id))
+		out = append(out, &LogicalProcessor{
+			ID:         id,
+			Online:     sets.isOnline(id),
+			Frequency:  logicalProcessorFrequency(paths, lpPath),
+			IdleStates: logicalProcessorIdleStates(lpPath),
+		})
+	}
+	return out
+}
+
+// processorLogicalProcessors returns the logical processors, among the
+// supplied ones, of the cores of the supplied processor, sorted by ID
+func processorLogicalProcessors(p *Processor, lps []*LogicalProcessor) []*LogicalProcessor {
+	ids := make(map[int]bool)
+	for _, c := range p.Cores {
+		for _, id := range c.LogicalProcessors {
+			ids[id] = true
+		}
+	}
+	out := make([]*LogicalProcessor, 0, len(ids))
+	for _, lp := range lps {
+		if ids[lp.ID] {
+			out = append(out, lp)
+		}
+	}
+	return out
+}
+
+// logicalProcessorFrequency returns the frequency scaling of the logical
+// processor whose sysfs directory is supplied, found in its cpufreq
+// subdirectory, or nil. The cpufreq subdirectory is a symlink to the
+// directory of the cpufreq policy shared by the logical processors which
+// change frequency together.
+func logicalProcessorFrequency(paths *linuxpath.Paths, lpPath string) *Frequency {
+	fpath := filepath.Join(lpPath, "cpufreq")
+	if _, err := os.Stat(fpath); err != nil {
+		return nil
+	}
+	f := &Frequency{
+		MinKHz:        cpuUintAttr(fpath, "cpuinfo_min_freq"),
+		MaxKHz:        cpuUintAttr(fpath, "cpuinfo_max_freq"),
+		BaseKHz:       cpuUintAttr(fpath, "base_frequency"),
+		CurrentKHz:    cpuUintAttr(fpath, "scaling_cur_freq"),
+		ScalingMinKHz: cpuUintAttr(fpath, "scaling_min_freq"),
+		ScalingMaxKHz: cpuUintAttr(fpath, "scaling_max_freq"),
+		Driver:        cpuAttr(fpath, "scaling_driver"),
+		Governor:      cpuAttr(fpath, "scaling_governor"),
+		AvailableGovernors: strings.Fields(
+			cpuAttr(fpath, "scaling_available_governors"),
+		),
+		Boost:                       boostState(paths, fpath),
+		EnergyPerformancePreference: cpuAttr(fpath, "energy_performance_preference"),
+	}
+	if f.BaseKHz == 0 {
+		// amd-pstate names the base frequency after ACPI CPPC
+		f.BaseKHz = cpuUintAttr(fpath, "amd_pstate_nominal_freq")
+	}
+	return f
+}
+
+// boostState returns whether the logical processor whose cpufreq directory
+// is supplied may run above its base frequency. Recent kernels have a
+// per-policy boost file. Older ones have a global one for acpi-cpufreq and
+// amd-pstate, while intel_pstate has its own, inverted, no_turbo file.
+func boostState(paths *linuxpath.Paths, fpath string) BoostState {
+	for _, dir := range []string{fpath, filepath.Join(paths.SysDevicesSystemCPU, "cpufreq")} {
+		switch cpuAttr(dir, "boost") {
+		case "1":
+			return BOOST_STATE_ENABLED
+		case "0":
+			return BOOST_STATE_DISABLED
+		}
+	}
+	switch cpuAttr(filepath.Join(paths.SysDevicesSystemCPU, "intel_pstate"), "no_turbo") {
+	case "0":
+		return BOOST_STATE_ENABLED
+	case "1":
+		return BOOST_STATE_DISABLED
+	}
+	return BOOST_STATE_UNKNOWN
+}
+
+// logicalProcessorIdleStates returns the idle states of the logical processor
+// whose sysfs directory is supplied, found in the cpuidle/state$INDEX
+// subdirectories
+func logicalProcessorIdleStates(lpPath string) []*IdleState {
+	out := make([]*IdleState, 0)
+	ipath := filepath.Join(lpPath, "cpuidle")
+	entries, err := ioutil.ReadDir(ipath)
+	if err != nil {
+		return out
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "state") {
+			continue
+		}
+		index, err := strconv.Atoi(name[5:])
+		if err != nil {
+			continue
+		}
+		spath := filepath.Join(ipath, name)
+		out = append(out, &IdleState{
+			Index:         index,
+			Name:          cpuAttr(spath, "name"),
+			Description:   cpuAttr(spath, "desc"),
+			LatencyUsec:   cpuUintAttr(spath, "latency"),
+			ResidencyUsec: cpuUintAttr(spath, "residency"),
+			Disabled:      cpuAttr(spath, "disable") == "1",
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Index < out[j].Index
+	})
+	return out
+}
+
+func cpuAttr(path string, attr string) string {
+	contents, err := ioutil.ReadFile(filepath.Join(path, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+func cpuUintAttr(path string, attr string) uint64 {
+	v, err := strconv.ParseUint(cpuAttr(path, attr), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
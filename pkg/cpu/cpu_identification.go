@@ -0,0 +1,142 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+)
+
+// X86Identification identifies an x86 processor, as reported by the CPUID
+// instruction and the kernel
+type X86Identification struct {
+	// Family, Model and Stepping identify the microarchitecture and the
+	// revision of the processor, e.g. 6, 106 and 6 for an Ice Lake-SP
+	Family   int `json:"family"`
+	Model    int `json:"model"`
+	Stepping int `json:"stepping"`
+	// Microcode is the revision of the microcode loaded, e.g. "0xd0003a5"
+	Microcode string `json:"microcode"`
+	// CacheSizeBytes is the size of the last level cache, as reported in
+	// /proc/cpuinfo
+	CacheSizeBytes uint64  `json:"cache_size_bytes"`
+	BogoMIPS       float64 `json:"bogomips"`
+	// PhysicalAddressBits and VirtualAddressBits are the widths of the
+	// physical and the virtual addresses
+	PhysicalAddressBits int `json:"physical_address_bits"`
+	VirtualAddressBits  int `json:"virtual_address_bits"`
+}
+
+func (i *X86Identification) String() string {
+	return fmt.Sprintf(
+		"family %d model %d stepping %d microcode %s",
+		i.Family,
+		i.Model,
+		i.Stepping,
+		i.Microcode,
+	)
+}
+
+// ArmIdentification identifies an Arm processor, as reported by its Main ID
+// Register
+type ArmIdentification struct {
+	// Implementer identifies the designer of the processor, e.g. 0x41 for
+	// Arm, and ImplementerName names it, when known
+	Implementer     int    `json:"implementer"`
+	ImplementerName string `json:"implementer_name,omitempty"`
+	// Architecture is the version of the architecture, e.g. 8
+	Architecture int `json:"architecture"`
+	// Variant and Revision are the major and minor revisions of the part
+	Variant int `json:"variant"`
+	// Part identifies the processor among those of the implementer, e.g.
+	// 0xd0c for a Neoverse-N1, and PartName names it, when known
+	Part     int    `json:"part"`
+	PartName string `json:"part_name,omitempty"`
+	Revision int    `json:"revision"`
+}
+
+func (i *ArmIdentification) String() string {
+	return fmt.Sprintf(
+		"implementer %#x part %#x (%s %s) r%dp%d",
+		i.Implementer,
+		i.Part,
+		i.ImplementerName,
+		i.PartName,
+		i.Variant,
+		i.Revision,
+	)
+}
+
+var (
+	armImplementerNames = map[int]string{
+		0x41: "ARM",
+		0x42: "Broadcom",
+		0x43: "Cavium",
+		0x46: "Fujitsu",
+		0x48: "HiSilicon",
+		0x4e: "NVIDIA",
+		0x50: "APM",
+		0x51: "Qualcomm",
+		0x61: "Apple",
+		0xc0: "Ampere",
+	}
+
+	armPartNames = map[int]map[int]string{
+		0x41: {
+			0xd03: "Cortex-A53",
+			0xd04: "Cortex-A35",
+			0xd05: "Cortex-A55",
+			0xd07: "Cortex-A57",
+			0xd08: "Cortex-A72",
+			0xd09: "Cortex-A73",
+			0xd0a: "Cortex-A75",
+			0xd0b: "Cortex-A76",
+			0xd0c: "Neoverse-N1",
+			0xd0d: "Cortex-A77",
+			0xd40: "Neoverse-V1",
+			0xd41: "Cortex-A78",
+			0xd44: "Cortex-X1",
+			0xd46: "Cortex-A510",
+			0xd47: "Cortex-A710",
+			0xd48: "Cortex-X2",
+			0xd49: "Neoverse-N2",
+			0xd4f: "Neoverse-V2",
+		},
+		0x43: {
+			0x0a1: "ThunderX",
+			0x0af: "ThunderX2",
+		},
+		0x46: {
+			0x001: "A64FX",
+		},
+		0x48: {
+			0xd01: "Kunpeng-920",
+		},
+		0x4e: {
+			0x004: "Carmel",
+		},
+		0x50: {
+			0x000: "X-Gene",
+		},
+		0xc0: {
+			0xac3: "Ampere-1",
+			0xac4: "Ampere-1a",
+		},
+	}
+)
+
+// ArmImplementerName returns the name of the supplied Arm implementer, e.g.
+// "ARM" for 0x41, or an empty string when unknown
+func ArmImplementerName(implementer int) string {
+	return armImplementerNames[implementer]
+}
+
+// ArmPartName returns the name of the supplied part of the supplied Arm
+// implementer, e.g. "Neoverse-N1" for the part 0xd0c of 0x41, or an empty
+// string when unknown
+func ArmPartName(implementer int, part int) string {
+	return armPartNames[implementer][part]
+}
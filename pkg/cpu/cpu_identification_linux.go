@@ -0,0 +1,74 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/unitutil"
+)
+
+// x86Identification returns a pointer to a X86Identification struct built
+// from the supplied /proc/cpuinfo attributes of a logical processor, or nil
+// when the processor is not an x86 one
+func x86Identification(attrs map[string]string) *X86Identification {
+	if _, ok := attrs["cpu family"]; !ok {
+		return nil
+	}
+	i := &X86Identification{
+		Family:    cpuinfoInt(attrs["cpu family"], 10),
+		Model:     cpuinfoInt(attrs["model"], 10),
+		Stepping:  cpuinfoInt(attrs["stepping"], 10),
+		Microcode: attrs["microcode"],
+	}
+	// The cache size comes as "55296 KB"
+	if fields := strings.Fields(attrs["cache size"]); len(fields) == 2 && fields[1] == "KB" {
+		if size, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			i.CacheSizeBytes = size * uint64(unitutil.KB)
+		}
+	}
+	if bogoMIPS, err := strconv.ParseFloat(attrs["bogomips"], 64); err == nil {
+		i.BogoMIPS = bogoMIPS
+	}
+	_, _ = fmt.Sscanf(
+		attrs["address sizes"],
+		"%d bits physical, %d bits virtual",
+		&i.PhysicalAddressBits,
+		&i.VirtualAddressBits,
+	)
+	return i
+}
+
+// armIdentification returns a pointer to a ArmIdentification struct built
+// from the supplied /proc/cpuinfo attributes of a logical processor, or nil
+// when the processor is not an Arm one
+func armIdentification(attrs map[string]string) *ArmIdentification {
+	if _, ok := attrs["CPU implementer"]; !ok {
+		return nil
+	}
+	i := &ArmIdentification{
+		Implementer:  cpuinfoInt(attrs["CPU implementer"], 0),
+		Architecture: cpuinfoInt(attrs["CPU architecture"], 10),
+		Variant:      cpuinfoInt(attrs["CPU variant"], 0),
+		Part:         cpuinfoInt(attrs["CPU part"], 0),
+		Revision:     cpuinfoInt(attrs["CPU revision"], 10),
+	}
+	i.ImplementerName = ArmImplementerName(i.Implementer)
+	i.PartName = ArmPartName(i.Implementer, i.Part)
+	return i
+}
+
+// cpuinfoInt returns the integer value of a /proc/cpuinfo attribute in the
+// supplied base, 0 meaning a "0x" prefixed hexadecimal value, or -1
+func cpuinfoInt(value string, base int) int {
+	v, err := strconv.ParseInt(value, base, 64)
+	if err != nil {
+		return -1
+	}
+	return int(v)
+}
@@ -11,19 +11,30 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/cpulist"
 	"github.com/jaypipes/ghw/pkg/linuxpath"
 	"github.com/jaypipes/ghw/pkg/util"
 )
 
 func (i *Info) load() error {
 	i.Processors = processorsGet(i.ctx)
+	paths := linuxpath.New(i.ctx)
+	i.Sets = cpuSets(i.ctx, paths)
+	i.Vulnerabilities = vulnerabilities(paths)
 	var totCores uint32
 	var totThreads uint32
+	types := logicalProcessorTypes(paths)
+	i.LogicalProcessors = logicalProcessors(paths, i.Processors, i.Sets)
 	for _, p := range i.Processors {
+		setCoreTypes(paths, p.Cores, types)
+		p.NumPerformanceCores, p.NumEfficiencyCores = CoreTypeCounts(p.Cores)
+		p.LogicalProcessors = processorLogicalProcessors(p, i.LogicalProcessors)
+		p.Frequency = summarizeFrequency(p.LogicalProcessors)
 		totCores += p.NumCores
 		totThreads += p.NumThreads
 	}
@@ -33,153 +44,302 @@ func (i *Info) load() error {
 }
 
 func processorsGet(ctx *context.Context) []*Processor {
-	procs := make([]*Processor, 0)
 	paths := linuxpath.New(ctx)
+	attrs, globalAttrs := cpuinfoAttrs(paths)
 
-	r, err := os.Open(paths.ProcCpuinfo)
-	if err != nil {
-		return nil
+	lps := sysfsTopology(paths)
+	if len(lps) == 0 {
+		// Without sysfs, e.g. in old snapshots, fall back to the topology
+		// found in /proc/cpuinfo, which is only complete on x86
+		lps = cpuinfoTopology(attrs)
 	}
-	defer util.SafeClose(r)
-
-	// An array of maps of attributes describing the logical processor
-	procAttrs := make([]map[string]string, 0)
-	curProcAttrs := make(map[string]string)
-
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			// Output of /proc/cpuinfo has a blank newline to separate logical
-			// processors, so here we collect up all the attributes we've
-			// collected for this logical processor block
-			procAttrs = append(procAttrs, curProcAttrs)
-			// Reset the current set of processor attributes...
-			curProcAttrs = make(map[string]string)
-			continue
+	procs := buildProcessors(lps)
+	for _, p := range procs {
+		first := attrs[p.Cores[0].LogicalProcessors[0]]
+		attr := func(keys ...string) string {
+			for _, key := range keys {
+				if v := first[key]; v != "" {
+					return v
+				}
+				if v := globalAttrs[key]; v != "" {
+					return v
+				}
+			}
+			return ""
 		}
-		parts := strings.Split(line, ":")
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		curProcAttrs[key] = value
-	}
+		p.Vendor = attr("vendor_id", "vendor")
+		// x86 has a "model name", POWER a "cpu", RISC-V an "uarch", and
+		// older arm kernels a global "Processor"
+		p.Model = attr("model name", "cpu", "uarch", "Processor")
+		// The flags field is a space-separated list of CPU capabilities,
+		// named "Features" on arm and "features" on s390x
+		p.setCapabilities(strings.Fields(attr("flags", "Features", "features")))
 
-	// Build a set of physical processor IDs which represent the physical
-	// package of the CPU
-	setPhysicalIDs := make(map[int]bool)
-	for _, attrs := range procAttrs {
-		pid, err := strconv.Atoi(attrs["physical id"])
-		if err != nil {
-			continue
+		p.X86 = x86Identification(first)
+		p.Arm = armIdentification(first)
+		if p.Arm != nil {
+			// arm64 kernels name neither the vendor nor the model
+			if p.Vendor == "" {
+				p.Vendor = p.Arm.ImplementerName
+			}
+			if p.Model == "" {
+				p.Model = p.Arm.PartName
+			}
+		}
+		if p.X86 != nil {
+			checkMicrocode(ctx, p, attrs)
 		}
-		setPhysicalIDs[pid] = true
 	}
+	return procs
+}
 
-	for pid := range setPhysicalIDs {
-		p := &Processor{
-			ID: pid,
-		}
-		// The indexes into the array of attribute maps for each logical
-		// processor within the physical processor
-		lps := make([]int, 0)
-		for x := range procAttrs {
-			lppid, err := strconv.Atoi(procAttrs[x]["physical id"])
-			if err != nil {
-				continue
-			}
-			if pid == lppid {
-				lps = append(lps, x)
+// checkMicrocode warns when the logical processors of the supplied processor
+// run different microcode revisions, e.g. after a failed late load, since
+// the revision reported for the processor is the one of its first logical
+// processor
+func checkMicrocode(ctx *context.Context, p *Processor, attrs map[int]map[string]string) {
+	for _, c := range p.Cores {
+		for _, lp := range c.LogicalProcessors {
+			microcode, ok := attrs[lp]["microcode"]
+			if ok && microcode != p.X86.Microcode {
+				ctx.Warn(
+					"processor %d: logical processor %d runs microcode %s, but logical processor %d runs %s",
+					p.ID,
+					lp,
+					microcode,
+					p.Cores[0].LogicalProcessors[0],
+					p.X86.Microcode,
+				)
+				return
 			}
 		}
-		first := procAttrs[lps[0]]
-		p.Model = first["model name"]
-		p.Vendor = first["vendor_id"]
-		numCores, err := strconv.Atoi(first["cpu cores"])
-		if err != nil {
+	}
+}
+
+// lpTopology describes where a logical processor sits in the topology of the
+// host system
+type lpTopology struct {
+	id        int
+	packageID int
+	dieID     int
+	clusterID int
+	coreID    int
+	// siblings identifies the logical processors sharing the same core, as
+	// formatted by cpulist.Format. It is empty when unknown, in which case
+	// the core is identified by its die, cluster and core IDs.
+	siblings string
+}
+
+// sysfsTopology returns the topology of the online logical processors, found
+// in /sys/devices/system/cpu/cpu$ID/topology. Offline logical processors
+// have no topology directory.
+func sysfsTopology(paths *linuxpath.Paths) []*lpTopology {
+	lps := make([]*lpTopology, 0)
+	entries, err := ioutil.ReadDir(paths.SysDevicesSystemCPU)
+	if err != nil {
+		return lps
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "cpu") {
 			continue
 		}
-		p.NumCores = uint32(numCores)
-		numThreads, err := strconv.Atoi(first["siblings"])
+		id, err := strconv.Atoi(name[3:])
 		if err != nil {
 			continue
 		}
-		p.NumThreads = uint32(numThreads)
+		tpath := filepath.Join(paths.SysDevicesSystemCPU, name, "topology")
+		if lp := readLPTopology(id, tpath); lp != nil {
+			lps = append(lps, lp)
+		}
+	}
+	return lps
+}
 
-		// The flags field is a space-separated list of CPU capabilities
-		p.Capabilities = strings.Split(first["flags"], " ")
+// readLPTopology returns the topology of the logical processor of the
+// supplied ID, found in the supplied topology directory, or nil when the
+// directory does not report the core of the logical processor
+func readLPTopology(id int, tpath string) *lpTopology {
+	coreID, err := strconv.Atoi(cpuAttr(tpath, "core_id"))
+	if err != nil {
+		return nil
+	}
+	lp := &lpTopology{
+		id:        id,
+		packageID: topologyID(tpath, "physical_package_id"),
+		dieID:     topologyID(tpath, "die_id"),
+		clusterID: topologyID(tpath, "cluster_id"),
+		coreID:    coreID,
+	}
+	// Some arm64 firmwares do not describe the packages
+	if lp.packageID < 0 {
+		lp.packageID = 0
+	}
+	// The list is formatted again, so that cores are told apart by the
+	// logical processors they hold rather than by the way the kernel spells
+	// them out, e.g. "0,1" or "0-1"
+	siblings, err := cpulist.Parse(cpuAttr(tpath, "thread_siblings_list"))
+	if err == nil && len(siblings) > 0 {
+		lp.siblings = cpulist.Format(siblings)
+	}
+	return lp
+}
 
-		cores := make([]*ProcessorCore, 0)
-		for _, lpidx := range lps {
-			lpid, err := strconv.Atoi(procAttrs[lpidx]["processor"])
-			if err != nil {
-				continue
-			}
-			coreID, err := strconv.Atoi(procAttrs[lpidx]["core id"])
-			if err != nil {
-				continue
-			}
-			var core *ProcessorCore
-			for _, c := range cores {
-				if c.ID == coreID {
-					c.LogicalProcessors = append(
-						c.LogicalProcessors,
-						lpid,
-					)
-					c.NumThreads = uint32(len(c.LogicalProcessors))
-					core = c
-				}
+// coreKey returns a key identifying the core of the logical processor. Core
+// IDs are only unique within a cluster on arm64 device-tree systems, and may
+// repeat across the dies of a package, so the cores are told apart by the
+// siblings of the logical processor, or else by their die, cluster and core
+// IDs.
+func (lp *lpTopology) coreKey() string {
+	key := lp.siblings
+	if key == "" {
+		key = fmt.Sprintf("%d/%d/%d", lp.dieID, lp.clusterID, lp.coreID)
+	}
+	return fmt.Sprintf("%d:%s", lp.packageID, key)
+}
+
+// topologyID returns the value of the supplied attribute of the topology
+// directory of a logical processor, or -1 when the kernel does not report it
+func topologyID(tpath string, attr string) int {
+	v, err := strconv.Atoi(cpuAttr(tpath, attr))
+	if err != nil {
+		return -1
+	}
+	return v
+}
+
+// cpuinfoTopology returns the topology of the logical processors described
+// by the supplied /proc/cpuinfo attributes. Logical processors without
+// "physical id" are put on package 0, and those without "core id" on a core
+// of their own.
+func cpuinfoTopology(attrs map[int]map[string]string) []*lpTopology {
+	lps := make([]*lpTopology, 0, len(attrs))
+	for id, lpAttrs := range attrs {
+		lp := &lpTopology{
+			id:        id,
+			dieID:     -1,
+			clusterID: -1,
+			coreID:    id,
+		}
+		if pid, err := strconv.Atoi(lpAttrs["physical id"]); err == nil {
+			lp.packageID = pid
+		}
+		if coreID, err := strconv.Atoi(lpAttrs["core id"]); err == nil {
+			lp.coreID = coreID
+		}
+		lps = append(lps, lp)
+	}
+	return lps
+}
+
+// buildProcessors returns the physical processor packages, sorted by ID,
+// holding the supplied logical processors. The cores of a package are
+// indexed in the order of their lowest logical processor ID.
+func buildProcessors(lps []*lpTopology) []*Processor {
+	sort.Slice(lps, func(i, j int) bool {
+		return lps[i].id < lps[j].id
+	})
+	procs := make([]*Processor, 0)
+	procsByID := make(map[int]*Processor)
+	cores := make(map[string]*ProcessorCore)
+	for _, lp := range lps {
+		p, ok := procsByID[lp.packageID]
+		if !ok {
+			p = &Processor{
+				ID:           lp.packageID,
+				Capabilities: make([]string, 0),
+				Cores:        make([]*ProcessorCore, 0),
 			}
-			if core == nil {
-				coreLps := make([]int, 1)
-				coreLps[0] = lpid
-				core = &ProcessorCore{
-					ID:                coreID,
-					Index:             len(cores),
-					NumThreads:        1,
-					LogicalProcessors: coreLps,
-				}
-				cores = append(cores, core)
+			procsByID[lp.packageID] = p
+			procs = append(procs, p)
+		}
+		key := lp.coreKey()
+		core, ok := cores[key]
+		if !ok {
+			core = &ProcessorCore{
+				ID:                lp.coreID,
+				Index:             len(p.Cores),
+				DieID:             lp.dieID,
+				ClusterID:         lp.clusterID,
+				LogicalProcessors: make([]int, 0),
 			}
+			cores[key] = core
+			p.Cores = append(p.Cores, core)
+			p.NumCores++
 		}
-		p.Cores = cores
-		procs = append(procs, p)
+		core.LogicalProcessors = append(core.LogicalProcessors, lp.id)
+		core.NumThreads++
+		p.NumThreads++
 	}
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].ID < procs[j].ID
+	})
 	return procs
 }
 
+// cpuinfoAttrs returns the attributes of the logical processors found in
+// /proc/cpuinfo, keyed by logical processor ID, along with the attributes
+// found outside of their blocks, e.g. "Hardware" on arm or "features" on
+// s390x.
+func cpuinfoAttrs(paths *linuxpath.Paths) (map[int]map[string]string, map[string]string) {
+	attrs := make(map[int]map[string]string)
+	globalAttrs := make(map[string]string)
+
+	r, err := os.Open(paths.ProcCpuinfo)
+	if err != nil {
+		return attrs, globalAttrs
+	}
+	defer util.SafeClose(r)
+
+	curAttrs := make(map[string]string)
+	// Output of /proc/cpuinfo has a blank newline to separate logical
+	// processors, so here we collect up all the attributes we've collected
+	// for the current block
+	endBlock := func() {
+		if id, err := strconv.Atoi(curAttrs["processor"]); err == nil {
+			attrs[id] = curAttrs
+		} else {
+			for key, value := range curAttrs {
+				globalAttrs[key] = value
+			}
+		}
+		curAttrs = make(map[string]string)
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			endBlock()
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		curAttrs[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	endBlock()
+	return attrs, globalAttrs
+}
+
 func CoresForNode(ctx *context.Context, nodeID int) ([]*ProcessorCore, error) {
 	// The /sys/devices/system/node/nodeX directory contains a subdirectory
 	// called 'cpuX' for each logical processor assigned to the node. Each of
-	// those subdirectories contains a topology subdirectory which has a
-	// core_id file that indicates the 0-based identifier of the physical core
-	// the logical processor (hardware thread) is on.
+	// those subdirectories contains a topology subdirectory describing the
+	// physical core the logical processor (hardware thread) is on. Cores are
+	// grouped the same way buildProcessors does, since core_id alone does
+	// not identify a core.
 	paths := linuxpath.New(ctx)
 	path := filepath.Join(
 		paths.SysDevicesSystemNode,
 		fmt.Sprintf("node%d", nodeID),
 	)
-	cores := make([]*ProcessorCore, 0)
-
-	findCoreByID := func(coreID int) *ProcessorCore {
-		for _, c := range cores {
-			if c.ID == coreID {
-				return c
-			}
-		}
-
-		c := &ProcessorCore{
-			ID:                coreID,
-			Index:             len(cores),
-			LogicalProcessors: make([]int, 0),
-		}
-		cores = append(cores, c)
-		return c
-	}
 
 	files, err := ioutil.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
+	lps := make([]*lpTopology, 0)
 	for _, file := range files {
 		filename := file.Name()
 		if !strings.HasPrefix(filename, "cpu") {
@@ -203,18 +363,48 @@ func CoresForNode(ctx *context.Context, nodeID int) ([]*ProcessorCore, error) {
 			)
 			continue
 		}
-		coreIDPath := filepath.Join(cpuPath, "topology", "core_id")
-		coreID := util.SafeIntFromFile(ctx, coreIDPath)
-		core := findCoreByID(coreID)
-		core.LogicalProcessors = append(
-			core.LogicalProcessors,
-			procID,
-		)
+		tpath := filepath.Join(cpuPath, "topology")
+		lp := readLPTopology(procID, tpath)
+		if lp == nil {
+			// Offline logical processors have no topology, and are put
+			// together on a core of ID -1
+			ctx.Warn("failed to read the topology of logical processor %d\n", procID)
+			lp = &lpTopology{
+				id:        procID,
+				dieID:     -1,
+				clusterID: -1,
+				coreID:    -1,
+			}
+		}
+		lps = append(lps, lp)
+	}
+	sort.Slice(lps, func(i, j int) bool {
+		return lps[i].id < lps[j].id
+	})
+
+	cores := make([]*ProcessorCore, 0)
+	coresByKey := make(map[string]*ProcessorCore)
+	for _, lp := range lps {
+		key := lp.coreKey()
+		c, ok := coresByKey[key]
+		if !ok {
+			c = &ProcessorCore{
+				ID:                lp.coreID,
+				Index:             len(cores),
+				DieID:             lp.dieID,
+				ClusterID:         lp.clusterID,
+				LogicalProcessors: make([]int, 0),
+			}
+			coresByKey[key] = c
+			cores = append(cores, c)
+		}
+		c.LogicalProcessors = append(c.LogicalProcessors, lp.id)
 	}
 
 	for _, c := range cores {
 		c.NumThreads = uint32(len(c.LogicalProcessors))
 	}
+	setCoreTypes(paths, cores, logicalProcessorTypes(paths))
 
 	return cores, nil
 }
@@ -0,0 +1,788 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package cpu
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+const cpuinfoTwoThreads = `processor	: 0
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) Gold 6338 CPU @ 2.00GHz
+physical id	: 0
+siblings	: 2
+core id		: 0
+cpu cores	: 1
+flags		: fpu vme de
+
+processor	: 1
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) Gold 6338 CPU @ 2.00GHz
+physical id	: 0
+siblings	: 2
+core id		: 0
+cpu cores	: 1
+flags		: fpu vme de
+
+`
+
+func TestCPUFrequency(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-freq-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Both logical processors share cpufreq policy0, run by intel_pstate
+	// with turbo enabled. C6 is disabled on cpu1 only.
+	cpuDir := filepath.Join(root, "sys", "devices", "system", "cpu")
+	writeFiles(t, root, map[string]string{
+		"proc/cpuinfo": cpuinfoTwoThreads,
+	})
+	writeFiles(t, cpuDir, map[string]string{
+		"cpufreq/policy0/cpuinfo_min_freq":              "800000\n",
+		"cpufreq/policy0/cpuinfo_max_freq":              "3200000\n",
+		"cpufreq/policy0/base_frequency":                "2000000\n",
+		"cpufreq/policy0/scaling_cur_freq":              "2900123\n",
+		"cpufreq/policy0/scaling_min_freq":              "800000\n",
+		"cpufreq/policy0/scaling_max_freq":              "3200000\n",
+		"cpufreq/policy0/scaling_driver":                "intel_pstate\n",
+		"cpufreq/policy0/scaling_governor":              "performance\n",
+		"cpufreq/policy0/scaling_available_governors":   "performance powersave\n",
+		"cpufreq/policy0/energy_performance_preference": "performance\n",
+		"intel_pstate/no_turbo":                         "0\n",
+		"cpu0/cpuidle/state0/name":                      "POLL\n",
+		"cpu0/cpuidle/state0/latency":                   "0\n",
+		"cpu0/cpuidle/state0/residency":                 "0\n",
+		"cpu0/cpuidle/state0/disable":                   "0\n",
+		"cpu0/cpuidle/state1/name":                      "C6\n",
+		"cpu0/cpuidle/state1/desc":                      "MWAIT 0x20\n",
+		"cpu0/cpuidle/state1/latency":                   "170\n",
+		"cpu0/cpuidle/state1/residency":                 "600\n",
+		"cpu0/cpuidle/state1/disable":                   "0\n",
+		"cpu1/cpuidle/state0/name":                      "POLL\n",
+		"cpu1/cpuidle/state0/disable":                   "0\n",
+		"cpu1/cpuidle/state1/name":                      "C6\n",
+		"cpu1/cpuidle/state1/disable":                   "1\n",
+	})
+	for _, lp := range []string{"cpu0", "cpu1"} {
+		if err = os.Symlink("../cpufreq/policy0", filepath.Join(cpuDir, lp, "cpufreq")); err != nil {
+			t.Fatalf("Unable to create cpufreq link: %v", err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Processors) != 1 {
+		t.Fatalf("Expected 1 processor, but got %d", len(info.Processors))
+	}
+	proc := info.Processors[0]
+	if len(proc.LogicalProcessors) != 2 {
+		t.Fatalf("Expected 2 logical processors, but got %d", len(proc.LogicalProcessors))
+	}
+
+	f := proc.LogicalProcessors[1].Frequency
+	if f == nil {
+		t.Fatalf("Expected cpu1 to report its frequency scaling")
+	}
+	if f.MinKHz != 800000 || f.MaxKHz != 3200000 || f.BaseKHz != 2000000 || f.CurrentKHz != 2900123 {
+		t.Fatalf("Unexpected cpu1 frequencies: %v", f)
+	}
+	if f.Driver != "intel_pstate" || f.Governor != "performance" || f.Boost != BOOST_STATE_ENABLED {
+		t.Fatalf("Expected cpu1 to run intel_pstate/performance with turbo, but got %v", f)
+	}
+	if !reflect.DeepEqual(f.AvailableGovernors, []string{"performance", "powersave"}) {
+		t.Fatalf("Expected performance and powersave governors, but got %v", f.AvailableGovernors)
+	}
+
+	states := proc.LogicalProcessors[0].IdleStates
+	if len(states) != 2 || states[1].Name != "C6" || states[1].LatencyUsec != 170 || states[1].ResidencyUsec != 600 {
+		t.Fatalf("Expected cpu0 to have POLL and C6 idle states, but got %v", states)
+	}
+
+	s := proc.Frequency
+	if s == nil {
+		t.Fatalf("Expected a frequency summary")
+	}
+	if !reflect.DeepEqual(s.Governors, []string{"performance"}) || s.Boost != BOOST_STATE_ENABLED {
+		t.Fatalf("Expected the performance governor with turbo enabled, but got %v", s)
+	}
+	if !reflect.DeepEqual(s.IdleStates, []string{"POLL", "C6"}) || !reflect.DeepEqual(s.DisabledIdleStates, []string{"C6"}) {
+		t.Fatalf("Expected C6 to be disabled, but got %v and %v", s.IdleStates, s.DisabledIdleStates)
+	}
+
+	// The logical processors of the package are only serialised once, in
+	// Info.LogicalProcessors
+	out, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if n := strings.Count(string(out), `"MWAIT 0x20"`); n != 1 {
+		t.Fatalf("Expected the C6 state of cpu0 to be serialised once, but got it %d times in %s", n, out)
+	}
+}
+
+// cpuinfoArm64 is the /proc/cpuinfo of a Graviton2 instance, which has no
+// "physical id", "core id" nor "model name", trimmed to two logical processors
+// and preceded by a line without a colon
+const cpuinfoArm64 = `CPU info
+processor	: 0
+BogoMIPS	: 243.75
+Features	: fp asimd evtstrm aes pmull sha1 sha2 crc32 atomics
+CPU implementer	: 0x41
+CPU architecture: 8
+CPU variant	: 0x3
+CPU part	: 0xd0c
+CPU revision	: 1
+
+processor	: 1
+BogoMIPS	: 243.75
+Features	: fp asimd evtstrm aes pmull sha1 sha2 crc32 atomics
+CPU implementer	: 0x41
+CPU architecture: 8
+CPU variant	: 0x3
+CPU part	: 0xd0c
+CPU revision	: 1
+`
+
+// cpuinfoX86 describes two hardware threads of an Ice Lake-SP core, which
+// run different microcode revisions
+const cpuinfoX86 = `processor	: 0
+vendor_id	: GenuineIntel
+cpu family	: 6
+model		: 106
+model name	: Intel(R) Xeon(R) Gold 6338 CPU @ 2.00GHz
+stepping	: 6
+microcode	: 0xd0003a5
+cache size	: 49152 KB
+physical id	: 0
+siblings	: 2
+core id		: 0
+cpu cores	: 1
+flags		: fpu vme de
+bogomips	: 4000.00
+address sizes	: 46 bits physical, 57 bits virtual
+
+processor	: 1
+vendor_id	: GenuineIntel
+cpu family	: 6
+model		: 106
+model name	: Intel(R) Xeon(R) Gold 6338 CPU @ 2.00GHz
+stepping	: 6
+microcode	: 0xd000390
+cache size	: 49152 KB
+physical id	: 0
+siblings	: 2
+core id		: 0
+cpu cores	: 1
+flags		: fpu vme de
+bogomips	: 4000.00
+address sizes	: 46 bits physical, 57 bits virtual
+
+`
+
+func TestCPUTopologyArm64(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-topology-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Four single-threaded cores in two clusters. The firmware does not
+	// describe the package, and cpu3 is offline, so has no topology.
+	writeFiles(t, root, map[string]string{
+		"proc/cpuinfo": cpuinfoArm64,
+	})
+	cpuDir := filepath.Join(root, "sys", "devices", "system", "cpu")
+	for lp, clusterID := range []string{"0", "0", "1"} {
+		tpath := fmt.Sprintf("cpu%d/topology/", lp)
+		writeFiles(t, cpuDir, map[string]string{
+			tpath + "physical_package_id":  "-1\n",
+			tpath + "die_id":               "-1\n",
+			tpath + "cluster_id":           clusterID + "\n",
+			tpath + "core_id":              fmt.Sprintf("%d\n", lp),
+			tpath + "thread_siblings_list": fmt.Sprintf("%d\n", lp),
+		})
+	}
+	writeFiles(t, cpuDir, map[string]string{
+		"cpu3/online": "0\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Processors) != 1 || info.TotalCores != 3 || info.TotalThreads != 3 {
+		t.Fatalf("Expected 1 processor with 3 cores and 3 threads, but got %v", info)
+	}
+	proc := info.Processors[0]
+	if proc.ID != 0 {
+		t.Fatalf("Expected processor 0, but got %d", proc.ID)
+	}
+	if !proc.HasCapability("atomics") {
+		t.Fatalf("Expected the Features of the processor as capabilities, but got %v", proc.Capabilities)
+	}
+	if proc.X86 != nil {
+		t.Fatalf("Expected no x86 identification, but got %v", proc.X86)
+	}
+	expectedArm := &ArmIdentification{
+		Implementer:     0x41,
+		ImplementerName: "ARM",
+		Architecture:    8,
+		Variant:         3,
+		Part:            0xd0c,
+		PartName:        "Neoverse-N1",
+		Revision:        1,
+	}
+	if !reflect.DeepEqual(proc.Arm, expectedArm) {
+		t.Fatalf("Expected arm identification %v, but got %v", expectedArm, proc.Arm)
+	}
+	if proc.Vendor != "ARM" || proc.Model != "Neoverse-N1" {
+		t.Fatalf("Expected an ARM Neoverse-N1, but got %s %s", proc.Vendor, proc.Model)
+	}
+	f := proc.Features
+	if f == nil || f.X86Level != 0 || !f.LSE || len(f.SVE) != 0 {
+		t.Fatalf("Expected LSE atomics without SVE, but got %v", f)
+	}
+	if !reflect.DeepEqual(f.Crypto, []string{"aes", "pmull", "sha1", "sha2"}) {
+		t.Fatalf("Expected the aes, pmull, sha1 and sha2 crypto extensions, but got %v", f.Crypto)
+	}
+	for x, core := range proc.Cores {
+		if core.Index != x || core.ID != x || !reflect.DeepEqual(core.LogicalProcessors, []int{x}) {
+			t.Fatalf("Expected core %d to run logical processor %d only, but got %v", x, x, core)
+		}
+		if core.DieID != -1 {
+			t.Fatalf("Expected an unknown die, but got %d", core.DieID)
+		}
+	}
+	if proc.Cores[1].ClusterID != 0 || proc.Cores[2].ClusterID != 1 {
+		t.Fatalf("Expected cores 1 and 2 in clusters 0 and 1, but got %d and %d", proc.Cores[1].ClusterID, proc.Cores[2].ClusterID)
+	}
+}
+
+func TestCPUTopologySMT(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-topology-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Two packages of one core with two threads each, the siblings being
+	// numbered apart. Both cores have core_id 0, and the second thread of
+	// each core spells out the list of its siblings differently.
+	cpuDir := filepath.Join(root, "sys", "devices", "system", "cpu")
+	for lp, pkg := range []int{0, 1, 0, 1} {
+		tpath := fmt.Sprintf("cpu%d/topology/", lp)
+		siblings := fmt.Sprintf("%d,%d\n", pkg, pkg+2)
+		if lp >= 2 {
+			siblings = fmt.Sprintf("%d-%d,%d\n", pkg, pkg, pkg+2)
+		}
+		writeFiles(t, cpuDir, map[string]string{
+			tpath + "physical_package_id":  fmt.Sprintf("%d\n", pkg),
+			tpath + "die_id":               "0\n",
+			tpath + "core_id":              "0\n",
+			tpath + "thread_siblings_list": siblings,
+		})
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Processors) != 2 || info.TotalCores != 2 || info.TotalThreads != 4 {
+		t.Fatalf("Expected 2 processors with 2 cores and 4 threads, but got %v", info)
+	}
+	for x, proc := range info.Processors {
+		if proc.ID != x || len(proc.Cores) != 1 {
+			t.Fatalf("Expected processor %d with 1 core, but got %v", x, proc)
+		}
+		if lps := proc.Cores[0].LogicalProcessors; !reflect.DeepEqual(lps, []int{x, x + 2}) {
+			t.Fatalf("Expected processor %d to run logical processors %d and %d, but got %v", x, x, x+2, lps)
+		}
+	}
+}
+
+func TestCoresForNodeClusters(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-topology-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Four single-threaded cores in two clusters of NUMA node 0, whose
+	// core_id restarts in each cluster, as on arm64 device-tree systems
+	cpuDir := filepath.Join(root, "sys", "devices", "system", "cpu")
+	nodeDir := filepath.Join(root, "sys", "devices", "system", "node", "node0")
+	if err = os.MkdirAll(nodeDir, os.ModePerm); err != nil {
+		t.Fatalf("Unable to create node directory: %v", err)
+	}
+	for lp := 0; lp < 4; lp++ {
+		name := fmt.Sprintf("cpu%d", lp)
+		writeFiles(t, cpuDir, map[string]string{
+			name + "/topology/physical_package_id":  "0\n",
+			name + "/topology/cluster_id":           fmt.Sprintf("%d\n", lp/2),
+			name + "/topology/core_id":              fmt.Sprintf("%d\n", lp%2),
+			name + "/topology/thread_siblings_list": fmt.Sprintf("%d\n", lp),
+		})
+		if err = os.Symlink(filepath.Join("..", "..", "cpu", name), filepath.Join(nodeDir, name)); err != nil {
+			t.Fatalf("Unable to create node cpu link: %v", err)
+		}
+	}
+
+	ctx := context.New(option.WithChroot(root), option.WithNullAlerter())
+	check := func() {
+		cores, err := CoresForNode(ctx, 0)
+		if err != nil {
+			t.Fatalf("Expected nil error, but got %v", err)
+		}
+		if len(cores) != 4 {
+			t.Fatalf("Expected 4 cores on node 0, but got %v", cores)
+		}
+		for x, core := range cores {
+			if core.Index != x || core.ID != x%2 || core.ClusterID != x/2 || !reflect.DeepEqual(core.LogicalProcessors, []int{x}) {
+				t.Fatalf("Expected core %d of cluster %d to run logical processor %d only, but got %v", x%2, x/2, x, core)
+			}
+		}
+	}
+	check()
+
+	// Without the list of siblings, the cores are told apart by their
+	// cluster
+	for lp := 0; lp < 4; lp++ {
+		if err = os.Remove(filepath.Join(cpuDir, fmt.Sprintf("cpu%d", lp), "topology", "thread_siblings_list")); err != nil {
+			t.Fatalf("Unable to remove thread siblings list: %v", err)
+		}
+	}
+	check()
+}
+
+func TestCPUCoreTypesHybrid(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-core-types-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Two P-cores with two threads each, then two single-threaded E-cores,
+	// all on NUMA node 0
+	siblings := []string{"0-1", "0-1", "2-3", "2-3", "4", "5"}
+	coreIDs := []string{"0", "0", "4", "4", "8", "9"}
+	cpuDir := filepath.Join(root, "sys", "devices", "system", "cpu")
+	nodeDir := filepath.Join(root, "sys", "devices", "system", "node", "node0")
+	for lp, sibling := range siblings {
+		tpath := fmt.Sprintf("cpu%d/topology/", lp)
+		writeFiles(t, cpuDir, map[string]string{
+			tpath + "physical_package_id":  "0\n",
+			tpath + "core_id":              coreIDs[lp] + "\n",
+			tpath + "thread_siblings_list": sibling + "\n",
+		})
+	}
+	writeFiles(t, filepath.Join(root, "sys", "devices"), map[string]string{
+		"cpu_core/cpus": "0-3\n",
+		"cpu_atom/cpus": "4-5\n",
+	})
+	if err = os.MkdirAll(nodeDir, os.ModePerm); err != nil {
+		t.Fatalf("Unable to create node directory: %v", err)
+	}
+	for lp := range siblings {
+		name := fmt.Sprintf("cpu%d", lp)
+		if err = os.Symlink(filepath.Join("..", "..", "cpu", name), filepath.Join(nodeDir, name)); err != nil {
+			t.Fatalf("Unable to create node cpu link: %v", err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Processors) != 1 {
+		t.Fatalf("Expected 1 processor, but got %d", len(info.Processors))
+	}
+	proc := info.Processors[0]
+	if proc.NumCores != 4 || proc.NumPerformanceCores != 2 || proc.NumEfficiencyCores != 2 {
+		t.Fatalf("Expected 2 performance and 2 efficiency cores, but got %v", proc.Cores)
+	}
+	expected := []CoreType{CORE_TYPE_PERFORMANCE, CORE_TYPE_PERFORMANCE, CORE_TYPE_EFFICIENCY, CORE_TYPE_EFFICIENCY}
+	for x, core := range proc.Cores {
+		if core.Type != expected[x] {
+			t.Fatalf("Expected core %d to be a %s core, but got %s", x, expected[x], core.Type)
+		}
+	}
+
+	ctx := context.New(option.WithChroot(root), option.WithNullAlerter())
+	cores, err := CoresForNode(ctx, 0)
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if perf, eff := CoreTypeCounts(cores); perf != 2 || eff != 2 {
+		t.Fatalf("Expected 2 performance and 2 efficiency cores on node 0, but got %d and %d", perf, eff)
+	}
+}
+
+func TestCPUCoreTypesCapacity(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-core-types-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// A big.LITTLE system with two LITTLE cores followed by two big ones
+	cpuDir := filepath.Join(root, "sys", "devices", "system", "cpu")
+	for lp, capacity := range []string{"446", "446", "1024", "1024"} {
+		lpPath := fmt.Sprintf("cpu%d/", lp)
+		writeFiles(t, cpuDir, map[string]string{
+			lpPath + "topology/physical_package_id":  "0\n",
+			lpPath + "topology/core_id":              fmt.Sprintf("%d\n", lp),
+			lpPath + "topology/thread_siblings_list": fmt.Sprintf("%d\n", lp),
+			lpPath + "cpu_capacity":                  capacity + "\n",
+		})
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	proc := info.Processors[0]
+	if proc.NumPerformanceCores != 2 || proc.NumEfficiencyCores != 2 {
+		t.Fatalf("Expected 2 performance and 2 efficiency cores, but got %v", proc.Cores)
+	}
+	if proc.Cores[0].Type != CORE_TYPE_EFFICIENCY || proc.Cores[0].Capacity != 446 {
+		t.Fatalf("Expected core 0 to be an efficiency core of capacity 446, but got %s and %d", proc.Cores[0].Type, proc.Cores[0].Capacity)
+	}
+	if proc.Cores[3].Type != CORE_TYPE_PERFORMANCE || proc.Cores[3].Capacity != 1024 {
+		t.Fatalf("Expected core 3 to be a performance core of capacity 1024, but got %s and %d", proc.Cores[3].Type, proc.Cores[3].Capacity)
+	}
+}
+
+func TestClassifyCoreTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[int]uint64
+		gap      float64
+		expected map[int]CoreType
+	}{
+		{
+			name:     "alike",
+			values:   map[int]uint64{0: 1024, 1: 1024},
+			gap:      minCapacityGap,
+			expected: map[int]CoreType{},
+		},
+		{
+			// CPPC highest performance values of an Arm server
+			name:     "near-equal",
+			values:   map[int]uint64{0: 1020, 1: 1024, 2: 1014},
+			gap:      minCapacityGap,
+			expected: map[int]CoreType{},
+		},
+		{
+			name:   "big.LITTLE",
+			values: map[int]uint64{0: 446, 1: 450, 2: 1024},
+			gap:    minCapacityGap,
+			expected: map[int]CoreType{
+				0: CORE_TYPE_EFFICIENCY,
+				1: CORE_TYPE_EFFICIENCY,
+				2: CORE_TYPE_PERFORMANCE,
+			},
+		},
+		{
+			name:   "tri-cluster",
+			values: map[int]uint64{0: 325, 1: 870, 2: 1024},
+			gap:    minCapacityGap,
+			expected: map[int]CoreType{
+				0: CORE_TYPE_EFFICIENCY,
+				1: CORE_TYPE_PERFORMANCE,
+				2: CORE_TYPE_PERFORMANCE,
+			},
+		},
+		{
+			name:   "three groups",
+			values: map[int]uint64{0: 325, 1: 640, 2: 1024},
+			gap:    minCapacityGap,
+			expected: map[int]CoreType{
+				0: CORE_TYPE_EFFICIENCY,
+				1: CORE_TYPE_UNKNOWN,
+				2: CORE_TYPE_PERFORMANCE,
+			},
+		},
+		{
+			// Intel SST-TF high priority cores
+			name:     "high priority",
+			values:   map[int]uint64{0: 2700000, 1: 3500000},
+			gap:      minFrequencyGap,
+			expected: map[int]CoreType{},
+		},
+	}
+	for _, test := range tests {
+		got := classifyCoreTypes(test.values, test.gap)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Fatalf("%s: expected %v, but got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestCPUSets(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-sets-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Eight logical processors, two of them offline, whose topology the
+	// kernel removed. The logical processors 2 and 3 are isolated for the
+	// packet processing threads of a NFV workload.
+	cpuDir := filepath.Join(root, "sys", "devices", "system", "cpu")
+	writeFiles(t, cpuDir, map[string]string{
+		"possible":   "0-7\n",
+		"present":    "0-7\n",
+		"online":     "0-5\n",
+		"offline":    "6-7\n",
+		"isolated":   "2-3\n",
+		"nohz_full":  "2-3\n",
+		"kernel_max": "8191\n",
+	})
+	for lp := 0; lp <= 5; lp++ {
+		tpath := fmt.Sprintf("cpu%d/topology/", lp)
+		writeFiles(t, cpuDir, map[string]string{
+			tpath + "physical_package_id":  "0\n",
+			tpath + "core_id":              fmt.Sprintf("%d\n", lp),
+			tpath + "thread_siblings_list": fmt.Sprintf("%d\n", lp),
+		})
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	sets := info.Sets
+	if sets == nil {
+		t.Fatalf("Expected non-nil Sets")
+	}
+	if !reflect.DeepEqual(sets.Possible, []int{0, 1, 2, 3, 4, 5, 6, 7}) || !reflect.DeepEqual(sets.Online, []int{0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("Expected logical processors 0-7 possible and 0-5 online, but got %v and %v", sets.Possible, sets.Online)
+	}
+	if !reflect.DeepEqual(sets.Offline, []int{6, 7}) {
+		t.Fatalf("Expected logical processors 6-7 offline, but got %v", sets.Offline)
+	}
+	if !reflect.DeepEqual(sets.Isolated, []int{2, 3}) || !reflect.DeepEqual(sets.NohzFull, []int{2, 3}) {
+		t.Fatalf("Expected logical processors 2-3 isolated and nohz_full, but got %v and %v", sets.Isolated, sets.NohzFull)
+	}
+	if sets.KernelMax != 8191 {
+		t.Fatalf("Expected kernel_max 8191, but got %d", sets.KernelMax)
+	}
+	if s := sets.String(); s != "online 0-5, offline 6-7, isolated 2-3, nohz_full 2-3" {
+		t.Fatalf("Unexpected sets string %q", s)
+	}
+
+	lps := info.LogicalProcessors
+	if len(lps) != 8 {
+		t.Fatalf("Expected 8 logical processors, but got %d", len(lps))
+	}
+	for x, lp := range lps {
+		if lp.ID != x || lp.Online != (x < 6) {
+			t.Fatalf("Expected only logical processors 6-7 to be offline, but got %v for %d", lp.Online, lp.ID)
+		}
+	}
+	plps := info.Processors[0].LogicalProcessors
+	if len(plps) != 6 || plps[5] != lps[5] {
+		t.Fatalf("Expected the 6 online logical processors in the processor, but got %v", plps)
+	}
+}
+
+func TestCPUVulnerabilities(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-vulnerabilities-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, filepath.Join(root, "sys", "devices", "system", "cpu", "vulnerabilities"), map[string]string{
+		"itlb_multihit":   "KVM: Mitigation: VMX disabled\n",
+		"mds":             "Vulnerable: Clear CPU buffers attempted, no microcode; SMT vulnerable\n",
+		"meltdown":        "Mitigation: PTI\n",
+		"mmio_stale_data": "Unknown: No mitigations\n",
+		"retbleed":        "Not affected\n",
+		"spectre_v2":      "Vulnerable\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	expected := []*Vulnerability{
+		{"itlb_multihit", VULNERABILITY_STATUS_MITIGATED, "VMX disabled", "KVM: Mitigation: VMX disabled"},
+		{"mds", VULNERABILITY_STATUS_VULNERABLE, "Clear CPU buffers attempted, no microcode; SMT vulnerable", "Vulnerable: Clear CPU buffers attempted, no microcode; SMT vulnerable"},
+		{"meltdown", VULNERABILITY_STATUS_MITIGATED, "PTI", "Mitigation: PTI"},
+		{"mmio_stale_data", VULNERABILITY_STATUS_UNKNOWN, "No mitigations", "Unknown: No mitigations"},
+		{"retbleed", VULNERABILITY_STATUS_NOT_AFFECTED, "", "Not affected"},
+		{"spectre_v2", VULNERABILITY_STATUS_VULNERABLE, "", "Vulnerable"},
+	}
+	if !reflect.DeepEqual(info.Vulnerabilities, expected) {
+		t.Fatalf("Expected vulnerabilities %v, but got %v", expected, info.Vulnerabilities)
+	}
+
+	if s := info.JSONString(false); !strings.Contains(s, `{"name":"retbleed","status":"not_affected","description":"Not affected"}`) {
+		t.Fatalf("Expected retbleed to be serialized as not affected, but got %s", s)
+	}
+}
+
+func TestCPUIdentificationX86(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-identification-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"proc/cpuinfo": cpuinfoX86,
+	})
+
+	var warnings bytes.Buffer
+	info, err := New(option.WithChroot(root), option.WithAlerter(log.New(&warnings, "", 0)))
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	proc := info.Processors[0]
+	if proc.Arm != nil {
+		t.Fatalf("Expected no arm identification, but got %v", proc.Arm)
+	}
+	expected := &X86Identification{
+		Family:              6,
+		Model:               106,
+		Stepping:            6,
+		Microcode:           "0xd0003a5",
+		CacheSizeBytes:      49152 * 1024,
+		BogoMIPS:            4000,
+		PhysicalAddressBits: 46,
+		VirtualAddressBits:  57,
+	}
+	if !reflect.DeepEqual(proc.X86, expected) {
+		t.Fatalf("Expected x86 identification %v, but got %v", expected, proc.X86)
+	}
+	if !strings.Contains(warnings.String(), "logical processor 1 runs microcode 0xd000390") {
+		t.Fatalf("Expected a warning about the microcode of logical processor 1, but got %q", warnings.String())
+	}
+}
+
+func TestCPUFeaturesX86(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_CPU"); ok {
+		t.Skip("Skipping CPU tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-cpu-features-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// The flags of a Zen 2 processor, which supports x86-64-v3 but no
+	// AVX-512, trimmed to the relevant ones
+	flags := "fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat " +
+		"pse36 clflush mmx fxsr sse sse2 ht syscall nx mmxext fxsr_opt pdpe1gb " +
+		"rdtscp lm constant_tsc pni pclmulqdq monitor ssse3 fma cx16 sse4_1 " +
+		"sse4_2 movbe popcnt aes xsave avx f16c rdrand lahf_lm cmp_legacy svm " +
+		"abm sse4a bmi1 avx2 smep bmi2 sha_ni"
+	writeFiles(t, root, map[string]string{
+		"proc/cpuinfo": "processor\t: 0\nvendor_id\t: AuthenticAMD\nflags\t\t: " + flags + "\n\n",
+	})
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	proc := info.Processors[0]
+	f := proc.Features
+	if f == nil || f.X86Level != 3 || f.X86LevelString() != "x86-64-v3" {
+		t.Fatalf("Expected x86-64-v3, but got %v", f)
+	}
+	if len(f.AVX512) != 0 || len(f.AMX) != 0 || f.Virtualization != "svm" || f.LSE {
+		t.Fatalf("Expected AMD-V without AVX-512 nor AMX, but got %v", f)
+	}
+	if !reflect.DeepEqual(f.Crypto, []string{"pclmulqdq", "aes", "sha_ni"}) {
+		t.Fatalf("Expected the pclmulqdq, aes and sha_ni crypto extensions, but got %v", f.Crypto)
+	}
+	if !proc.HasCapability("avx2") || proc.HasCapability("avx512f") {
+		t.Fatalf("Expected avx2 but not avx512f capabilities, but got %v", proc.Capabilities)
+	}
+
+	// Without lahf_lm, the processor falls back to the baseline level
+	writeFiles(t, root, map[string]string{
+		"proc/cpuinfo": "processor\t: 0\nflags\t\t: " + strings.Replace(flags, "lahf_lm ", "", 1) + "\n\n",
+	})
+	info, err = New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if level := info.Processors[0].Features.X86LevelString(); level != "x86-64" {
+		t.Fatalf("Expected x86-64, but got %q", level)
+	}
+}
+
+// writeFiles populates the supplied root directory with the supplied files,
+// keyed by path relative to root, creating parent directories as needed.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, contents := range files {
+		fp := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+			t.Fatalf("Unable to create directory for %q: %v", fp, err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(contents), 0644); err != nil {
+			t.Fatalf("Unable to write %q: %v", fp, err)
+		}
+	}
+}
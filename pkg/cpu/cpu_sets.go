@@ -0,0 +1,58 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/jaypipes/ghw/pkg/cpulist"
+)
+
+// Sets describes the sets of logical processors the kernel manages. Each set
+// is a sorted slice of logical processor IDs.
+type Sets struct {
+	// Possible contains the logical processors that may ever be brought
+	// online, hotpluggable ones included, and Present those currently
+	// present in the system
+	Possible []int `json:"possible"`
+	Present  []int `json:"present"`
+	// Online contains the logical processors the kernel schedules tasks on,
+	// and Offline the ones brought offline or beyond the limits of the
+	// kernel
+	Online  []int `json:"online"`
+	Offline []int `json:"offline"`
+	// onlineSet indexes the online logical processors for isOnline
+	onlineSet map[int]bool
+	// Isolated contains the logical processors removed from the scheduler's
+	// load balancing with the isolcpus kernel parameter, and NohzFull the
+	// ones running without the scheduler tick when a single task is
+	// runnable, set with the nohz_full kernel parameter
+	Isolated []int `json:"isolated"`
+	NohzFull []int `json:"nohz_full"`
+	// KernelMax is the highest logical processor ID the kernel supports, or
+	// -1 when unknown
+	KernelMax int `json:"kernel_max"`
+}
+
+// String returns a short string describing the Sets in the kernel's list
+// format
+func (s *Sets) String() string {
+	return fmt.Sprintf(
+		"online %s, offline %s, isolated %s, nohz_full %s",
+		formatSet(s.Online),
+		formatSet(s.Offline),
+		formatSet(s.Isolated),
+		formatSet(s.NohzFull),
+	)
+}
+
+func formatSet(ids []int) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	return cpulist.Format(ids)
+}
@@ -0,0 +1,70 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/cpulist"
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// cpuSets returns the sets of logical processors listed in
+// /sys/devices/system/cpu. Sets the kernel does not report, e.g. nohz_full
+// on kernels built without CONFIG_NO_HZ_FULL, are empty.
+func cpuSets(ctx *context.Context, paths *linuxpath.Paths) *Sets {
+	s := &Sets{
+		KernelMax: -1,
+	}
+	for name, set := range map[string]*[]int{
+		"possible":  &s.Possible,
+		"present":   &s.Present,
+		"online":    &s.Online,
+		"offline":   &s.Offline,
+		"isolated":  &s.Isolated,
+		"nohz_full": &s.NohzFull,
+	} {
+		*set = readCPUList(ctx, filepath.Join(paths.SysDevicesSystemCPU, name))
+	}
+	if len(s.Online) > 0 {
+		s.onlineSet = make(map[int]bool, len(s.Online))
+		for _, id := range s.Online {
+			s.onlineSet[id] = true
+		}
+	}
+	if kernelMax, err := strconv.Atoi(cpuAttr(paths.SysDevicesSystemCPU, "kernel_max")); err == nil {
+		s.KernelMax = kernelMax
+	}
+	return s
+}
+
+// readCPUList returns the logical processor IDs listed in the supplied file,
+// or an empty slice
+func readCPUList(ctx *context.Context, path string) []int {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return make([]int, 0)
+	}
+	ids, err := cpulist.Parse(string(contents))
+	if err != nil {
+		ctx.Warn("%s: %v", path, err)
+		return make([]int, 0)
+	}
+	return ids
+}
+
+// isOnline returns whether the supplied logical processor is online. Without
+// an online set, e.g. in old snapshots, the logical processors found in the
+// topology are deemed online.
+func (s *Sets) isOnline(id int) bool {
+	if s.onlineSet == nil {
+		return true
+	}
+	return s.onlineSet[id]
+}
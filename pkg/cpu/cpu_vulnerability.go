@@ -0,0 +1,104 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VulnerabilityStatus describes whether the processors are affected by a
+// hardware vulnerability, and whether the kernel mitigates it
+type VulnerabilityStatus int
+
+const (
+	VULNERABILITY_STATUS_UNKNOWN VulnerabilityStatus = iota
+	VULNERABILITY_STATUS_NOT_AFFECTED
+	VULNERABILITY_STATUS_VULNERABLE
+	VULNERABILITY_STATUS_MITIGATED
+)
+
+var (
+	vulnerabilityStatusString = map[VulnerabilityStatus]string{
+		VULNERABILITY_STATUS_UNKNOWN:      "Unknown",
+		VULNERABILITY_STATUS_NOT_AFFECTED: "Not affected",
+		VULNERABILITY_STATUS_VULNERABLE:   "Vulnerable",
+		VULNERABILITY_STATUS_MITIGATED:    "Mitigated",
+	}
+)
+
+func (s VulnerabilityStatus) String() string {
+	return vulnerabilityStatusString[s]
+}
+
+// MarshalJSON encodes the vulnerability status as its lowercased name, with
+// underscores instead of spaces, e.g. "not_affected"
+func (s VulnerabilityStatus) MarshalJSON() ([]byte, error) {
+	status := strings.Replace(s.String(), " ", "_", -1)
+	return []byte("\"" + strings.ToLower(status) + "\""), nil
+}
+
+// Vulnerability describes a hardware vulnerability of the processors, as
+// reported by the kernel
+type Vulnerability struct {
+	// Name is the name the kernel gave the vulnerability, e.g. "spectre_v2"
+	// or "mds"
+	Name   string              `json:"name"`
+	Status VulnerabilityStatus `json:"status"`
+	// Detail describes the mitigation in place, e.g. "PTI" for meltdown, or
+	// why the processors remain vulnerable, e.g. "SMT vulnerable". It is
+	// empty when the kernel gives no detail.
+	Detail string `json:"detail,omitempty"`
+	// Description is the status as reported by the kernel, e.g.
+	// "Mitigation: PTI"
+	Description string `json:"description"`
+}
+
+func (v *Vulnerability) String() string {
+	detail := ""
+	if v.Detail != "" {
+		detail = " (" + v.Detail + ")"
+	}
+	return fmt.Sprintf(
+		"%s: %s%s",
+		v.Name,
+		v.Status.String(),
+		detail,
+	)
+}
+
+// parseVulnerability returns a pointer to a Vulnerability struct describing
+// the supplied status reported by the kernel. The status starts with "Not
+// affected", "Vulnerable", "Mitigation" or "Unknown", possibly followed by a
+// detail, e.g. "Mitigation: Clear CPU buffers; SMT vulnerable". The statuses
+// of the vulnerabilities of the KVM hypervisor, like itlb_multihit, are
+// prefixed with "KVM: ".
+func parseVulnerability(name string, description string) *Vulnerability {
+	v := &Vulnerability{
+		Name:        name,
+		Description: description,
+	}
+	status := strings.TrimPrefix(description, "KVM: ")
+	for _, prefix := range []struct {
+		prefix string
+		status VulnerabilityStatus
+	}{
+		{"Not affected", VULNERABILITY_STATUS_NOT_AFFECTED},
+		{"Mitigation", VULNERABILITY_STATUS_MITIGATED},
+		{"Vulnerable", VULNERABILITY_STATUS_VULNERABLE},
+		{"Processor vulnerable", VULNERABILITY_STATUS_VULNERABLE},
+		{"Unknown", VULNERABILITY_STATUS_UNKNOWN},
+	} {
+		if strings.HasPrefix(status, prefix.prefix) {
+			v.Status = prefix.status
+			v.Detail = strings.TrimLeft(status[len(prefix.prefix):], ":;, ")
+			return v
+		}
+	}
+	v.Detail = status
+	return v
+}
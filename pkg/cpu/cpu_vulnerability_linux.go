@@ -0,0 +1,30 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+// vulnerabilities returns the hardware vulnerabilities of the processors,
+// sorted by name, found in /sys/devices/system/cpu/vulnerabilities. It is
+// empty on kernels predating the directory.
+func vulnerabilities(paths *linuxpath.Paths) []*Vulnerability {
+	out := make([]*Vulnerability, 0)
+	vpath := filepath.Join(paths.SysDevicesSystemCPU, "vulnerabilities")
+	entries, err := ioutil.ReadDir(vpath)
+	if err != nil {
+		return out
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		out = append(out, parseVulnerability(name, cpuAttr(vpath, name)))
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package cpulist parses and formats sets of logical processor IDs in the
+// list format the kernel uses in sysfs and on its command line, e.g.
+// "0-3,8-11" for the logical processors 0, 1, 2, 3, 8, 9, 10 and 11.
+package cpulist
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parse returns the sorted, distinct logical processor IDs of the supplied
+// list. Surrounding whitespace is ignored, and an empty list, as found in
+// /sys/devices/system/cpu/isolated when no logical processor is isolated,
+// returns an empty slice.
+func Parse(list string) ([]int, error) {
+	ids := make([]int, 0)
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return ids, nil
+	}
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(list, ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu list %q: %v", list, err)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid cpu list %q: %v", list, err)
+			}
+		}
+		if first < 0 || last < first {
+			return nil, fmt.Errorf("invalid cpu list %q: invalid range %q", list, item)
+		}
+		for id := first; id <= last; id++ {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// Format returns the supplied logical processor IDs in the list format,
+// consecutive IDs being collapsed into ranges, e.g. "0-3,8". It is the
+// inverse of Parse.
+func Format(ids []int) string {
+	sorted := make([]int, len(ids))
+	copy(sorted, ids)
+	sort.Ints(sorted)
+	items := make([]string, 0)
+	for x := 0; x < len(sorted); {
+		first := sorted[x]
+		last := first
+		for x++; x < len(sorted) && sorted[x] <= last+1; x++ {
+			last = sorted[x]
+		}
+		if first == last {
+			items = append(items, strconv.Itoa(first))
+		} else {
+			items = append(items, fmt.Sprintf("%d-%d", first, last))
+		}
+	}
+	return strings.Join(items, ",")
+}
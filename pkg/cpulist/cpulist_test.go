@@ -0,0 +1,61 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package cpulist_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/cpulist"
+)
+
+func TestParse(t *testing.T) {
+	tCases := []struct {
+		list     string
+		expected []int
+	}{
+		{list: "", expected: []int{}},
+		{list: "\n", expected: []int{}},
+		{list: "0\n", expected: []int{0}},
+		{list: "0-3,8-11", expected: []int{0, 1, 2, 3, 8, 9, 10, 11}},
+		{list: "0,2,4,6", expected: []int{0, 2, 4, 6}},
+		{list: "8,0-1,1", expected: []int{0, 1, 8}},
+	}
+	for _, tCase := range tCases {
+		ids, err := cpulist.Parse(tCase.list)
+		if err != nil {
+			t.Fatalf("Expected nil error parsing %q, but got %v", tCase.list, err)
+		}
+		if !reflect.DeepEqual(ids, tCase.expected) {
+			t.Fatalf("Expected %q to be %v, but got %v", tCase.list, tCase.expected, ids)
+		}
+	}
+
+	for _, list := range []string{"a", "0-", "3-1", "0,,1", "-1"} {
+		if _, err := cpulist.Parse(list); err == nil {
+			t.Fatalf("Expected an error parsing %q", list)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tCases := []struct {
+		ids      []int
+		expected string
+	}{
+		{ids: []int{}, expected: ""},
+		{ids: []int{5}, expected: "5"},
+		{ids: []int{0, 1}, expected: "0-1"},
+		{ids: []int{11, 0, 1, 2, 3, 8, 9, 10}, expected: "0-3,8-11"},
+		{ids: []int{0, 2, 2, 4}, expected: "0,2,4"},
+	}
+	for _, tCase := range tCases {
+		if list := cpulist.Format(tCase.ids); list != tCase.expected {
+			t.Fatalf("Expected %v to be %q, but got %q", tCase.ids, tCase.expected, list)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 // PathRoots holds the roots of all the filesystem subtrees
 // ghw wants to access.
 type PathRoots struct {
+	Dev  string
 	Etc  string
 	Proc string
 	Run  string
@@ -25,6 +26,7 @@ type PathRoots struct {
 // DefaultPathRoots return the canonical default value for PathRoots
 func DefaultPathRoots() PathRoots {
 	return PathRoots{
+		Dev:  "/dev",
 		Etc:  "/etc",
 		Proc: "/proc",
 		Run:  "/run",
@@ -37,6 +39,9 @@ func DefaultPathRoots() PathRoots {
 // allowing overrides of the canonical default paths.
 func PathRootsFromContext(ctx *context.Context) PathRoots {
 	roots := DefaultPathRoots()
+	if pathDev, ok := ctx.PathOverrides["/dev"]; ok {
+		roots.Dev = pathDev
+	}
 	if pathEtc, ok := ctx.PathOverrides["/etc"]; ok {
 		roots.Etc = pathEtc
 	}
@@ -56,19 +61,37 @@ func PathRootsFromContext(ctx *context.Context) PathRoots {
 }
 
 type Paths struct {
+	Dev                    string
 	VarLog                 string
 	ProcMeminfo            string
 	ProcCpuinfo            string
 	ProcMounts             string
+	ProcMountinfo          string
+	ProcSwaps              string
+	ProcSplKstatZFS        string
 	SysKernelMMHugepages   string
 	SysBlock               string
+	SysDevices             string
+	SysDevicesSystemCPU    string
 	SysDevicesSystemNode   string
 	SysDevicesSystemMemory string
 	SysBusPciDevices       string
 	SysClassDRM            string
 	SysClassDMI            string
 	SysClassNet            string
+	SysClassNVMe           string
+	SysClassFCHost         string
+	SysClassISCSISession   string
+	SysClassISCSIConn      string
+	SysFsBtrfs             string
+	SysModuleZFS           string
 	RunUdevData            string
+	// RunGHWMountUsage is only found in snapshots, recording the usage of
+	// the mounted filesystems at snapshot time
+	RunGHWMountUsage string
+	// RunGHWSmartctl is only found in snapshots, recording the smartctl
+	// output of each disk at snapshot time
+	RunGHWSmartctl string
 }
 
 // New returns a new Paths struct containing filepath fields relative to the
@@ -76,19 +99,33 @@ type Paths struct {
 func New(ctx *context.Context) *Paths {
 	roots := PathRootsFromContext(ctx)
 	return &Paths{
+		Dev:                    filepath.Join(ctx.Chroot, roots.Dev),
 		VarLog:                 filepath.Join(ctx.Chroot, roots.Var, "log"),
 		ProcMeminfo:            filepath.Join(ctx.Chroot, roots.Proc, "meminfo"),
 		ProcCpuinfo:            filepath.Join(ctx.Chroot, roots.Proc, "cpuinfo"),
 		ProcMounts:             filepath.Join(ctx.Chroot, roots.Proc, "self", "mounts"),
+		ProcMountinfo:          filepath.Join(ctx.Chroot, roots.Proc, "self", "mountinfo"),
+		ProcSwaps:              filepath.Join(ctx.Chroot, roots.Proc, "swaps"),
+		ProcSplKstatZFS:        filepath.Join(ctx.Chroot, roots.Proc, "spl", "kstat", "zfs"),
 		SysKernelMMHugepages:   filepath.Join(ctx.Chroot, roots.Sys, "kernel", "mm", "hugepages"),
 		SysBlock:               filepath.Join(ctx.Chroot, roots.Sys, "block"),
+		SysDevices:             filepath.Join(ctx.Chroot, roots.Sys, "devices"),
+		SysDevicesSystemCPU:    filepath.Join(ctx.Chroot, roots.Sys, "devices", "system", "cpu"),
 		SysDevicesSystemNode:   filepath.Join(ctx.Chroot, roots.Sys, "devices", "system", "node"),
 		SysDevicesSystemMemory: filepath.Join(ctx.Chroot, roots.Sys, "devices", "system", "memory"),
 		SysBusPciDevices:       filepath.Join(ctx.Chroot, roots.Sys, "bus", "pci", "devices"),
 		SysClassDRM:            filepath.Join(ctx.Chroot, roots.Sys, "class", "drm"),
 		SysClassDMI:            filepath.Join(ctx.Chroot, roots.Sys, "class", "dmi"),
 		SysClassNet:            filepath.Join(ctx.Chroot, roots.Sys, "class", "net"),
+		SysClassNVMe:           filepath.Join(ctx.Chroot, roots.Sys, "class", "nvme"),
+		SysClassFCHost:         filepath.Join(ctx.Chroot, roots.Sys, "class", "fc_host"),
+		SysClassISCSISession:   filepath.Join(ctx.Chroot, roots.Sys, "class", "iscsi_session"),
+		SysClassISCSIConn:      filepath.Join(ctx.Chroot, roots.Sys, "class", "iscsi_connection"),
+		SysFsBtrfs:             filepath.Join(ctx.Chroot, roots.Sys, "fs", "btrfs"),
+		SysModuleZFS:           filepath.Join(ctx.Chroot, roots.Sys, "module", "zfs"),
 		RunUdevData:            filepath.Join(ctx.Chroot, roots.Run, "udev", "data"),
+		RunGHWMountUsage:       filepath.Join(ctx.Chroot, roots.Run, "ghw", "mount-usage"),
+		RunGHWSmartctl:         filepath.Join(ctx.Chroot, roots.Run, "ghw", "smartctl"),
 	}
 }
 
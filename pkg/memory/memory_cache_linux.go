@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/cpulist"
 	"github.com/jaypipes/ghw/pkg/linuxpath"
 	"github.com/jaypipes/ghw/pkg/unitutil"
 )
@@ -79,11 +80,11 @@ func CachesForNode(ctx *context.Context, nodeID int) ([]*Cache, error) {
 
 			// The cache information is repeated for each node, so here, we
 			// just ensure that we only have a one Cache object for each
-			// unique combination of level, type and processor map
+			// unique combination of level, type and processor list
 			level := memoryCacheLevel(paths, nodeID, lpID, cacheIndex)
 			cacheType := memoryCacheType(paths, nodeID, lpID, cacheIndex)
-			sharedCpuMap := memoryCacheSharedCPUMap(paths, nodeID, lpID, cacheIndex)
-			cacheKey := fmt.Sprintf("%d-%d-%s", level, cacheType, sharedCpuMap)
+			sharedCpuList := memoryCacheSharedCPUList(paths, nodeID, lpID, cacheIndex)
+			cacheKey := fmt.Sprintf("%d-%d-%s", level, cacheType, sharedCpuList)
 
 			cache, exists := caches[cacheKey]
 			if !exists {
@@ -174,15 +175,20 @@ func memoryCacheType(paths *linuxpath.Paths, nodeID int, lpID int, cacheIndex in
 	}
 }
 
-func memoryCacheSharedCPUMap(paths *linuxpath.Paths, nodeID int, lpID int, cacheIndex int) string {
+func memoryCacheSharedCPUList(paths *linuxpath.Paths, nodeID int, lpID int, cacheIndex int) string {
 	scpuPath := filepath.Join(
 		paths.NodeCPUCacheIndex(nodeID, lpID, cacheIndex),
-		"shared_cpu_map",
+		"shared_cpu_list",
 	)
-	sharedCpuMap, err := ioutil.ReadFile(scpuPath)
+	sharedCpuList, err := ioutil.ReadFile(scpuPath)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
 		return ""
 	}
-	return string(sharedCpuMap[:len(sharedCpuMap)-1])
+	lps, err := cpulist.Parse(string(sharedCpuList))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", scpuPath, err)
+		return ""
+	}
+	return cpulist.Format(lps)
 }
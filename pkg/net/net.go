@@ -12,6 +12,7 @@ import (
 	"github.com/jaypipes/ghw/pkg/context"
 	"github.com/jaypipes/ghw/pkg/marshal"
 	"github.com/jaypipes/ghw/pkg/option"
+	"github.com/jaypipes/ghw/pkg/udev"
 )
 
 type NICCapability struct {
@@ -27,6 +28,10 @@ type NIC struct {
 	MTU          int              `json:"mtu"`
 	Capabilities []*NICCapability `json:"capabilities"`
 	PCIAddress   *string          `json:"pci_address,omitempty"`
+	// Udev is the entry of the interface in the udev database, with e.g.
+	// its ID_NET_NAME_* predictable names. It is nil when udev is not
+	// running.
+	Udev *udev.Device `json:"udev,omitempty"`
 	// TODO(fromani): add other hw addresses (USB) when we support them
 }
 
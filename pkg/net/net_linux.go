@@ -18,6 +18,7 @@ import (
 
 	"github.com/jaypipes/ghw/pkg/context"
 	"github.com/jaypipes/ghw/pkg/linuxpath"
+	"github.com/jaypipes/ghw/pkg/udev"
 )
 
 const (
@@ -76,6 +77,7 @@ func nics(ctx *context.Context) []*NIC {
 		}
 
 		nic.PCIAddress = netDevicePCIAddress(paths.SysClassNet, filename)
+		nic.Udev = netDeviceUdev(ctx, paths, filename)
 
 		nics = append(nics, nic)
 	}
@@ -186,6 +188,24 @@ func netDeviceMTU(paths *linuxpath.Paths, dev string) int {
 	return mtu
 }
 
+// netDeviceUdev returns the entry of the supplied network interface in the
+// udev database, which is keyed by interface index, or nil
+func netDeviceUdev(ctx *context.Context, paths *linuxpath.Paths, dev string) *udev.Device {
+	contents, err := ioutil.ReadFile(filepath.Join(paths.SysClassNet, dev, "ifindex"))
+	if err != nil {
+		return nil
+	}
+	ifindex, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return nil
+	}
+	ud, err := udev.ReadDevice(ctx, udev.NetworkInterfaceID(ifindex))
+	if err != nil {
+		return nil
+	}
+	return ud
+}
+
 func netDevicePCIAddress(netDevDir, netDevName string) *string {
 	// what we do here is not that hard in the end: we need to navigate the sysfs
 	// up to the directory belonging to the device backing the network interface.
@@ -133,6 +133,14 @@ type Option struct {
 	// PathOverrides optionally allows to override the default paths ghw uses internally
 	// to learn about the system resources.
 	PathOverrides PathOverrides
+
+	// EnableVirtualBlockDevices optionally requests ghw to report the loop,
+	// zram and ram block devices it skips by default.
+	EnableVirtualBlockDevices *bool
+
+	// EnableDiskHealth optionally requests ghw to read the SMART health of
+	// the disks, which it skips by default.
+	EnableDiskHealth *bool
 }
 
 // SnapshotOptions contains options for handling of ghw snapshots
@@ -188,6 +196,20 @@ func WithDisableTools() *Option {
 	return &Option{EnableTools: &false_}
 }
 
+// WithVirtualBlockDevices requests ghw to report loop, zram and ram devices
+// along with the other block devices.
+func WithVirtualBlockDevices() *Option {
+	true_ := true
+	return &Option{EnableVirtualBlockDevices: &true_}
+}
+
+// WithDiskHealth requests ghw to read the SMART health of the disks, calling
+// smartctl when external tools are enabled.
+func WithDiskHealth() *Option {
+	true_ := true
+	return &Option{EnableDiskHealth: &true_}
+}
+
 // PathOverrides is a map, keyed by the string name of a mount path, of override paths
 type PathOverrides map[string]string
 
@@ -221,6 +243,12 @@ func Merge(opts ...*Option) *Option {
 		if opt.PathOverrides != nil {
 			merged.PathOverrides = opt.PathOverrides
 		}
+		if opt.EnableVirtualBlockDevices != nil {
+			merged.EnableVirtualBlockDevices = opt.EnableVirtualBlockDevices
+		}
+		if opt.EnableDiskHealth != nil {
+			merged.EnableDiskHealth = opt.EnableDiskHealth
+		}
 	}
 	// Set the default value if missing from mergeOpts
 	if merged.Chroot == nil {
@@ -242,5 +270,13 @@ func Merge(opts ...*Option) *Option {
 		enabled := EnvOrDefaultTools()
 		merged.EnableTools = &enabled
 	}
+	if merged.EnableVirtualBlockDevices == nil {
+		disabled := false
+		merged.EnableVirtualBlockDevices = &disabled
+	}
+	if merged.EnableDiskHealth == nil {
+		disabled := false
+		merged.EnableDiskHealth = &disabled
+	}
 	return merged
 }
@@ -49,6 +49,10 @@ type Device struct {
 	// Topology node that the PCI device is affined to. Will be nil if the
 	// architecture is not NUMA.
 	Node *topology.Node `json:"node,omitempty"`
+	// LocalLogicalProcessors contains the IDs of the logical processors
+	// local to the device, i.e. attached to the same NUMA node on NUMA
+	// systems, and all of them otherwise
+	LocalLogicalProcessors []int `json:"local_logical_processors,omitempty"`
 }
 
 type devIdent struct {
@@ -65,6 +69,9 @@ type devMarshallable struct {
 	Class     devIdent `json:"class"`
 	Subclass  devIdent `json:"subclass"`
 	Interface devIdent `json:"programming_interface"`
+	// LocalLogicalProcessors contains the IDs of the logical processors
+	// local to the device
+	LocalLogicalProcessors []int `json:"local_logical_processors,omitempty"`
 }
 
 // NOTE(jaypipes) Device has a custom JSON marshaller because we don't want
@@ -99,6 +106,7 @@ func (d *Device) MarshalJSON() ([]byte, error) {
 			ID:   d.ProgrammingInterface.ID,
 			Name: d.ProgrammingInterface.Name,
 		},
+		LocalLogicalProcessors: d.LocalLogicalProcessors,
 	}
 	return json.Marshal(dm)
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/jaypipes/pcidb"
 
 	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/cpulist"
 	"github.com/jaypipes/ghw/pkg/linuxpath"
 	pciaddr "github.com/jaypipes/ghw/pkg/pci/address"
 	"github.com/jaypipes/ghw/pkg/topology"
@@ -67,6 +68,32 @@ func getDeviceRevision(ctx *context.Context, address string) string {
 	return strings.TrimSpace(string(revision))
 }
 
+// getDeviceLocalLogicalProcessors returns the IDs of the logical processors
+// local to the PCI device at the supplied address, found in its local_cpulist
+// file, or nil
+func getDeviceLocalLogicalProcessors(ctx *context.Context, address string) []int {
+	paths := linuxpath.New(ctx)
+	pciAddr := pciaddr.FromString(address)
+	if pciAddr == nil {
+		return nil
+	}
+	localCPUListPath := filepath.Join(
+		paths.SysBusPciDevices,
+		pciAddr.String(),
+		"local_cpulist",
+	)
+	localCPUList, err := ioutil.ReadFile(localCPUListPath)
+	if err != nil {
+		return nil
+	}
+	lps, err := cpulist.Parse(string(localCPUList))
+	if err != nil {
+		ctx.Warn("%s: %v", localCPUListPath, err)
+		return nil
+	}
+	return lps
+}
+
 func getDeviceNUMANode(ctx *context.Context, address string) *topology.Node {
 	paths := linuxpath.New(ctx)
 	pciAddr := AddressFromString(address)
@@ -293,6 +320,7 @@ func (info *Info) GetDevice(address string) *Device {
 
 	device := info.getDeviceFromModaliasInfo(address, modaliasInfo)
 	device.Revision = getDeviceRevision(info.ctx, address)
+	device.LocalLogicalProcessors = getDeviceLocalLogicalProcessors(info.ctx, address)
 	if info.arch == topology.ARCHITECTURE_NUMA {
 		device.Node = getDeviceNUMANode(info.ctx, address)
 	}
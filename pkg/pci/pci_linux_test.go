@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/jaypipes/ghw/pkg/context"
@@ -24,6 +25,7 @@ type pciTestCase struct {
 	addr     string
 	node     int
 	revision string
+	lps      []int
 }
 
 // nolint: gocyclo
@@ -91,6 +93,37 @@ func TestPCIDeviceRevision(t *testing.T) {
 	}
 }
 
+// nolint: gocyclo
+func TestPCIDeviceLocalLogicalProcessors(t *testing.T) {
+	info := pciTestSetup(t)
+
+	tCases := []pciTestCase{
+		{
+			addr: "0000:07:03.0",
+			lps:  []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+		},
+		{
+			addr: "0000:05:11.0",
+			lps:  []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22},
+		},
+		{
+			addr: "0000:05:00.1",
+			lps:  []int{1, 3, 5, 7, 9, 11, 13, 15, 17, 19, 21, 23},
+		},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.addr, func(t *testing.T) {
+			dev := info.GetDevice(tCase.addr)
+			if dev == nil {
+				t.Fatalf("got nil device for address %q", tCase.addr)
+			}
+			if !reflect.DeepEqual(dev.LocalLogicalProcessors, tCase.lps) {
+				t.Errorf("device %q got local logical processors %v expected %v", tCase.addr, dev.LocalLogicalProcessors, tCase.lps)
+			}
+		})
+	}
+}
+
 func TestPCIMarshalJSON(t *testing.T) {
 	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_PCI"); ok {
 		t.Skip("Skipping PCI tests.")
@@ -38,9 +38,14 @@ func CloneTreeInto(scratchDir string) error {
 // most notably PCI, is host-specific and unpredictable.
 func ExpectedCloneContent() []string {
 	fileSpecs := ExpectedCloneStaticContent()
+	fileSpecs = append(fileSpecs, ExpectedCloneCPUContent()...)
 	fileSpecs = append(fileSpecs, ExpectedCloneNetContent()...)
 	fileSpecs = append(fileSpecs, ExpectedClonePCIContent()...)
 	fileSpecs = append(fileSpecs, ExpectedCloneGPUContent()...)
+	fileSpecs = append(fileSpecs, ExpectedCloneNVMeContent()...)
+	fileSpecs = append(fileSpecs, ExpectedCloneFabricContent()...)
+	fileSpecs = append(fileSpecs, ExpectedClonePoolContent()...)
+	fileSpecs = append(fileSpecs, ExpectedCloneUdevContent()...)
 	return fileSpecs
 }
 
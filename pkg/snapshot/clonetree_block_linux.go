@@ -11,22 +11,27 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+var (
+	scsiAddressRegex = regexp.MustCompile(`^\d+:\d+:\d+:\d+$`)
+	scsiHostRegex    = regexp.MustCompile(`^host\d+$`)
+	ataPortRegex     = regexp.MustCompile(`^ata\d+$`)
+	sasEndDevRegex   = regexp.MustCompile(`^end_device-\d+(:\d+)+$`)
+)
+
 func createBlockDevices(buildDir string) error {
 	// Grab all the block device pseudo-directories from /sys/block symlinks
-	// (excluding loopback devices) and inject them into our build filesystem
-	// with all but the circular symlink'd subsystem directories
+	// and inject them into our build filesystem with all but the circular
+	// symlink'd subsystem directories
 	devLinks, err := ioutil.ReadDir("/sys/block")
 	if err != nil {
 		return err
 	}
 	for _, devLink := range devLinks {
 		dname := devLink.Name()
-		if strings.HasPrefix(dname, "loop") {
-			continue
-		}
 		devPath := filepath.Join("/sys/block", dname)
 		trace("processing block device %q\n", devPath)
 
@@ -70,6 +75,9 @@ func createBlockDevices(buildDir string) error {
 		if err = createBlockDeviceDir(linkTargetPath, srcDeviceDir); err != nil {
 			return err
 		}
+		if err = createBlockDeviceAncestors(buildDir, srcDeviceDir); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -142,35 +150,164 @@ func createBlockDeviceDir(buildDeviceDir string, srcDeviceDir string) error {
 	}
 	// There is a special file $DEVICE_DIR/queue/rotational that, for some hard
 	// drives, contains a 1 or 0 indicating whether the device is a spinning
-	// disk or not
-	srcQueueDir := filepath.Join(
-		srcDeviceDir,
-		"queue",
-	)
-	buildQueueDir := filepath.Join(
-		buildDeviceDir,
-		"queue",
-	)
-	err = os.MkdirAll(buildQueueDir, os.ModePerm)
+	// disk or not. The queue directory also holds the block sizes, I/O limits
+	// and scheduler settings of the device, so we grab all of its files.
+	if err = createBlockDeviceSubdir(buildDeviceDir, srcDeviceDir, "queue"); err != nil {
+		return err
+	}
+
+	// Device-mapper devices describe their mapping in the $DEVICE_DIR/dm
+	// directory
+	if err = createBlockDeviceSubdir(buildDeviceDir, srcDeviceDir, "dm"); err != nil {
+		return err
+	}
+	// Loop devices describe their backing file in the $DEVICE_DIR/loop
+	// directory
+	if err = createBlockDeviceSubdir(buildDeviceDir, srcDeviceDir, "loop"); err != nil {
+		return err
+	}
+	// md devices describe the array in the $DEVICE_DIR/md directory, with one
+	// $DEVICE_DIR/md/dev-$MEMBER subdirectory for each member device
+	if err = createMDDeviceDir(buildDeviceDir, srcDeviceDir); err != nil {
+		return err
+	}
+	// Block devices stacked on top of each other are linked together through
+	// the $DEVICE_DIR/slaves and $DEVICE_DIR/holders directories
+	for _, linkDir := range []string{"slaves", "holders"} {
+		if err = createBlockDeviceLinks(buildDeviceDir, srcDeviceDir, linkDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createBlockDeviceAncestors copies the SCSI device, SCSI host, SAS end
+// device and ATA port information found in the directories the supplied
+// block device directory is nested in, up to the /sys/devices root.
+func createBlockDeviceAncestors(buildDir string, srcDeviceDir string) error {
+	for dir := filepath.Dir(srcDeviceDir); strings.HasPrefix(dir, "/sys/devices/"); dir = filepath.Dir(dir) {
+		base := filepath.Base(dir)
+		subdir := ""
+		switch {
+		case ataPortRegex.MatchString(base):
+			if err := createATAPortDir(filepath.Join(buildDir, dir), dir); err != nil {
+				return err
+			}
+			continue
+		case scsiAddressRegex.MatchString(base):
+			// The SCSI device directory holds the SAS address of the disk
+			// among other attributes
+		case scsiHostRegex.MatchString(base):
+			subdir = filepath.Join("scsi_host", base)
+		case sasEndDevRegex.MatchString(base):
+			subdir = filepath.Join("sas_device", base)
+		default:
+			continue
+		}
+		buildAncestorDir := filepath.Join(buildDir, dir)
+		if err := createBlockDeviceSubdir(buildAncestorDir, dir, subdir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createATAPortDir copies the attributes of the supplied ATA port, and of the
+// links and devices behind it, e.g. ata1/ata_port/ata1,
+// ata1/link1/ata_link/link1 and ata1/link1/dev1.0/ata_device/dev1.0
+func createATAPortDir(buildPortDir string, srcPortDir string) error {
+	subdirs := []string{filepath.Join("ata_port", filepath.Base(srcPortDir))}
+	for _, pattern := range []string{"link*/ata_link/link*", "link*/dev*/ata_device/dev*"} {
+		matches, err := filepath.Glob(filepath.Join(srcPortDir, pattern))
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			subdirs = append(subdirs, strings.TrimPrefix(match, srcPortDir+string(os.PathSeparator)))
+		}
+	}
+	for _, subdir := range subdirs {
+		if err := createBlockDeviceSubdir(buildPortDir, srcPortDir, subdir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createBlockDeviceSubdir copies the regular files found in the supplied
+// subdirectory of a block device directory, if the subdirectory exists.
+func createBlockDeviceSubdir(buildDeviceDir string, srcDeviceDir string, subdir string) error {
+	srcSubdir := filepath.Join(srcDeviceDir, subdir)
+	files, err := ioutil.ReadDir(srcSubdir)
 	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	buildSubdir := filepath.Join(buildDeviceDir, subdir)
+	if err = os.MkdirAll(buildSubdir, os.ModePerm); err != nil {
 		return err
 	}
-	fp := filepath.Join(srcQueueDir, "rotational")
-	buf, err := ioutil.ReadFile(fp)
+	for _, f := range files {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		fp := filepath.Join(srcSubdir, f.Name())
+		buf, err := ioutil.ReadFile(fp)
+		if err != nil {
+			// some attributes are write-only (e.g. md/new_dev) or require
+			// privileges, and are of no interest to us anyway
+			trace("error reading %q: %v - skipped\n", fp, err)
+			continue
+		}
+		targetPath := filepath.Join(buildSubdir, f.Name())
+		trace("creating %s\n", targetPath)
+		if err = ioutil.WriteFile(targetPath, buf, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createMDDeviceDir(buildDeviceDir string, srcDeviceDir string) error {
+	if err := createBlockDeviceSubdir(buildDeviceDir, srcDeviceDir, "md"); err != nil {
+		return err
+	}
+	members, err := filepath.Glob(filepath.Join(srcDeviceDir, "md", "dev-*"))
 	if err != nil {
 		return err
 	}
-	targetPath := filepath.Join(buildQueueDir, "rotational")
-	trace("creating %s\n", targetPath)
-	f, err := os.Create(targetPath)
+	for _, member := range members {
+		memberDir := filepath.Join("md", filepath.Base(member))
+		if err = createBlockDeviceSubdir(buildDeviceDir, srcDeviceDir, memberDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createBlockDeviceLinks recreates the symlinks found in the supplied
+// subdirectory of a block device directory, if the subdirectory exists. The
+// link targets are kept verbatim: ghw only cares about the link names.
+func createBlockDeviceLinks(buildDeviceDir string, srcDeviceDir string, subdir string) error {
+	srcSubdir := filepath.Join(srcDeviceDir, subdir)
+	links, err := ioutil.ReadDir(srcSubdir)
 	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
 		return err
 	}
-	if _, err = f.Write(buf); err != nil {
+	buildSubdir := filepath.Join(buildDeviceDir, subdir)
+	if err = os.MkdirAll(buildSubdir, os.ModePerm); err != nil {
 		return err
 	}
-	f.Close()
-
+	for _, link := range links {
+		if err = copyLink(filepath.Join(srcSubdir, link.Name()), filepath.Join(buildSubdir, link.Name())); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -217,5 +354,7 @@ func createPartitionDir(buildPartitionDir string, srcPartitionDir string) error
 			f.Close()
 		}
 	}
-	return nil
+	// Partitions used by device-mapper or md devices list them in the
+	// $PARTITION_DIR/holders directory
+	return createBlockDeviceLinks(buildPartitionDir, srcPartitionDir, "holders")
 }
@@ -0,0 +1,67 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+import (
+	"path/filepath"
+)
+
+// ExpectedCloneCPUContent returns a slice of glob patterns pertaining to the
+// sets, the vulnerabilities, the frequency scaling, the idle states and the
+// core types of the logical processors. Only the patterns matching some
+// content on this host are returned, since most virtual machines have no
+// frequency scaling, idle states nor core types.
+func ExpectedCloneCPUContent() []string {
+	patterns := []string{
+		"/sys/devices/system/cpu/possible",
+		"/sys/devices/system/cpu/present",
+		"/sys/devices/system/cpu/online",
+		"/sys/devices/system/cpu/offline",
+		"/sys/devices/system/cpu/isolated",
+		"/sys/devices/system/cpu/nohz_full",
+		"/sys/devices/system/cpu/kernel_max",
+		"/sys/devices/system/cpu/vulnerabilities/*",
+		// the cpufreq subdirectory of each logical processor is a symlink
+		// to the directory of its policy
+		"/sys/devices/system/cpu/cpu*/cpufreq",
+		"/sys/devices/system/cpu/cpufreq/boost",
+		"/sys/devices/system/cpu/intel_pstate/no_turbo",
+		"/sys/devices/system/cpu/cpu*/cpuidle/state*/name",
+		"/sys/devices/system/cpu/cpu*/cpuidle/state*/desc",
+		"/sys/devices/system/cpu/cpu*/cpuidle/state*/latency",
+		"/sys/devices/system/cpu/cpu*/cpuidle/state*/residency",
+		"/sys/devices/system/cpu/cpu*/cpuidle/state*/disable",
+		// core types of hybrid processors
+		"/sys/devices/system/cpu/cpu*/cpu_capacity",
+		"/sys/devices/system/cpu/types/*/cpulist",
+		"/sys/devices/cpu_core/cpus",
+		"/sys/devices/cpu_atom/cpus",
+	}
+	for _, attr := range []string{
+		"cpuinfo_min_freq",
+		"cpuinfo_max_freq",
+		"base_frequency",
+		"amd_pstate_nominal_freq",
+		"scaling_cur_freq",
+		"scaling_min_freq",
+		"scaling_max_freq",
+		"scaling_driver",
+		"scaling_governor",
+		"scaling_available_governors",
+		"energy_performance_preference",
+		"boost",
+	} {
+		patterns = append(patterns, filepath.Join("/sys/devices/system/cpu/cpufreq/policy*", attr))
+	}
+	var fileSpecs []string
+	for _, pattern := range patterns {
+		if matches, err := filepath.Glob(pattern); err == nil && len(matches) > 0 {
+			fileSpecs = append(fileSpecs, pattern)
+		}
+	}
+	return fileSpecs
+}
@@ -0,0 +1,35 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+// ExpectedCloneFabricContent returns a slice of glob patterns pertaining to
+// the Fibre Channel ports and iSCSI sessions ghw cares about. The disks
+// reached through them are cloned along with the other block devices.
+func ExpectedCloneFabricContent() []string {
+	fcHostEntries := []string{
+		"fabric_name",
+		"node_name",
+		"port_name",
+		"port_state",
+		"speed",
+	}
+	sessionEntries := []string{
+		"initiatorname",
+		"state",
+		"targetname",
+	}
+	connEntries := []string{
+		"address",
+		"persistent_address",
+		"persistent_port",
+		"port",
+	}
+
+	fileSpecs := cloneContentByClass("fc_host", fcHostEntries, filterNone, filterNone)
+	fileSpecs = append(fileSpecs, cloneContentByClass("iscsi_session", sessionEntries, filterNone, filterNone)...)
+	return append(fileSpecs, cloneContentByClass("iscsi_connection", connEntries, filterNone, filterNone)...)
+}
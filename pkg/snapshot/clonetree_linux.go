@@ -23,7 +23,13 @@ func setupScratchDir(scratchDir string) error {
 		}
 	}
 
-	return createBlockDevices(scratchDir)
+	if err := createBlockDevices(scratchDir); err != nil {
+		return err
+	}
+	if err := createMountUsage(scratchDir); err != nil {
+		return err
+	}
+	return createSmartctlOutput(scratchDir)
 }
 
 // ExpectedCloneStaticContent return a slice of glob patterns which represent the pseudofiles
@@ -34,6 +40,8 @@ func ExpectedCloneStaticContent() []string {
 		"/proc/cpuinfo",
 		"/proc/meminfo",
 		"/proc/self/mounts",
+		"/proc/self/mountinfo",
+		"/proc/swaps",
 		"/sys/devices/system/cpu/cpu*/cache/index*/*",
 		"/sys/devices/system/cpu/cpu*/topology/*",
 		"/sys/devices/system/memory/block_size_bytes",
@@ -0,0 +1,77 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// createMountUsage records the usage of the filesystems mounted on block
+// devices, as reported by statfs(2), into the $BUILD_DIR/run/ghw/mount-usage
+// file. Unlike the pseudofiles we copy, the usage of a filesystem cannot be
+// found out from an unpacked snapshot.
+//
+// Each line of the file describes a mount point, escaped like in
+// /proc/self/mounts, followed by the block size, the total, free and
+// available blocks, and the total and free inodes.
+func createMountUsage(buildDir string) error {
+	mounts, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		// we should not import context, hence we can't Warn()
+		trace("error reading mounts: %v - skipped\n", err)
+		return nil
+	}
+	defer mounts.Close()
+
+	var lines []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(mounts)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// like ghw, only care about the filesystems mounted from block
+		// devices
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/") || seen[fields[1]] {
+			continue
+		}
+		seen[fields[1]] = true
+		mountPoint := strings.NewReplacer(
+			"\\011", "\t", "\\012", "\n", "\\040", " ", "\\\\", "\\",
+		).Replace(fields[1])
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(mountPoint, &st); err != nil {
+			trace("error calling statfs on %q: %v - skipped\n", mountPoint, err)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(
+			"%s %d %d %d %d %d %d\n",
+			fields[1], st.Bsize, st.Blocks, st.Bfree, st.Bavail, st.Files, st.Ffree,
+		))
+	}
+
+	usageDir := filepath.Join(buildDir, "run", "ghw")
+	if err := os.MkdirAll(usageDir, os.ModePerm); err != nil {
+		return err
+	}
+	usagePath := filepath.Join(usageDir, "mount-usage")
+	trace("creating %s\n", usagePath)
+	f, err := os.Create(usagePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err = f.WriteString(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
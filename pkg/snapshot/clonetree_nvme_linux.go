@@ -0,0 +1,29 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+// ExpectedCloneNVMeContent returns a slice of glob patterns pertaining to the
+// NVMe controllers ghw cares about. The namespace block devices themselves
+// are cloned along with the other block devices; here we only need the
+// controller attributes and the multipath path devices, which are hidden
+// from /sys/block.
+func ExpectedCloneNVMeContent() []string {
+	ctrlEntries := []string{
+		"address",
+		"cntlid",
+		"device",
+		"firmware_rev",
+		"model",
+		"serial",
+		"state",
+		"subsysnqn",
+		"transport",
+		"nvme*c*n*/ana_state",
+	}
+
+	return cloneContentByClass("nvme", ctrlEntries, filterNone, filterNone)
+}
@@ -92,6 +92,11 @@ func scanPCIDeviceRoot(root string) (fileSpecs []string, pciRoots []string) {
 		for _, perNetEntry := range perDevEntries {
 			fileSpecs = append(fileSpecs, filepath.Join(pciEntry, perNetEntry))
 		}
+		// Only devices bound to a driver have a driver link
+		driverLink := filepath.Join(pciEntry, "driver")
+		if _, err := os.Lstat(driverLink); err == nil {
+			fileSpecs = append(fileSpecs, driverLink)
+		}
 
 		if isPCIBridge(entryPath) {
 			trace("adding new PCI root %q\n", entryName)
@@ -0,0 +1,36 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+import (
+	"path/filepath"
+)
+
+// ExpectedClonePoolContent returns a slice of glob patterns pertaining to the
+// btrfs filesystems and ZFS pools ghw cares about. Only the patterns matching
+// some content on this host are returned, since most hosts have neither.
+func ExpectedClonePoolContent() []string {
+	patterns := []string{
+		"/sys/fs/btrfs/*/label",
+		"/sys/fs/btrfs/*/devices/*",
+		"/sys/fs/btrfs/*/allocation/*/total_bytes",
+		"/sys/fs/btrfs/*/allocation/*/bytes_used",
+		"/sys/fs/btrfs/*/allocation/*/disk_total",
+		"/sys/fs/btrfs/*/allocation/*/disk_used",
+		// the profile of the block groups is the name of a subdirectory
+		"/sys/fs/btrfs/*/allocation/*/*/used_bytes",
+		"/proc/spl/kstat/zfs/*/state",
+		"/sys/module/zfs/version",
+	}
+	var fileSpecs []string
+	for _, pattern := range patterns {
+		if matches, err := filepath.Glob(pattern); err == nil && len(matches) > 0 {
+			fileSpecs = append(fileSpecs, pattern)
+		}
+	}
+	return fileSpecs
+}
@@ -0,0 +1,61 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/jaypipes/ghw/pkg/block/smartctl"
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+// createSmartctlOutput records the `smartctl --json -a` output of each disk
+// into the $BUILD_DIR/run/ghw/smartctl/$DEVICE.json file, so that the health
+// of the disks can be replayed from the snapshot. Nothing is recorded when
+// smartctl is not installed or external tools are disabled.
+func createSmartctlOutput(buildDir string) error {
+	if !option.EnvOrDefaultTools() {
+		return nil
+	}
+	smartctlPath, err := exec.LookPath("smartctl")
+	if err != nil {
+		trace("smartctl not found - skipped\n")
+		return nil
+	}
+	devLinks, err := ioutil.ReadDir("/sys/block")
+	if err != nil {
+		return err
+	}
+	outDir := filepath.Join(buildDir, "run", "ghw", "smartctl")
+	if err = os.MkdirAll(outDir, os.ModePerm); err != nil {
+		return err
+	}
+	for _, devLink := range devLinks {
+		dname := devLink.Name()
+		// like ghw, only ask physical drives for their SMART data
+		if !smartctl.HasSMART(dname) {
+			continue
+		}
+		out, err := smartctl.Run(smartctlPath, filepath.Join("/dev", dname), time.Now().Add(smartctl.Timeout))
+		if err != nil {
+			trace("smartctl failed for %q: %v\n", dname, err)
+		}
+		if len(out) == 0 {
+			continue
+		}
+		outPath := filepath.Join(outDir, dname+".json")
+		trace("creating %s\n", outPath)
+		if err = ioutil.WriteFile(outPath, out, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
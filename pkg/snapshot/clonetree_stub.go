@@ -21,6 +21,10 @@ func ExpectedCloneStaticContent() []string {
 	return []string{}
 }
 
+func ExpectedCloneCPUContent() []string {
+	return []string{}
+}
+
 func ExpectedCloneGPUContent() []string {
 	return []string{}
 }
@@ -29,6 +33,22 @@ func ExpectedCloneNetContent() []string {
 	return []string{}
 }
 
+func ExpectedCloneNVMeContent() []string {
+	return []string{}
+}
+
+func ExpectedCloneFabricContent() []string {
+	return []string{}
+}
+
+func ExpectedClonePoolContent() []string {
+	return []string{}
+}
+
+func ExpectedCloneUdevContent() []string {
+	return []string{}
+}
+
 func ExpectedClonePCIContent() []string {
 	return []string{}
 }
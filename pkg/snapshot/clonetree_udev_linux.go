@@ -0,0 +1,34 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+import (
+	"path/filepath"
+)
+
+// ExpectedCloneUdevContent returns a slice of glob patterns pertaining to the
+// udev database, which holds the models, serial numbers and /dev symlinks
+// of the devices. It is empty on hosts not running udev, like most
+// containers.
+func ExpectedCloneUdevContent() []string {
+	// the entries of block and character devices, network interfaces and
+	// devices without device nodes. Temporary files start with a dot and are
+	// left out.
+	patterns := []string{
+		"/run/udev/data/b*",
+		"/run/udev/data/c*",
+		"/run/udev/data/n*",
+		"/run/udev/data/+*",
+	}
+	var fileSpecs []string
+	for _, pattern := range patterns {
+		if matches, err := filepath.Glob(pattern); err == nil && len(matches) > 0 {
+			fileSpecs = append(fileSpecs, pattern)
+		}
+	}
+	return fileSpecs
+}
@@ -55,10 +55,14 @@ func (a Architecture) MarshalJSON() ([]byte, error) {
 // memory caching available to the single physical processor package's physical
 // processor cores
 type Node struct {
-	ID        int                  `json:"id"`
-	Cores     []*cpu.ProcessorCore `json:"cores"`
-	Caches    []*memory.Cache      `json:"caches"`
-	Distances []int                `json:"distances"`
+	ID    int                  `json:"id"`
+	Cores []*cpu.ProcessorCore `json:"cores"`
+	// NumPerformanceCores and NumEfficiencyCores are the number of cores of
+	// each type on hybrid processors, and zero otherwise
+	NumPerformanceCores uint32          `json:"total_performance_cores"`
+	NumEfficiencyCores  uint32          `json:"total_efficiency_cores"`
+	Caches              []*memory.Cache `json:"caches"`
+	Distances           []int           `json:"distances"`
 }
 
 func (n *Node) String() string {
@@ -55,6 +55,7 @@ func topologyNodes(ctx *context.Context) []*Node {
 			return nodes
 		}
 		node.Cores = cores
+		node.NumPerformanceCores, node.NumEfficiencyCores = cpu.CoreTypeCounts(cores)
 		caches, err := memory.CachesForNode(ctx, nodeID)
 		if err != nil {
 			ctx.Warn("failed to determine caches for node: %s\n", err)
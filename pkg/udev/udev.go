@@ -0,0 +1,195 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package udev
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/marshal"
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+// Device describes the entry of a device in the udev database
+type Device struct {
+	// ID is the name of the entry in the udev database: "b" or "c" followed
+	// by the major:minor numbers of block and character devices, e.g.
+	// "b8:0", "n" followed by the index of network interfaces, e.g. "n2",
+	// and "+" followed by the subsystem and the kernel name of other devices,
+	// e.g. "+pci:0000:00:1f.2"
+	ID string `json:"id"`
+	// Properties contains the properties udev attached to the device, e.g.
+	// ID_MODEL, ID_FS_UUID or ID_PART_ENTRY_NAME
+	Properties map[string]string `json:"properties"`
+	// Links contains the paths of the symlinks udev created for the device,
+	// e.g. "/dev/disk/by-id/wwn-0x5000c500a1b2c3d4"
+	Links []string `json:"links,omitempty"`
+	// Tags contains the tags attached to the device, e.g. "systemd"
+	Tags []string `json:"tags,omitempty"`
+}
+
+func (d *Device) String() string {
+	return fmt.Sprintf(
+		"%s (%d properties, %d links)",
+		d.ID,
+		len(d.Properties),
+		len(d.Links),
+	)
+}
+
+// Property returns the value of the supplied property of the device, or an
+// empty string. It may be called on a nil Device.
+func (d *Device) Property(key string) string {
+	if d == nil {
+		return ""
+	}
+	return d.Properties[key]
+}
+
+// LookupProperty returns the value of the supplied property of the device,
+// and whether the device has that property, even with an empty value. It may
+// be called on a nil Device.
+func (d *Device) LookupProperty(key string) (string, bool) {
+	if d == nil {
+		return "", false
+	}
+	val, ok := d.Properties[key]
+	return val, ok
+}
+
+// LinksIn returns the symlinks of the device found in the supplied
+// directory, e.g. "/dev/disk/by-id". It may be called on a nil Device.
+func (d *Device) LinksIn(dir string) []string {
+	out := make([]string, 0)
+	if d == nil {
+		return out
+	}
+	for _, link := range d.Links {
+		if filepath.Dir(link) == dir {
+			out = append(out, link)
+		}
+	}
+	return out
+}
+
+// BlockDeviceID returns the ID of the udev database entry of the block device
+// with the supplied major:minor numbers, e.g. "8:0"
+func BlockDeviceID(majorMinor string) string {
+	return "b" + majorMinor
+}
+
+// NetworkInterfaceID returns the ID of the udev database entry of the network
+// interface with the supplied index
+func NetworkInterfaceID(ifindex int) string {
+	return "n" + strconv.Itoa(ifindex)
+}
+
+// SubsystemDeviceID returns the ID of the udev database entry of a device
+// without a device node, given its subsystem and its kernel name, e.g. "pci"
+// and "0000:00:1f.2"
+func SubsystemDeviceID(subsystem string, name string) string {
+	return "+" + subsystem + ":" + name
+}
+
+// Parse returns a pointer to a Device struct describing the contents of the
+// udev database entry with the supplied ID. Each line of an entry starts with
+// a letter describing the kind of record, followed by a colon:
+//
+// S:disk/by-id/wwn-0x5000c500a1b2c3d4
+// E:ID_MODEL=ST4000NM0035
+// G:systemd
+//
+// S records are symlinks relative to /dev, E records properties and G
+// records tags. Other records are internal to udev.
+func Parse(id string, data string) *Device {
+	d := &Device{
+		ID:         id,
+		Properties: make(map[string]string),
+		Links:      make([]string, 0),
+		Tags:       make([]string, 0),
+	}
+	for _, line := range strings.Split(data, "\n") {
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		value := line[2:]
+		switch line[0] {
+		case 'S':
+			d.Links = append(d.Links, filepath.Join("/dev", value))
+		case 'E':
+			if s := strings.SplitN(value, "=", 2); len(s) == 2 {
+				d.Properties[s[0]] = s[1]
+			}
+		case 'G':
+			d.Tags = append(d.Tags, value)
+		}
+	}
+	sort.Strings(d.Links)
+	return d
+}
+
+// Info describes the devices found in the udev database of the host system
+type Info struct {
+	ctx     *context.Context
+	Devices []*Device `json:"devices"`
+}
+
+// New returns a pointer to an Info struct that contains the entries of the
+// udev database of the host system
+func New(opts ...*option.Option) (*Info, error) {
+	return NewWithContext(context.New(opts...))
+}
+
+// NewWithContext returns a pointer to an Info struct that contains the
+// entries of the udev database of the host system. Use this function when you
+// want to consume the udev package from another package (e.g. block)
+func NewWithContext(ctx *context.Context) (*Info, error) {
+	info := &Info{ctx: ctx}
+	if err := ctx.Do(info.load); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Device returns the entry of the udev database with the supplied ID, or nil
+func (i *Info) Device(id string) *Device {
+	for _, d := range i.Devices {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func (i *Info) String() string {
+	return fmt.Sprintf(
+		"udev (%d devices)",
+		len(i.Devices),
+	)
+}
+
+// simple private struct used to encapsulate udev information in a top-level
+// "udev" YAML/JSON map/object key
+type udevPrinter struct {
+	Info *Info `json:"udev"`
+}
+
+// YAMLString returns a string with the udev information formatted as YAML
+// under a top-level "udev:" key
+func (i *Info) YAMLString() string {
+	return marshal.SafeYAML(i.ctx, udevPrinter{i})
+}
+
+// JSONString returns a string with the udev information formatted as JSON
+// under a top-level "udev:" key
+func (i *Info) JSONString(indent bool) string {
+	return marshal.SafeJSON(i.ctx, udevPrinter{i}, indent)
+}
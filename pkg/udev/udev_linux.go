@@ -0,0 +1,50 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package udev
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jaypipes/ghw/pkg/context"
+	"github.com/jaypipes/ghw/pkg/linuxpath"
+)
+
+func (i *Info) load() error {
+	paths := linuxpath.New(i.ctx)
+	i.Devices = make([]*Device, 0)
+	entries, err := ioutil.ReadDir(paths.RunUdevData)
+	if err != nil {
+		i.ctx.Warn("failed to read the udev database: %s\n", err)
+		return nil
+	}
+	for _, entry := range entries {
+		// udev writes its entries to temporary files whose names start with
+		// a dot before renaming them
+		id := entry.Name()
+		if !entry.Mode().IsRegular() || id[0] == '.' {
+			continue
+		}
+		if d, err := readDevice(paths, id); err == nil {
+			i.Devices = append(i.Devices, d)
+		}
+	}
+	return nil
+}
+
+// ReadDevice returns a pointer to a Device struct describing the entry of the
+// udev database with the supplied ID, e.g. "b8:0"
+func ReadDevice(ctx *context.Context, id string) (*Device, error) {
+	return readDevice(linuxpath.New(ctx), id)
+}
+
+func readDevice(paths *linuxpath.Paths, id string) (*Device, error) {
+	data, err := ioutil.ReadFile(filepath.Join(paths.RunUdevData, id))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(id, string(data)), nil
+}
@@ -0,0 +1,109 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// +build linux
+
+package udev
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/ghw/pkg/option"
+)
+
+func TestUdevDatabase(t *testing.T) {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_UDEV"); ok {
+		t.Skip("Skipping udev tests.")
+	}
+
+	root, err := ioutil.TempDir("", "ghw-udev-testing-*")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	data := filepath.Join(root, "run", "udev", "data")
+	if err = os.MkdirAll(data, os.ModePerm); err != nil {
+		t.Fatalf("Unable to create udev database directory: %v", err)
+	}
+	files := map[string]string{
+		"b8:0": "S:disk/by-path/pci-0000:00:1f.2-ata-1\n" +
+			"S:disk/by-id/ata-ST4000NM0035-1V4107_ZC11A2B3\n" +
+			"S:disk/by-id/wwn-0x5000c500a1b2c3d4\n" +
+			"L:0\n" +
+			"I:4310243\n" +
+			"E:ID_ATA=1\n" +
+			"E:ID_MODEL=ST4000NM0035-1V4107\n" +
+			"E:ID_SERIAL_SHORT=ZC11A2B3\n" +
+			"E:ID_PART_TABLE_TYPE=gpt\n" +
+			"E:ID_WWN=\n" +
+			"G:systemd\n" +
+			"Q:systemd\n" +
+			"V:1\n",
+		"n2": "I:2847120\n" +
+			"E:ID_NET_NAME_PATH=enp3s0\n" +
+			"E:ID_NET_NAME_MAC=enx0cc47a123456\n" +
+			"G:systemd\n",
+		"+pci:0000:00:1f.2": "I:1845120\n" +
+			"E:ID_VENDOR_FROM_DATABASE=Intel Corporation\n",
+		".#b8:16": "E:ID_MODEL=half-written\n",
+	}
+	for name, contents := range files {
+		if err = ioutil.WriteFile(filepath.Join(data, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("Unable to write %q: %v", name, err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil error, but got %v", err)
+	}
+	if len(info.Devices) != 3 {
+		t.Fatalf("Expected 3 devices, but got %d", len(info.Devices))
+	}
+
+	disk := info.Device(BlockDeviceID("8:0"))
+	if disk == nil {
+		t.Fatalf("Expected an entry for block device 8:0")
+	}
+	if disk.Property("ID_MODEL") != "ST4000NM0035-1V4107" || len(disk.Properties) != 5 {
+		t.Fatalf("Expected the 5 properties of sda, but got %v", disk.Properties)
+	}
+	if wwn, ok := disk.LookupProperty("ID_WWN"); !ok || wwn != "" {
+		t.Fatalf("Expected sda to have an empty ID_WWN property")
+	}
+	if _, ok := disk.LookupProperty("ID_SERIAL"); ok {
+		t.Fatalf("Expected sda to have no ID_SERIAL property")
+	}
+	expectedLinks := []string{
+		"/dev/disk/by-id/ata-ST4000NM0035-1V4107_ZC11A2B3",
+		"/dev/disk/by-id/wwn-0x5000c500a1b2c3d4",
+	}
+	if links := disk.LinksIn("/dev/disk/by-id"); !reflect.DeepEqual(links, expectedLinks) {
+		t.Fatalf("Expected by-id links %v, but got %v", expectedLinks, links)
+	}
+	if len(disk.Links) != 3 || !reflect.DeepEqual(disk.Tags, []string{"systemd"}) {
+		t.Fatalf("Expected 3 links and the systemd tag, but got %v and %v", disk.Links, disk.Tags)
+	}
+
+	nic := info.Device(NetworkInterfaceID(2))
+	if nic == nil || nic.Property("ID_NET_NAME_PATH") != "enp3s0" {
+		t.Fatalf("Expected the entry of interface 2 to name it enp3s0, but got %v", nic)
+	}
+	pci := info.Device(SubsystemDeviceID("pci", "0000:00:1f.2"))
+	if pci == nil || pci.Property("ID_VENDOR_FROM_DATABASE") != "Intel Corporation" {
+		t.Fatalf("Expected the entry of PCI device 0000:00:1f.2, but got %v", pci)
+	}
+
+	var missing *Device
+	if _, ok := missing.LookupProperty("ID_MODEL"); ok || missing.Property("ID_MODEL") != "" || len(missing.LinksIn("/dev/disk/by-id")) != 0 {
+		t.Fatalf("Expected a nil device to have no properties and no links")
+	}
+}
@@ -0,0 +1,26 @@
+// +build !linux
+
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package udev
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/jaypipes/ghw/pkg/context"
+)
+
+func (i *Info) load() error {
+	return errors.New("udev load not implemented on " + runtime.GOOS)
+}
+
+// ReadDevice returns a pointer to a Device struct describing the entry of the
+// udev database with the supplied ID, e.g. "b8:0"
+func ReadDevice(ctx *context.Context, id string) (*Device, error) {
+	return nil, errors.New("ReadDevice not implemented on " + runtime.GOOS)
+}